@@ -0,0 +1,26 @@
+package main
+
+import (
+	"time"
+
+	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/logger"
+)
+
+// runAbandonedCartJob runs the abandoned cart job once. A panic is logged
+// rather than taking the API down with it.
+func runAbandonedCartJob(abandonedCartUseCase *usecases.AbandonedCartUseCase, logger *logger.Logger) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("Abandoned cart job panicked: %v", r)
+		}
+	}()
+
+	run, err := abandonedCartUseCase.Run(time.Now())
+	if err != nil {
+		logger.Errorf("Abandoned cart job: %v", err)
+	}
+	if run != nil {
+		logger.Infof("Abandoned cart job: %d abandoned, %d notified, %d recovered, %d purged", run.Abandoned, run.Notified, run.Recovered, run.Purged)
+	}
+}
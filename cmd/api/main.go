@@ -2,15 +2,24 @@ package main
 
 import (
 	"log"
+	"time"
 
+	"prototype-fiber/internal/domain/entities"
 	"prototype-fiber/internal/infrastructure/cache"
 	"prototype-fiber/internal/infrastructure/database"
+	"prototype-fiber/internal/infrastructure/inventory"
+	"prototype-fiber/internal/infrastructure/mail"
+	"prototype-fiber/internal/infrastructure/search"
+	"prototype-fiber/internal/infrastructure/storage"
 	"prototype-fiber/internal/interfaces/http/handlers"
+	"prototype-fiber/internal/interfaces/http/problem"
 	"prototype-fiber/internal/interfaces/http/routes"
 	"prototype-fiber/internal/interfaces/repositories"
 	"prototype-fiber/internal/usecases"
 	"prototype-fiber/pkg/config"
 	"prototype-fiber/pkg/logger"
+	"prototype-fiber/pkg/password"
+	"prototype-fiber/pkg/tokens"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -38,64 +47,190 @@ func main() {
 	logger.Info("Connected to PostgreSQL database")
 
 	// Initialize Redis
+	var sessionCache entities.SessionCache
+	var suggestionCache entities.SuggestionCache
 	redisClient, err := cache.NewRedisClient(cfg.Redis)
 	if err != nil {
 		logger.Warn("Failed to connect to Redis:", err)
 	} else {
-		_ = redisClient
+		sessionCache = cache.NewSessionCache(redisClient)
+		suggestionCache = cache.NewSuggestionCache(redisClient)
 		logger.Info("Connected to Redis")
 	}
 
+	// Initialize JWT signing keys
+	keySet, err := tokens.NewKeySet(cfg.JWT, cfg.App.Environment)
+	if err != nil {
+		log.Fatal("Failed to load JWT keys:", err)
+	}
+	if cfg.JWT.KeysDir == "" {
+		logger.Warn("JWT_KEYS_DIR not set, using an ephemeral signing key")
+	}
+
+	// Initialize password hashing
+	passwordService, err := password.NewServiceFromConfig(cfg.Password)
+	if err != nil {
+		log.Fatal("Invalid password configuration:", err)
+	}
+	password.SetDefault(passwordService)
+	passwordPolicy := password.NewPolicy(cfg.Password.MinLength, cfg.Password.MaxLength)
+
 	// Initialize repositories
 	userRepo := repositories.NewUserRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	impersonationLogRepo := repositories.NewImpersonationLogRepository(db)
 	productRepo := repositories.NewProductRepository(db)
+	variantRepo := repositories.NewProductVariantRepository(db)
+	categoryRepo := repositories.NewCategoryRepository(db)
 	cartRepo := repositories.NewCartRepository(db)
 	orderRepo := repositories.NewOrderRepository(db)
+	mediaRepo := repositories.NewProductMediaRepository(db)
+	importJobRepo := repositories.NewImportJobRepository(db)
+	stockMovementRepo := repositories.NewStockMovementRepository(db)
+	warehouseRepo := repositories.NewWarehouseRepository(db)
+	stockAlertRepo := repositories.NewStockAlertRepository(db)
+	backInStockRepo := repositories.NewBackInStockRepository(db)
+	reviewRepo := repositories.NewReviewRepository(db)
+	wishlistRepo := repositories.NewWishlistRepository(db)
+	abandonedCartRepo := repositories.NewAbandonedCartRepository(db)
+
+	// Initialize product search; product writes keep the index in sync
+	searchIndex, err := search.New(cfg.Search, db, productRepo)
+	if err != nil {
+		log.Fatal("Invalid search configuration:", err)
+	}
+	productRepo = search.NewSyncedProductRepository(productRepo, searchIndex, logger)
+	variantRepo = search.NewSyncedVariantRepository(variantRepo, productRepo, searchIndex, logger)
+	categoryRepo = search.NewSyncedCategoryRepository(categoryRepo, productRepo, searchIndex, logger)
+
+	// Stock writes raise low-stock alerts and back-in-stock notifications
+	stockWatcher := inventory.NewWatcher(productRepo, stockAlertRepo, backInStockRepo, inventory.NewLogNotifier(logger), logger)
+	productRepo = inventory.NewWatchedProductRepository(productRepo, stockWatcher)
+	variantRepo = inventory.NewWatchedVariantRepository(variantRepo, stockWatcher)
+
+	// Initialize media storage
+	blobStorage, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatal("Invalid storage configuration:", err)
+	}
+
+	// Initialize mail delivery
+	mailer, err := mail.New(cfg.Mail)
+	if err != nil {
+		log.Fatal("Invalid mail configuration:", err)
+	}
 
 	// Initialize use cases
-	userUseCase := usecases.NewUserUseCase(userRepo, cfg.JWT)
-	productUseCase := usecases.NewProductUseCase(productRepo)
-	cartUseCase := usecases.NewCartUseCase(cartRepo, productRepo)
-	orderUseCase := usecases.NewOrderUseCase(orderRepo, cartRepo, productRepo)
+	sessionUseCase := usecases.NewSessionUseCase(sessionRepo, sessionCache, cfg.Session.LastSeenInterval)
+	userUseCase := usecases.NewUserUseCase(userRepo, sessionUseCase, keySet, passwordPolicy)
+	impersonationUseCase := usecases.NewImpersonationUseCase(userRepo, impersonationLogRepo, sessionUseCase, keySet, cfg.Session.ImpersonationTTL)
+	productUseCase := usecases.NewProductUseCase(productRepo, variantRepo, categoryRepo, searchIndex, suggestionCache, blobStorage)
+	productImportUseCase := usecases.NewProductImportUseCase(productUseCase, importJobRepo, cfg.Import.MaxUploadSize, logger)
+	if failed, err := productImportUseCase.FailInterruptedJobs(); err != nil {
+		logger.Errorf("Failing interrupted import jobs: %v", err)
+	} else if failed > 0 {
+		logger.Warnf("Marked %d import jobs interrupted by a restart as failed", failed)
+	}
+	inventoryUseCase := usecases.NewInventoryUseCase(stockMovementRepo, productRepo)
+	warehouseUseCase := usecases.NewWarehouseUseCase(warehouseRepo, productRepo)
+	stockAlertUseCase := usecases.NewStockAlertUseCase(stockAlertRepo, backInStockRepo, productRepo)
+	reviewUseCase := usecases.NewReviewUseCase(reviewRepo, productRepo, orderRepo, userRepo)
+	categoryUseCase := usecases.NewCategoryUseCase(categoryRepo)
+	mediaUseCase := usecases.NewMediaUseCase(mediaRepo, productRepo, blobStorage, cfg.Media.MaxUploadSize, cfg.Media.ThumbnailSizes, logger)
+	cartUseCase := usecases.NewCartUseCase(cartRepo, productRepo, keySet, cfg.Cart.GuestTTL)
+	wishlistUseCase := usecases.NewWishlistUseCase(wishlistRepo, productRepo, cartUseCase)
+	allocationPolicy, err := usecases.NewAllocationPolicy(cfg.Allocation.Strategy, cfg.Allocation.AllowSplit)
+	if err != nil {
+		log.Fatal("Invalid allocation configuration:", err)
+	}
+	orderUseCase := usecases.NewOrderUseCase(orderRepo, cartRepo, productRepo, warehouseRepo, allocationPolicy)
+	abandonedCartUseCase := usecases.NewAbandonedCartUseCase(abandonedCartRepo, cartRepo, mailer, keySet, cfg.Cart.AbandonAfter, cfg.Cart.Retention, cfg.Cart.RecoveryURL)
+
+	// The embedded search index lives in memory and starts empty
+	if cfg.Search.Engine == search.EngineEmbedded {
+		indexed, err := productUseCase.ReindexSearch()
+		if err != nil {
+			log.Fatal("Failed to build search index:", err)
+		}
+		logger.Infof("Indexed %d products in the embedded search index", indexed)
+	}
+
+	// Look for abandoned carts in the background
+	go func() {
+		for range time.Tick(cfg.Cart.AbandonCheckInterval) {
+			runAbandonedCartJob(abandonedCartUseCase, logger)
+		}
+	}()
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(userUseCase)
+	authHandler := handlers.NewAuthHandler(userUseCase, cartUseCase, logger)
 	userHandler := handlers.NewUserHandler(userUseCase)
 	productHandler := handlers.NewProductHandler(productUseCase)
+	productImportHandler := handlers.NewProductImportHandler(productImportUseCase)
+	inventoryHandler := handlers.NewInventoryHandler(inventoryUseCase)
+	warehouseHandler := handlers.NewWarehouseHandler(warehouseUseCase)
+	stockAlertHandler := handlers.NewStockAlertHandler(stockAlertUseCase)
+	reviewHandler := handlers.NewReviewHandler(reviewUseCase)
+	wishlistHandler := handlers.NewWishlistHandler(wishlistUseCase)
+	categoryHandler := handlers.NewCategoryHandler(categoryUseCase)
+	mediaHandler := handlers.NewMediaHandler(mediaUseCase)
 	cartHandler := handlers.NewCartHandler(cartUseCase)
+	abandonedCartHandler := handlers.NewAbandonedCartHandler(abandonedCartUseCase)
 	orderHandler := handlers.NewOrderHandler(orderUseCase)
+	sessionHandler := handlers.NewSessionHandler(sessionUseCase)
+	impersonationHandler := handlers.NewImpersonationHandler(impersonationUseCase)
+	jwksHandler := handlers.NewJWKSHandler(keySet)
 
 	handlersStruct := &routes.Handlers{
-		Auth:    authHandler,
-		User:    userHandler,
-		Product: productHandler,
-		Cart:    cartHandler,
-		Order:   orderHandler,
+		Auth:          authHandler,
+		User:          userHandler,
+		Product:       productHandler,
+		Category:      categoryHandler,
+		Media:         mediaHandler,
+		ProductImport: productImportHandler,
+		Inventory:     inventoryHandler,
+		Warehouse:     warehouseHandler,
+		StockAlert:    stockAlertHandler,
+		Review:        reviewHandler,
+		Wishlist:      wishlistHandler,
+		Cart:          cartHandler,
+		AbandonedCart: abandonedCartHandler,
+		Order:         orderHandler,
+		Session:       sessionHandler,
+		Impersonation: impersonationHandler,
+		JWKS:          jwksHandler,
 	}
 
 	// Initialize Fiber app
 	app := fiber.New(fiber.Config{
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			code := fiber.StatusInternalServerError
-			if e, ok := err.(*fiber.Error); ok {
-				code = e.Code
-			}
-			return c.Status(code).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		},
+		// Every error leaves as application/problem+json with a stable code
+		ErrorHandler: problem.ErrorHandler(logger),
+		// Bodies over the default limit are streamed rather than read up
+		// front; the routes decide how large they may be
+		StreamRequestBody: true,
 	})
 
 	// Middleware
 	app.Use(recover.New())
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
-		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders: "Origin,Content-Type,Accept,Authorization",
+		AllowMethods: "GET,POST,PUT,PATCH,DELETE,OPTIONS",
+		AllowHeaders: "Origin,Content-Type,Accept,Authorization,X-Cart-Token",
 	}))
 
 	// Setup routes
-	routes.SetupRoutes(app, handlersStruct, cfg.JWT.Secret)
+	// Uploads leave room for their multipart framing
+	bodyLimits := routes.BodyLimits{
+		Default: fiber.DefaultBodyLimit,
+		Media:   cfg.Media.MaxUploadSize + 1<<20,
+		Import:  cfg.Import.MaxUploadSize + 1<<20,
+	}
+	routes.SetupRoutes(app, handlersStruct, bodyLimits, keySet, sessionUseCase, impersonationUseCase, logger)
+
+	// Locally stored media is served by the API itself
+	if cfg.Storage.Driver == storage.DriverLocal {
+		app.Static("/media", cfg.Storage.LocalDir)
+	}
 
 	// Swagger UI
 	app.Get("/swagger/*", fiberSwagger.WrapHandler)
@@ -113,4 +248,4 @@ func main() {
 	if err := app.Listen(":" + cfg.App.Port); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"prototype-fiber/internal/infrastructure/database"
+	"prototype-fiber/internal/infrastructure/inventory"
+	"prototype-fiber/internal/infrastructure/search"
+	"prototype-fiber/internal/interfaces/repositories"
+	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/config"
+	"prototype-fiber/pkg/logger"
+)
+
+// import upserts products by SKU from a CSV or JSON file, the same way
+// POST /api/v1/admin/products/import does, and prints the report.
+//
+//	go run ./cmd/import -file products.csv [-format csv|json] [-dry-run]
+func main() {
+	path := flag.String("file", "", "CSV or JSON file to import")
+	format := flag.String("format", "", "csv or json (default: from the file extension)")
+	dryRun := flag.Bool("dry-run", false, "validate and report without writing")
+	flag.Parse()
+
+	if *path == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	fileFormat, err := usecases.ParseImportFormat(*format, *path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	file, err := os.Open(*path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	cfg := config.Load()
+	logger := logger.New()
+
+	if cfg.Search.Engine == search.EngineEmbedded && !*dryRun {
+		// The embedded index lives inside the API process, so products
+		// written here would be indexed into a throwaway copy and missing
+		// from the API's search. Import through the API instead.
+		log.Fatal("SEARCH_ENGINE=embedded: import through the running API with POST /api/v1/admin/products/import")
+	}
+
+	db, err := database.NewPostgresDB(cfg.Database)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	productRepo := repositories.NewProductRepository(db)
+	variantRepo := repositories.NewProductVariantRepository(db)
+	searchIndex, err := search.New(cfg.Search, db, productRepo)
+	if err != nil {
+		log.Fatal("Invalid search configuration:", err)
+	}
+	productRepo = search.NewSyncedProductRepository(productRepo, searchIndex, logger)
+	variantRepo = search.NewSyncedVariantRepository(variantRepo, productRepo, searchIndex, logger)
+
+	// Imported stock levels raise alerts and notify subscribers as in the API
+	stockWatcher := inventory.NewWatcher(productRepo, repositories.NewStockAlertRepository(db), repositories.NewBackInStockRepository(db), inventory.NewLogNotifier(logger), logger)
+	productRepo = inventory.NewWatchedProductRepository(productRepo, stockWatcher)
+	variantRepo = inventory.NewWatchedVariantRepository(variantRepo, stockWatcher)
+
+	productUseCase := usecases.NewProductUseCase(productRepo, variantRepo, repositories.NewCategoryRepository(db), searchIndex, nil, nil)
+	importUseCase := usecases.NewProductImportUseCase(productUseCase, repositories.NewImportJobRepository(db), 0, logger)
+
+	report, err := importUseCase.RunImport(fileFormat, file, *dryRun)
+	if err != nil {
+		log.Fatal("Import failed:", err)
+	}
+
+	for _, rowErr := range report.Errors {
+		fmt.Printf("row %d", rowErr.Row)
+		if rowErr.SKU != "" {
+			fmt.Printf(" (%s)", rowErr.SKU)
+		}
+		if rowErr.Field != "" {
+			fmt.Printf(" %s", rowErr.Field)
+		}
+		fmt.Printf(": %s\n", rowErr.Message)
+	}
+	verb := "Imported"
+	if *dryRun {
+		verb = "Dry run:"
+	}
+	logger.Infof("%s %d rows: %d created, %d updated, %d skipped, %d failed",
+		verb, report.Rows, report.Created, report.Updated, report.Skipped, report.Failed)
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"log"
+
+	"prototype-fiber/internal/infrastructure/database"
+	"prototype-fiber/internal/infrastructure/search"
+	"prototype-fiber/internal/interfaces/repositories"
+	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/config"
+	"prototype-fiber/pkg/logger"
+)
+
+// reindex rebuilds the configured product search index from the database.
+func main() {
+	cfg := config.Load()
+	logger := logger.New()
+
+	if cfg.Search.Engine == search.EngineEmbedded {
+		// The embedded index lives inside the API process, out of reach of this
+		// one. It is rebuilt on every start; to rebuild a running server call
+		// POST /api/v1/admin/search/reindex instead.
+		log.Fatal("SEARCH_ENGINE=embedded: reindex the running API with POST /api/v1/admin/search/reindex")
+	}
+
+	db, err := database.NewPostgresDB(cfg.Database)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	productRepo := repositories.NewProductRepository(db)
+	searchIndex, err := search.New(cfg.Search, db, productRepo)
+	if err != nil {
+		log.Fatal("Invalid search configuration:", err)
+	}
+
+	productUseCase := usecases.NewProductUseCase(productRepo, repositories.NewProductVariantRepository(db), repositories.NewCategoryRepository(db), searchIndex, nil, nil)
+	indexed, err := productUseCase.ReindexSearch()
+	if err != nil {
+		log.Fatal("Reindex failed:", err)
+	}
+	logger.Infof("Reindexed %d products (%s)", indexed, cfg.Search.Engine)
+}
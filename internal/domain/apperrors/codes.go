@@ -0,0 +1,75 @@
+package apperrors
+
+// Stable error codes returned in the "code" member of problem responses.
+// Clients switch on these, so existing values must never change meaning.
+const (
+	CodeInvalidBody     = "invalid_request_body"
+	CodeBodyTooLarge    = "request_body_too_large"
+	CodeValidation      = "validation_failed"
+	CodeInvalidID       = "invalid_id"
+	CodeInvalidQuery    = "invalid_query"
+	CodeInternal        = "internal_error"
+	CodeUnauthorized    = "unauthorized"
+	CodeInvalidToken    = "invalid_token"
+	CodeSessionRevoked  = "session_revoked"
+	CodeInvalidLogin    = "invalid_credentials"
+	CodeAccountDisabled = "account_deactivated"
+	CodeAdminRequired   = "admin_required"
+	CodeImpersonation   = "impersonation_forbidden"
+	CodeForbidden       = "forbidden"
+
+	CodeUserNotFound    = "user_not_found"
+	CodeUserExists      = "user_already_exists"
+	CodeWeakPassword    = "weak_password"
+	CodeWrongPassword   = "incorrect_password"
+	CodePasswordReused  = "password_reused"
+	CodeSessionNotFound = "session_not_found"
+
+	CodeProductNotFound = "product_not_found"
+	CodeSKUExists       = "sku_already_exists"
+	CodeProductInStock  = "product_in_stock"
+
+	CodeVariantNotFound       = "variant_not_found"
+	CodeVariantRequired       = "variant_required"
+	CodeVariantExists         = "variant_already_exists"
+	CodeInvalidVariantOptions = "invalid_variant_options"
+	CodeProductHasVariants    = "product_has_variants"
+
+	CodeCategoryNotFound      = "category_not_found"
+	CodeCategoryExists        = "category_already_exists"
+	CodeCategoryHasChildren   = "category_has_children"
+	CodeInvalidCategoryParent = "invalid_category_parent"
+
+	CodeMediaNotFound     = "media_not_found"
+	CodeMediaTooLarge     = "media_too_large"
+	CodeUnsupportedMedia  = "unsupported_media_type"
+	CodeMediaLimitReached = "media_limit_reached"
+	CodeInvalidMediaOrder = "invalid_media_order"
+
+	CodeInvalidImportFile = "invalid_import_file"
+	CodeImportTooLarge    = "import_file_too_large"
+	CodeImportJobNotFound = "import_job_not_found"
+
+	CodeWarehouseNotFound  = "warehouse_not_found"
+	CodeWarehouseExists    = "warehouse_already_exists"
+	CodeWarehouseNotEmpty  = "warehouse_not_empty"
+	CodeLastWarehouse      = "last_warehouse"
+	CodeAllocationFailed   = "allocation_failed"
+	CodeStockAlertNotFound = "stock_alert_not_found"
+
+	CodeReviewNotFound = "review_not_found"
+	CodeReviewExists   = "review_already_exists"
+
+	CodeWishlistNotFound     = "wishlist_not_found"
+	CodeWishlistItemNotFound = "wishlist_item_not_found"
+
+	CodeCartNotFound      = "cart_not_found"
+	CodeInvalidCartToken  = "invalid_cart_token"
+	CodeCartEmpty         = "cart_empty"
+	CodeCartChanged       = "cart_changed"
+	CodeInsufficientStock = "insufficient_stock"
+
+	CodeOrderNotFound           = "order_not_found"
+	CodeOrderNotCancellable     = "order_not_cancellable"
+	CodeInvalidStatusTransition = "invalid_status_transition"
+)
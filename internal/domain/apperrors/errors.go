@@ -0,0 +1,157 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Kind classifies an error. The HTTP layer maps each kind to a status code;
+// the use cases never deal with status codes themselves.
+type Kind string
+
+const (
+	KindValidation        Kind = "validation"
+	KindUnauthorized      Kind = "unauthorized"
+	KindForbidden         Kind = "forbidden"
+	KindNotFound          Kind = "not_found"
+	KindConflict          Kind = "conflict"
+	KindInsufficientStock Kind = "insufficient_stock"
+	KindTooLarge          Kind = "too_large"
+	KindUnsupportedMedia  Kind = "unsupported_media"
+	KindInternal          Kind = "internal"
+)
+
+// Error is a domain error with a stable, machine-readable code that clients
+// can switch on and optional structured details.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Details interface{}
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches another *Error of the same kind, and the same code when the
+// target has one, so errors.Is(err, ErrNotFound) matches any not-found error.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return t.Kind == e.Kind && (t.Code == "" || t.Code == e.Code)
+}
+
+// WithDetails returns a copy of the error carrying structured details.
+func (e *Error) WithDetails(details interface{}) *Error {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+// Wrap returns a copy of the error that records the underlying cause.
+func (e *Error) Wrap(err error) *Error {
+	copied := *e
+	copied.Err = err
+	return &copied
+}
+
+// Sentinels for errors.Is checks by kind.
+var (
+	ErrValidation        = &Error{Kind: KindValidation, Message: "validation failed"}
+	ErrUnauthorized      = &Error{Kind: KindUnauthorized, Message: "unauthorized"}
+	ErrForbidden         = &Error{Kind: KindForbidden, Message: "forbidden"}
+	ErrNotFound          = &Error{Kind: KindNotFound, Message: "not found"}
+	ErrConflict          = &Error{Kind: KindConflict, Message: "conflict"}
+	ErrInsufficientStock = &Error{Kind: KindInsufficientStock, Message: "insufficient stock"}
+)
+
+func Validation(code, message string) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message}
+}
+
+func Unauthorized(code, message string) *Error {
+	return &Error{Kind: KindUnauthorized, Code: code, Message: message}
+}
+
+func Forbidden(code, message string) *Error {
+	return &Error{Kind: KindForbidden, Code: code, Message: message}
+}
+
+func NotFound(code, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+func Conflict(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+func TooLarge(code, message string) *Error {
+	return &Error{Kind: KindTooLarge, Code: code, Message: message}
+}
+
+func UnsupportedMedia(code, message string) *Error {
+	return &Error{Kind: KindUnsupportedMedia, Code: code, Message: message}
+}
+
+func Internal(err error) *Error {
+	return &Error{Kind: KindInternal, Code: CodeInternal, Message: "internal error", Err: err}
+}
+
+// StockDetails describes which product could not be fulfilled.
+type StockDetails struct {
+	ProductID   uuid.UUID  `json:"product_id"`
+	VariantID   *uuid.UUID `json:"variant_id,omitempty"`
+	ProductName string     `json:"product_name,omitempty"`
+	Requested   int        `json:"requested"`
+	Available   int        `json:"available"`
+}
+
+func InsufficientStock(productID uuid.UUID, productName string, requested, available int) *Error {
+	message := "insufficient stock"
+	if productName != "" {
+		message = "insufficient stock for product: " + productName
+	}
+	return &Error{
+		Kind:    KindInsufficientStock,
+		Code:    CodeInsufficientStock,
+		Message: message,
+		Details: StockDetails{
+			ProductID:   productID,
+			ProductName: productName,
+			Requested:   requested,
+			Available:   available,
+		},
+	}
+}
+
+// InsufficientVariantStock is InsufficientStock for one variant of a
+// product; productName should include the variant's label.
+func InsufficientVariantStock(productID, variantID uuid.UUID, productName string, requested, available int) *Error {
+	err := InsufficientStock(productID, productName, requested, available)
+	details := err.Details.(StockDetails)
+	details.VariantID = &variantID
+	err.Details = details
+	return err
+}
+
+// MapNotFound replaces a not-found error from a repository with a specific
+// one and passes every other error through unchanged.
+func MapNotFound(err error, code, message string) error {
+	if errors.Is(err, ErrNotFound) {
+		return NotFound(code, message)
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+package entities
+
+import (
+	"time"
+
+	"prototype-fiber/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+// AbandonedCart records a signed-in shopper's cart that had items but saw no
+// activity for the abandonment window. A cart is recorded once for each
+// quiet spell: once it is touched and then left again, it is recorded anew.
+// Guest carts are not recorded, as there is nobody to email.
+type AbandonedCart struct {
+	ID     uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CartID uuid.UUID  `json:"cart_id" gorm:"type:uuid;not null;uniqueIndex:idx_abandoned_carts_spell"`
+	UserID *uuid.UUID `json:"user_id,omitempty" gorm:"type:uuid;index"`
+	// Email is where the recovery email goes.
+	Email     string  `json:"email,omitempty"`
+	ItemCount int     `json:"item_count" gorm:"not null"`
+	Total     float64 `json:"total" gorm:"not null"`
+	// CartUpdatedAt is the cart's last activity before it was abandoned.
+	CartUpdatedAt time.Time  `json:"cart_updated_at" gorm:"not null;uniqueIndex:idx_abandoned_carts_spell"`
+	NotifiedAt    *time.Time `json:"notified_at,omitempty"`
+	// RecoveredAt is when the shopper placed an order after abandoning the
+	// cart.
+	RecoveredAt *time.Time `json:"recovered_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// AbandonedCartStats sums up the carts abandoned since a point in time.
+type AbandonedCartStats struct {
+	Abandoned      int64   `json:"abandoned"`
+	Notified       int64   `json:"notified"`
+	Recovered      int64   `json:"recovered"`
+	RecoveryRate   float64 `json:"recovery_rate"`
+	AbandonedValue float64 `json:"abandoned_value"`
+	RecoveredValue float64 `json:"recovered_value"`
+}
+
+type AbandonedCartRepository interface {
+	// RecordAbandoned records every signed-in shopper's cart with items that
+	// has not been touched since before and is not recorded for this quiet
+	// spell yet, returning how many were recorded.
+	RecordAbandoned(before time.Time) (int64, error)
+	// ClaimPending marks the records still waiting for a recovery email as
+	// notified and returns them: not notified or recovered, with an email
+	// address, and whose cart is still there, untouched. Each record is
+	// claimed once, even by jobs running side by side.
+	ClaimPending() ([]*AbandonedCart, error)
+	// ReleaseClaim makes a claimed record pending again after its email
+	// could not be sent.
+	ReleaseClaim(id uuid.UUID) error
+	// MarkRecovered marks as recovered the records of shoppers who have
+	// placed an order since abandoning the cart, returning how many.
+	MarkRecovered() (int64, error)
+	List(params pagination.Params) (*pagination.Page[*AbandonedCart], error)
+	Stats(since time.Time) (*AbandonedCartStats, error)
+}
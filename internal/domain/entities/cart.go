@@ -6,24 +6,58 @@ import (
 	"github.com/google/uuid"
 )
 
+// Cart belongs to a user or, when UserID is nil, to a guest holding a
+// signed cart token for it. UpdatedAt moves with every change to its items.
 type Cart struct {
-	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null"`
-	User      User       `json:"user" gorm:"foreignKey:UserID"`
-	Items     []CartItem `json:"items" gorm:"foreignKey:CartID"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
+	ID     uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID *uuid.UUID `json:"user_id" gorm:"type:uuid;index"`
+	User   *User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Items  []CartItem `json:"items" gorm:"foreignKey:CartID"`
+	// Changes lists what revalidation changed or found wrong in the cart
+	// since the shopper last saw it.
+	Changes   []CartChange `json:"changes,omitempty" gorm:"-"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
 }
 
 type CartItem struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	CartID    uuid.UUID `json:"cart_id" gorm:"type:uuid;not null"`
-	ProductID uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
-	Product   Product   `json:"product" gorm:"foreignKey:ProductID"`
-	Quantity  int       `json:"quantity" gorm:"not null"`
-	Price     float64   `json:"price" gorm:"not null"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CartID    uuid.UUID       `json:"cart_id" gorm:"type:uuid;not null"`
+	ProductID uuid.UUID       `json:"product_id" gorm:"type:uuid;not null"`
+	Product   Product         `json:"product" gorm:"foreignKey:ProductID"`
+	VariantID *uuid.UUID      `json:"variant_id" gorm:"type:uuid;index"`
+	Variant   *ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID"`
+	Quantity  int             `json:"quantity" gorm:"not null"`
+	Price     float64         `json:"price" gorm:"not null"`
+	// Unavailable is set by revalidation when the line can no longer be
+	// bought; it stays in the cart until the shopper removes it.
+	Unavailable bool      `json:"unavailable,omitempty" gorm:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type CartChangeKind string
+
+const (
+	CartChangePriceIncreased  CartChangeKind = "price_increased"
+	CartChangePriceDecreased  CartChangeKind = "price_decreased"
+	CartChangeQuantityReduced CartChangeKind = "quantity_reduced"
+	CartChangeOutOfStock      CartChangeKind = "out_of_stock"
+	CartChangeUnavailable     CartChangeKind = "unavailable"
+)
+
+// CartChange is a notice about one cart line for the shopper to review
+// before placing the order.
+type CartChange struct {
+	ProductID   uuid.UUID      `json:"product_id"`
+	VariantID   *uuid.UUID     `json:"variant_id,omitempty"`
+	Name        string         `json:"name,omitempty"`
+	Kind        CartChangeKind `json:"kind"`
+	Message     string         `json:"message"`
+	OldPrice    float64        `json:"old_price,omitempty"`
+	NewPrice    float64        `json:"new_price,omitempty"`
+	OldQuantity int            `json:"old_quantity,omitempty"`
+	NewQuantity int            `json:"new_quantity,omitempty"`
 }
 
 type CartRepository interface {
@@ -33,9 +67,38 @@ type CartRepository interface {
 	Update(cart *Cart) error
 	Delete(id uuid.UUID) error
 	AddItem(cartID uuid.UUID, item *CartItem) error
-	UpdateItem(cartID uuid.UUID, productID uuid.UUID, quantity int) error
-	RemoveItem(cartID uuid.UUID, productID uuid.UUID) error
+	// UpdateItem and RemoveItem address the line for productID and
+	// variantID; a nil variantID is the line without a variant.
+	UpdateItem(cartID uuid.UUID, productID uuid.UUID, variantID *uuid.UUID, quantity int) error
+	RemoveItem(cartID uuid.UUID, productID uuid.UUID, variantID *uuid.UUID) error
 	Clear(cartID uuid.UUID) error
+	// UpdateLines sets the quantity and price of the cart's lines for the
+	// same products and variants as lines.
+	UpdateLines(cartID uuid.UUID, lines []CartItem) error
+	// Merge writes lines into cartID, replacing the quantity and price of
+	// lines it already has, and deletes the guest cart, all at once.
+	Merge(guestCartID, cartID uuid.UUID, lines []CartItem) error
+	// PurgeInactive deletes carts, with their items, that have not been
+	// touched since before, returning how many.
+	PurgeInactive(before time.Time) (int64, error)
+}
+
+// Item returns the line for the product and variant, or nil.
+func (c *Cart) Item(productID uuid.UUID, variantID *uuid.UUID) *CartItem {
+	for i := range c.Items {
+		if item := &c.Items[i]; item.ProductID == productID && sameVariant(item.VariantID, variantID) {
+			return item
+		}
+	}
+	return nil
+}
+
+// sameVariant compares optional variant IDs; two nils are the same.
+func sameVariant(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
 
 func (c *Cart) GetTotal() float64 {
@@ -56,4 +119,4 @@ func (c *Cart) GetItemCount() int {
 
 func (ci *CartItem) GetSubtotal() float64 {
 	return ci.Price * float64(ci.Quantity)
-}
\ No newline at end of file
+}
@@ -0,0 +1,93 @@
+package entities
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Category is a node of the product category tree. Products can belong to
+// several categories, and filtering by a category includes its descendants.
+type Category struct {
+	ID        uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ParentID  *uuid.UUID  `json:"parent_id" gorm:"type:uuid;index"`
+	Parent    *Category   `json:"-" gorm:"foreignKey:ParentID;constraint:OnDelete:RESTRICT"`
+	Name      string      `json:"name" gorm:"not null"`
+	Slug      string      `json:"slug" gorm:"uniqueIndex;not null"`
+	Position  int         `json:"position" gorm:"not null;default:0"`
+	IsActive  bool        `json:"is_active" gorm:"default:true"`
+	Children  []*Category `json:"children,omitempty" gorm:"-"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+type CategoryRepository interface {
+	Create(category *Category) error
+	GetByID(id uuid.UUID) (*Category, error)
+	GetByIDs(ids []uuid.UUID) ([]*Category, error)
+	GetBySlugs(slugs []string) ([]*Category, error)
+	Update(category *Category) error
+	Delete(id uuid.UUID) error
+	// List returns every category, unnested.
+	List() ([]*Category, error)
+	// DescendantIDs returns ids and the ids of every category below them.
+	DescendantIDs(ids []uuid.UUID) ([]uuid.UUID, error)
+	// ProductIDs returns the products assigned to the category.
+	ProductIDs(id uuid.UUID) ([]uuid.UUID, error)
+}
+
+// BuildCategoryTree nests categories under their parents, ordered by
+// position then name, and returns the roots. Unless includeInactive is set,
+// inactive categories are dropped along with everything below them.
+func BuildCategoryTree(categories []*Category, includeInactive bool) []*Category {
+	byParent := make(map[uuid.UUID][]*Category)
+	for _, category := range categories {
+		parent := uuid.Nil
+		if category.ParentID != nil {
+			parent = *category.ParentID
+		}
+		byParent[parent] = append(byParent[parent], category)
+	}
+
+	var attach func(parent uuid.UUID) []*Category
+	attach = func(parent uuid.UUID) []*Category {
+		children := make([]*Category, 0, len(byParent[parent]))
+		for _, category := range byParent[parent] {
+			if !category.IsActive && !includeInactive {
+				continue
+			}
+			category.Children = attach(category.ID)
+			children = append(children, category)
+		}
+		sort.SliceStable(children, func(a, b int) bool {
+			if children[a].Position != children[b].Position {
+				return children[a].Position < children[b].Position
+			}
+			return children[a].Name < children[b].Name
+		})
+		return children
+	}
+	return attach(uuid.Nil)
+}
+
+// CategoryNames returns the names of the product's categories.
+func (p *Product) CategoryNames() []string {
+	names := make([]string, len(p.Categories))
+	for i, category := range p.Categories {
+		names[i] = category.Name
+	}
+	return names
+}
+
+// InCategory reports whether the product is assigned to any of ids.
+func (p *Product) InCategory(ids []uuid.UUID) bool {
+	for _, category := range p.Categories {
+		for _, id := range ids {
+			if category.ID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"prototype-fiber/pkg/pagination"
+
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ImpersonationLog is the audit trail of support staff acting as a customer:
+// one entry when the impersonation starts and one per request made with it.
+type ImpersonationLog struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ImpersonatorID uuid.UUID `json:"impersonator_id" gorm:"type:uuid;not null;index"`
+	UserID         uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	SessionID      uuid.UUID `json:"session_id" gorm:"type:uuid;not null"`
+	Action         string    `json:"action" gorm:"not null"`
+	Method         string    `json:"method"`
+	Path           string    `json:"path"`
+	StatusCode     int       `json:"status_code"`
+	Reason         string    `json:"reason"`
+	IPAddress      string    `json:"ip_address"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+const (
+	ImpersonationActionStart   = "start"
+	ImpersonationActionRequest = "request"
+)
+
+type ImpersonationLogRepository interface {
+	Create(entry *ImpersonationLog) error
+	UpdateStatusCode(id uuid.UUID, status int) error
+	ListByUserID(userID uuid.UUID, params pagination.Params) (*pagination.Page[*ImpersonationLog], error)
+}
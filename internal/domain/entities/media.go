@@ -0,0 +1,64 @@
+package entities
+
+import (
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProductMedia is an image in a product's gallery. Key locates the original
+// in blob storage; URL is where clients fetch it. The primary image is
+// mirrored into Product.ImageURL for clients that read a single image.
+type ProductMedia struct {
+	ID          uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductID   uuid.UUID        `json:"product_id" gorm:"type:uuid;not null;index"`
+	Key         string           `json:"-" gorm:"not null"`
+	URL         string           `json:"url" gorm:"not null"`
+	ContentType string           `json:"content_type" gorm:"not null"`
+	Size        int64            `json:"size"`
+	Width       int              `json:"width"`
+	Height      int              `json:"height"`
+	AltText     string           `json:"alt_text"`
+	Position    int              `json:"position" gorm:"not null;default:0"`
+	IsPrimary   bool             `json:"is_primary" gorm:"not null;default:false"`
+	Thumbnails  []MediaThumbnail `json:"thumbnails" gorm:"foreignKey:MediaID;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// MediaThumbnail is a scaled copy of a media image that fits within
+// Size×Size pixels.
+type MediaThumbnail struct {
+	ID      uuid.UUID `json:"-" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	MediaID uuid.UUID `json:"-" gorm:"type:uuid;not null;index"`
+	Size    int       `json:"size"`
+	Key     string    `json:"-" gorm:"not null"`
+	URL     string    `json:"url" gorm:"not null"`
+	Width   int       `json:"width"`
+	Height  int       `json:"height"`
+}
+
+type ProductMediaRepository interface {
+	// Create stores the media with its thumbnails.
+	Create(media *ProductMedia) error
+	GetByID(id uuid.UUID) (*ProductMedia, error)
+	// ListByProductID returns a product's media in gallery order.
+	ListByProductID(productID uuid.UUID) ([]*ProductMedia, error)
+	Update(media *ProductMedia) error
+	Delete(id uuid.UUID) error
+	// SetPrimary makes mediaID the product's only primary image.
+	SetPrimary(productID, mediaID uuid.UUID) error
+	// Reorder sets each media's position to its index in ids.
+	Reorder(productID uuid.UUID, ids []uuid.UUID) error
+}
+
+// BlobStorage stores uploaded files by key. Keys are slash-separated paths
+// chosen by the application, never by the client.
+type BlobStorage interface {
+	Put(key string, body io.Reader, size int64, contentType string) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	// URL is the public address of the object at key.
+	URL(key string) string
+}
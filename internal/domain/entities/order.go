@@ -1,38 +1,60 @@
 package entities
 
 import (
+	"prototype-fiber/pkg/pagination"
+
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Order struct {
-	ID            uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	UserID        uuid.UUID   `json:"user_id" gorm:"type:uuid;not null"`
-	User          User        `json:"user" gorm:"foreignKey:UserID"`
-	Items         []OrderItem `json:"items" gorm:"foreignKey:OrderID"`
-	Status        OrderStatus `json:"status" gorm:"default:'pending'"`
-	Total         float64     `json:"total" gorm:"not null"`
-	ShippingCost  float64     `json:"shipping_cost" gorm:"default:0"`
-	Tax           float64     `json:"tax" gorm:"default:0"`
-	PaymentID     *uuid.UUID  `json:"payment_id,omitempty" gorm:"type:uuid"`
-	Payment       *Payment    `json:"payment,omitempty" gorm:"foreignKey:PaymentID"`
-	ShippingAddr  string      `json:"shipping_address"`
-	BillingAddr   string      `json:"billing_address"`
-	TrackingCode  string      `json:"tracking_code"`
-	CreatedAt     time.Time   `json:"created_at"`
-	UpdatedAt     time.Time   `json:"updated_at"`
+	ID              uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID          uuid.UUID   `json:"user_id" gorm:"type:uuid;not null"`
+	User            User        `json:"user" gorm:"foreignKey:UserID"`
+	Items           []OrderItem `json:"items" gorm:"foreignKey:OrderID"`
+	Status          OrderStatus `json:"status" gorm:"default:'pending'"`
+	Total           float64     `json:"total" gorm:"not null"`
+	ShippingCost    float64     `json:"shipping_cost" gorm:"default:0"`
+	Tax             float64     `json:"tax" gorm:"default:0"`
+	PaymentID       *uuid.UUID  `json:"payment_id,omitempty" gorm:"type:uuid"`
+	Payment         *Payment    `json:"payment,omitempty" gorm:"foreignKey:PaymentID"`
+	ShippingAddr    string      `json:"shipping_address"`
+	ShippingCountry string      `json:"shipping_country" gorm:"size:2"`
+	BillingAddr     string      `json:"billing_address"`
+	TrackingCode    string      `json:"tracking_code"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
 }
 
+// OrderItem is a line of an order as it was bought. The product and
+// variant are referenced by ID only: their name, SKU, options and image are
+// copied onto the item when the order is placed, so later catalogue edits,
+// or deleting the product, leave the order as it was.
 type OrderItem struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	OrderID   uuid.UUID `json:"order_id" gorm:"type:uuid;not null"`
-	ProductID uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
-	Product   Product   `json:"product" gorm:"foreignKey:ProductID"`
-	Quantity  int       `json:"quantity" gorm:"not null"`
-	Price     float64   `json:"price" gorm:"not null"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrderID   uuid.UUID  `json:"order_id" gorm:"type:uuid;not null"`
+	ProductID uuid.UUID  `json:"product_id" gorm:"type:uuid;not null"`
+	VariantID *uuid.UUID `json:"variant_id" gorm:"type:uuid;index"`
+	// ProductName is the product's name, without the variant.
+	ProductName string `json:"product_name" gorm:"not null;default:''"`
+	// SKU is the variant's SKU for a variant, the product's otherwise.
+	SKU            string            `json:"sku" gorm:"not null;default:''"`
+	VariantOptions []OrderItemOption `json:"variant_options,omitempty" gorm:"type:jsonb;serializer:json"`
+	ImageURL       string            `json:"image_url"`
+	Quantity       int               `json:"quantity" gorm:"not null"`
+	Price          float64           `json:"price" gorm:"not null"`
+	// Allocations say which warehouses the item ships from. Items of orders
+	// placed before warehouses existed have none.
+	Allocations []OrderItemAllocation `json:"allocations" gorm:"foreignKey:OrderItemID;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+// OrderItemOption is one of a variant's option values, e.g. size M.
+type OrderItemOption struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
 }
 
 type OrderStatus string
@@ -50,11 +72,14 @@ const (
 type OrderRepository interface {
 	Create(order *Order) error
 	GetByID(id uuid.UUID) (*Order, error)
-	GetByUserID(userID uuid.UUID, offset, limit int) ([]*Order, error)
+	GetByUserID(userID uuid.UUID, params pagination.Params) (*pagination.Page[*Order], error)
 	Update(order *Order) error
 	UpdateStatus(id uuid.UUID, status OrderStatus) error
-	List(offset, limit int) ([]*Order, error)
-	GetByStatus(status OrderStatus, offset, limit int) ([]*Order, error)
+	List(params pagination.Params) (*pagination.Page[*Order], error)
+	GetByStatus(status OrderStatus, params pagination.Params) (*pagination.Page[*Order], error)
+	// HasDelivered reports whether the user has a delivered order
+	// containing the product.
+	HasDelivered(userID, productID uuid.UUID) (bool, error)
 }
 
 func (o *Order) CanBeCancelled() bool {
@@ -65,6 +90,19 @@ func (o *Order) CanBeRefunded() bool {
 	return o.Status == OrderStatusPaid || o.Status == OrderStatusProcessing || o.Status == OrderStatusShipped
 }
 
+// ProductIDs lists the products ordered, each once, in item order.
+func (o *Order) ProductIDs() []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(o.Items))
+	var ids []uuid.UUID
+	for _, item := range o.Items {
+		if !seen[item.ProductID] {
+			seen[item.ProductID] = true
+			ids = append(ids, item.ProductID)
+		}
+	}
+	return ids
+}
+
 func (oi *OrderItem) GetSubtotal() float64 {
 	return oi.Price * float64(oi.Quantity)
-}
\ No newline at end of file
+}
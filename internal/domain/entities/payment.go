@@ -1,6 +1,8 @@
 package entities
 
 import (
+	"prototype-fiber/pkg/pagination"
+
 	"time"
 
 	"github.com/google/uuid"
@@ -46,7 +48,7 @@ type PaymentRepository interface {
 	GetByOrderID(orderID uuid.UUID) (*Payment, error)
 	Update(payment *Payment) error
 	UpdateStatus(id uuid.UUID, status PaymentStatus) error
-	List(offset, limit int) ([]*Payment, error)
+	List(params pagination.Params) (*pagination.Page[*Payment], error)
 }
 
 func (p *Payment) IsSuccessful() bool {
@@ -3,32 +3,110 @@ package entities
 import (
 	"time"
 
+	"prototype-fiber/pkg/pagination"
+
 	"github.com/google/uuid"
 )
 
 type Product struct {
 	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	Name        string    `json:"name" gorm:"not null"`
+	Name        string    `json:"name" gorm:"not null;index"`
 	Description string    `json:"description"`
-	Price       float64   `json:"price" gorm:"not null"`
+	Price       float64   `json:"price" gorm:"not null;index"`
 	SKU         string    `json:"sku" gorm:"uniqueIndex;not null"`
-	Stock       int       `json:"stock" gorm:"default:0"`
-	Category    string    `json:"category"`
-	ImageURL    string    `json:"image_url"`
-	IsActive    bool      `json:"is_active" gorm:"default:true"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	Stock       int       `json:"stock" gorm:"default:0;index"`
+	// ReorderThreshold raises a StockAlert when stock falls to it; zero
+	// disables alerts.
+	ReorderThreshold int    `json:"reorder_threshold" gorm:"not null;default:0"`
+	ImageURL         string `json:"image_url"`
+	IsActive         bool   `json:"is_active" gorm:"default:true;index"`
+	SalesCount       int    `json:"sales_count" gorm:"not null;default:0;index"`
+	// RatingAverage and RatingCount summarise the approved reviews and are
+	// maintained by the ReviewRepository.
+	RatingAverage float64   `json:"rating_average" gorm:"not null;default:0;index"`
+	RatingCount   int       `json:"rating_count" gorm:"not null;default:0"`
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	Categories []Category `json:"categories" gorm:"many2many:product_categories;constraint:OnDelete:CASCADE"`
+	// CategoryText is the space-separated names of Categories, maintained
+	// by the repository so the search vector can weight them.
+	CategoryText string `json:"-" gorm:"column:category"`
+
+	Media []ProductMedia `json:"media,omitempty" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+
+	// For products sold in variants Stock is the sum of the active
+	// variants' stock, kept up to date by the repositories.
+	Options  []ProductOption  `json:"options,omitempty" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	Variants []ProductVariant `json:"variants,omitempty" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+
+	// Only populated on search results: matched terms are wrapped in <mark>.
+	NameHighlight        string  `json:"name_highlight,omitempty" gorm:"->;-:migration"`
+	DescriptionHighlight string  `json:"description_highlight,omitempty" gorm:"->;-:migration"`
+	SearchRank           float64 `json:"-" gorm:"column:rank;->;-:migration"`
+}
+
+type ProductSort string
+
+const (
+	ProductSortNewest     ProductSort = "newest"
+	ProductSortPrice      ProductSort = "price"
+	ProductSortName       ProductSort = "name"
+	ProductSortPopularity ProductSort = "popularity"
+	ProductSortRating     ProductSort = "rating"
+)
+
+// ProductFilter narrows and orders a product listing. Zero values mean no
+// constraint; an empty Sort lists newest first. CategorySlugs come from the
+// request and are resolved by the use case into CategoryIDs, which include
+// descendants and are what repositories and search indexes filter on.
+type ProductFilter struct {
+	CategorySlugs []string
+	CategoryIDs   []uuid.UUID
+	MinPrice      *float64
+	MaxPrice      *float64
+	InStockOnly   bool
+	CreatedAfter  *time.Time
+	Sort          ProductSort
+	Descending    bool
+	// IncludeInactive lists inactive products too. Only List honours it.
+	IncludeInactive bool
 }
 
 type ProductRepository interface {
+	// Create records the product's initial stock as a receipt.
 	Create(product *Product) error
 	GetByID(id uuid.UUID) (*Product, error)
 	GetBySKU(sku string) (*Product, error)
+	// GetStockLevels loads only what stock alerts look at, the name, SKU,
+	// stock, reorder threshold and active flag, of each product found.
+	GetStockLevels(ids []uuid.UUID) (map[uuid.UUID]*Product, error)
+	// Update saves everything but stock, which changes only through
+	// UpdateStock, RecordOrder and ReturnOrder so that the ledger sees
+	// every movement, and the rating, which the ReviewRepository maintains.
 	Update(product *Product) error
+	// UpdateImageURL sets only the product's image URL, which follows its
+	// primary media.
+	UpdateImageURL(id uuid.UUID, url string) error
 	Delete(id uuid.UUID) error
-	List(offset, limit int, category string) ([]*Product, error)
-	Search(query string, offset, limit int) ([]*Product, error)
-	UpdateStock(id uuid.UUID, quantity int) error
+	List(filter ProductFilter, params pagination.Params) (*pagination.Page[*Product], error)
+	Search(query string, params pagination.Params) (*pagination.Page[*Product], error)
+	SearchFiltered(query string, filter ProductFilter, params pagination.Params) (*pagination.Page[*Product], error)
+	SearchFacets(query string, filter ProductFilter) (*SearchFacets, error)
+	Suggest(prefix string, limit int) ([]*ProductSuggestion, error)
+	// SetCategories replaces the product's category assignments.
+	SetCategories(id uuid.UUID, categoryIDs []uuid.UUID) error
+	// UpdateStock adds quantity, which may be negative, to the product's
+	// stock and records the movement.
+	UpdateStock(id uuid.UUID, quantity int, change StockChange) error
+	// RecordOrder saves a new order and records the sale of each of its
+	// allocations in one transaction, so an allocation that can no longer be
+	// met leaves neither the order nor any of its stock movements behind.
+	RecordOrder(order *Order) error
+	// ReturnOrder moves the order to status and applies returns in one
+	// transaction. It fails with ErrConflict if the order's status has
+	// changed since it was read, so an order is never returned twice.
+	ReturnOrder(order *Order, status OrderStatus, returns []StockReturn) error
 }
 
 func (p *Product) IsInStock() bool {
@@ -37,4 +115,4 @@ func (p *Product) IsInStock() bool {
 
 func (p *Product) CanFulfillQuantity(quantity int) bool {
 	return p.Stock >= quantity && p.IsActive
-}
\ No newline at end of file
+}
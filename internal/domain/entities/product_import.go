@@ -0,0 +1,66 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ImportStatus string
+
+const (
+	ImportStatusPending   ImportStatus = "pending"
+	ImportStatusRunning   ImportStatus = "running"
+	ImportStatusCompleted ImportStatus = "completed"
+	ImportStatusFailed    ImportStatus = "failed"
+)
+
+// ImportReport summarises a product import, or what one would do when run
+// as a dry run. Rows count data rows, not the header.
+type ImportReport struct {
+	Rows    int              `json:"rows"`
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Skipped int              `json:"skipped"`
+	Failed  int              `json:"failed"`
+	Errors  []ImportRowError `json:"errors" gorm:"foreignKey:JobID;constraint:OnDelete:CASCADE"`
+}
+
+// ImportRowError is one problem with one row. Row is 1-based and counts
+// data rows only; Field is empty when the whole row is at fault.
+type ImportRowError struct {
+	ID      uuid.UUID `json:"-" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	JobID   uuid.UUID `json:"-" gorm:"type:uuid;not null;index"`
+	Row     int       `json:"row"`
+	SKU     string    `json:"sku,omitempty"`
+	Field   string    `json:"field,omitempty"`
+	Message string    `json:"message"`
+}
+
+// ImportJob is a product import running in the background. Its counters
+// advance as rows are processed.
+type ImportJob struct {
+	ID         uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Format     string       `json:"format" gorm:"not null"`
+	Status     ImportStatus `json:"status" gorm:"not null;default:'pending'"`
+	CreatedBy  *uuid.UUID   `json:"created_by,omitempty" gorm:"type:uuid"`
+	Error      string       `json:"error,omitempty"`
+	StartedAt  *time.Time   `json:"started_at"`
+	FinishedAt *time.Time   `json:"finished_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+
+	ImportReport `gorm:"embedded"`
+}
+
+type ImportJobRepository interface {
+	Create(job *ImportJob) error
+	// GetByID returns the job with its row errors in row order.
+	GetByID(id uuid.UUID) (*ImportJob, error)
+	// Update saves the job's status and counters.
+	Update(job *ImportJob) error
+	AddErrors(jobID uuid.UUID, errors []ImportRowError) error
+	// FailUnfinished marks every pending or running job failed with the
+	// given message and returns how many there were.
+	FailUnfinished(message string) (int64, error)
+}
@@ -0,0 +1,77 @@
+package entities
+
+import (
+	"time"
+
+	"prototype-fiber/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+// ProductSuggestion is a lightweight autocomplete entry.
+type ProductSuggestion struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Category string    `json:"category,omitempty"`
+	Price    float64   `json:"price"`
+}
+
+// PriceBucketBounds splits search results into price facets: [0, 10),
+// [10, 25), ... [250, ∞). Every search index uses the same buckets.
+var PriceBucketBounds = []float64{10, 25, 50, 100, 250}
+
+// FacetCount is one facet value. Category facets are keyed by slug and
+// carry the display name.
+type FacetCount struct {
+	Value string `json:"value"`
+	Name  string `json:"name,omitempty"`
+	Count int64  `json:"count"`
+}
+
+// PriceBucket counts matches with Min <= price < Max. A nil Max is open-ended.
+type PriceBucket struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max"`
+	Count int64    `json:"count"`
+}
+
+// SearchFacets aggregates every match of a search, not just the current page.
+type SearchFacets struct {
+	Categories   []FacetCount  `json:"categories"`
+	PriceBuckets []PriceBucket `json:"price_buckets"`
+	InStock      int64         `json:"in_stock"`
+	OutOfStock   int64         `json:"out_of_stock"`
+}
+
+// SuggestionCache caches autocomplete results by normalized prefix.
+type SuggestionCache interface {
+	GetSuggestions(prefix string, limit int) ([]*ProductSuggestion, bool, error)
+	SetSuggestions(prefix string, limit int, suggestions []*ProductSuggestion, ttl time.Duration) error
+}
+
+// SearchQuery is a full-text product query. Results are ordered by
+// relevance, so Filter.Sort is ignored.
+type SearchQuery struct {
+	Text   string
+	Filter ProductFilter
+	Page   pagination.Params
+}
+
+type SearchResult struct {
+	Products *pagination.Page[*Product]
+	Facets   *SearchFacets
+}
+
+// SearchIndex is the product search backend. Index and Delete keep it in
+// step with product writes; Reset empties it before a full reindex.
+// Inactive products may be indexed but are never returned.
+type SearchIndex interface {
+	// StoresProducts reports whether the index keeps its own copy of the
+	// products, which Index must be given after every write. An index that
+	// reads the products table has no use for them.
+	StoresProducts() bool
+	Index(products ...*Product) error
+	Delete(id uuid.UUID) error
+	Search(query SearchQuery) (*SearchResult, error)
+	Reset() error
+}
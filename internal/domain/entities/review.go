@@ -0,0 +1,73 @@
+package entities
+
+import (
+	"time"
+
+	"prototype-fiber/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+type ReviewStatus string
+
+const (
+	ReviewPending  ReviewStatus = "pending"
+	ReviewApproved ReviewStatus = "approved"
+	ReviewRejected ReviewStatus = "rejected"
+)
+
+// Review is a customer's rating of a product, one per customer and
+// product. Reviews are published once approved, and only approved reviews
+// count towards the product's rating.
+type Review struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductID uuid.UUID `json:"product_id" gorm:"type:uuid;not null;uniqueIndex:idx_reviews_product_user"`
+	Product   *Product  `json:"-" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_reviews_product_user;index"`
+	User      *User     `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	// AuthorName is how the author is shown, taken from their profile when
+	// the review is written.
+	AuthorName string `json:"author_name" gorm:"not null"`
+	Rating     int    `json:"rating" gorm:"not null"`
+	Title      string `json:"title" gorm:"size:200;not null"`
+	Body       string `json:"body" gorm:"type:text"`
+	// VerifiedPurchase is set when the author had a delivered order
+	// containing the product at the time of writing.
+	VerifiedPurchase bool         `json:"verified_purchase" gorm:"not null;default:false"`
+	Status           ReviewStatus `json:"status" gorm:"not null;default:'pending';index"`
+	HelpfulCount     int          `json:"helpful_count" gorm:"not null;default:0"`
+	ModeratedBy      *uuid.UUID   `json:"moderated_by,omitempty" gorm:"type:uuid"`
+	ModeratedAt      *time.Time   `json:"moderated_at,omitempty"`
+	CreatedAt        time.Time    `json:"created_at"`
+	UpdatedAt        time.Time    `json:"updated_at"`
+}
+
+// ReviewVote marks a review as helpful to a customer.
+type ReviewVote struct {
+	ReviewID  uuid.UUID `json:"review_id" gorm:"type:uuid;primaryKey"`
+	Review    *Review   `json:"-" gorm:"foreignKey:ReviewID;constraint:OnDelete:CASCADE"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey"`
+	User      *User     `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReviewRepository keeps each product's RatingAverage and RatingCount in
+// step with its approved reviews on every write.
+type ReviewRepository interface {
+	Create(review *Review) error
+	GetByID(id uuid.UUID) (*Review, error)
+	// Update saves everything but the helpful count.
+	Update(review *Review) error
+	Delete(id uuid.UUID) error
+	// ListByProduct returns a product's reviews with the given status,
+	// newest first.
+	ListByProduct(productID uuid.UUID, status ReviewStatus, params pagination.Params) (*pagination.Page[*Review], error)
+	// List returns reviews of every product with the given status, newest
+	// first.
+	List(status ReviewStatus, params pagination.Params) (*pagination.Page[*Review], error)
+	SetStatus(id uuid.UUID, status ReviewStatus, moderatorID uuid.UUID) error
+	// AddVote and RemoveVote adjust the helpful count when the vote is new
+	// or existed, respectively; repeating either is harmless.
+	AddVote(reviewID, userID uuid.UUID) error
+	RemoveVote(reviewID, userID uuid.UUID) error
+}
@@ -0,0 +1,48 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is one login on one device. Every issued token carries the
+// session ID, so revoking the session invalidates the token.
+type Session struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	DeviceName     string     `json:"device_name"`
+	UserAgent      string     `json:"user_agent"`
+	IPAddress      string     `json:"ip_address"`
+	ImpersonatorID *uuid.UUID `json:"impersonator_id,omitempty" gorm:"type:uuid"`
+	LastSeenAt     time.Time  `json:"last_seen_at"`
+	ExpiresAt      time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	Current        bool       `json:"current" gorm:"-"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type SessionRepository interface {
+	Create(session *Session) error
+	GetByID(id uuid.UUID) (*Session, error)
+	ListActiveByUserID(userID uuid.UUID) ([]*Session, error)
+	UpdateLastSeen(id uuid.UUID, lastSeen time.Time) error
+	Revoke(id uuid.UUID) error
+	RevokeAllByUserID(userID uuid.UUID) ([]uuid.UUID, error)
+}
+
+// SessionCache keeps the hot path of AuthMiddleware off the database: it
+// remembers whether a session is active and buffers last-seen timestamps.
+type SessionCache interface {
+	GetStatus(id uuid.UUID) (active bool, found bool, err error)
+	SetStatus(id uuid.UUID, active bool, ttl time.Duration) error
+	// SetLastSeen buffers the timestamp for ttl, the rest of the session's
+	// life, after which there is nothing left to show it for.
+	SetLastSeen(id uuid.UUID, lastSeen time.Time, ttl time.Duration) error
+	GetLastSeen(ids []uuid.UUID) (map[uuid.UUID]time.Time, error)
+}
+
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
@@ -0,0 +1,85 @@
+package entities
+
+import (
+	"time"
+
+	"prototype-fiber/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+type StockAlertStatus string
+
+const (
+	StockAlertOpen     StockAlertStatus = "open"
+	StockAlertResolved StockAlertStatus = "resolved"
+)
+
+// StockAlert is raised when a product's stock falls to or below its
+// reorder threshold. A product has at most one open alert; it is resolved
+// when stock rises above the threshold again or by an admin.
+type StockAlert struct {
+	ID         uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductID  uuid.UUID        `json:"product_id" gorm:"type:uuid;not null;index"`
+	Product    *Product         `json:"product,omitempty" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	Threshold  int              `json:"threshold" gorm:"not null"`
+	Stock      int              `json:"stock" gorm:"not null"`
+	Status     StockAlertStatus `json:"status" gorm:"not null;default:'open';index"`
+	ResolvedAt *time.Time       `json:"resolved_at"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+// BackInStockSubscription asks for a customer to be told once when an
+// out-of-stock product can be bought again. NotifiedAt is set when they
+// have been.
+type BackInStockSubscription struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductID  uuid.UUID  `json:"product_id" gorm:"type:uuid;not null;uniqueIndex:idx_back_in_stock_product_user"`
+	Product    *Product   `json:"-" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_back_in_stock_product_user"`
+	User       *User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	NotifiedAt *time.Time `json:"notified_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type StockAlertRepository interface {
+	// Open records the alert unless the product already has an open one,
+	// reporting whether it did.
+	Open(alert *StockAlert) (bool, error)
+	GetByID(id uuid.UUID) (*StockAlert, error)
+	// List returns alerts newest first, all of them when status is empty.
+	List(status StockAlertStatus, params pagination.Params) (*pagination.Page[*StockAlert], error)
+	Resolve(id uuid.UUID) error
+	// ResolveOpen resolves the product's open alert, if it has one.
+	ResolveOpen(productID uuid.UUID) error
+}
+
+type BackInStockRepository interface {
+	// Subscribe creates the subscription, or renews one that was already
+	// notified.
+	Subscribe(subscription *BackInStockSubscription) error
+	Unsubscribe(productID, userID uuid.UUID) error
+	// ClaimPending marks the product's unnotified subscriptions as notified
+	// and returns them, so each is notified exactly once.
+	ClaimPending(productID uuid.UUID) ([]*BackInStockSubscription, error)
+}
+
+// StockNotifier delivers stock alerts to admins and back-in-stock notices
+// to customers. Delivery is best effort.
+type StockNotifier interface {
+	LowStock(product *Product, alert *StockAlert)
+	BackInStock(product *Product, subscriptions []*BackInStockSubscription)
+}
+
+// CrossedReorderThreshold reports whether stock moving from before to the
+// product's current stock fell to or below its reorder threshold. A zero
+// threshold disables alerts.
+func (p *Product) CrossedReorderThreshold(before int) bool {
+	return p.ReorderThreshold > 0 && before > p.ReorderThreshold && p.Stock <= p.ReorderThreshold
+}
+
+// RecoveredFromThreshold reports whether stock rose back above the reorder
+// threshold.
+func (p *Product) RecoveredFromThreshold(before int) bool {
+	return p.ReorderThreshold > 0 && before <= p.ReorderThreshold && p.Stock > p.ReorderThreshold
+}
@@ -0,0 +1,97 @@
+package entities
+
+import (
+	"time"
+
+	"prototype-fiber/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+type StockMovementReason string
+
+const (
+	StockReasonSale       StockMovementReason = "sale"
+	StockReasonCancel     StockMovementReason = "cancel"
+	StockReasonRefund     StockMovementReason = "refund"
+	StockReasonAdjustment StockMovementReason = "adjustment"
+	StockReasonReceipt    StockMovementReason = "receipt"
+)
+
+// StockMovement is one entry in the append-only inventory ledger. Movements
+// of a variant's stock carry its VariantID; a product sold in variants has
+// no stock of its own. Balance is the stock right after the movement, so
+// summing a product's or variant's deltas gives its current stock.
+type StockMovement struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductID uuid.UUID  `json:"product_id" gorm:"type:uuid;not null;index"`
+	VariantID *uuid.UUID `json:"variant_id,omitempty" gorm:"type:uuid;index"`
+	// WarehouseID is where the stock moved; it is unset for bookkeeping
+	// entries such as stock moving to a product's first variant.
+	WarehouseID *uuid.UUID          `json:"warehouse_id,omitempty" gorm:"type:uuid;index"`
+	Delta       int                 `json:"delta" gorm:"not null"`
+	Reason      StockMovementReason `json:"reason" gorm:"not null"`
+	OrderID     *uuid.UUID          `json:"order_id,omitempty" gorm:"type:uuid;index"`
+	UserID      *uuid.UUID          `json:"user_id,omitempty" gorm:"type:uuid"`
+	Note        string              `json:"note,omitempty"`
+	Balance     int                 `json:"balance" gorm:"not null"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+// StockChange says why stock is changing, where and on whose behalf. Every
+// stock write takes one, and the repository records it in the ledger in the
+// same transaction. A nil WarehouseID means the default warehouse.
+type StockChange struct {
+	Reason      StockMovementReason
+	WarehouseID *uuid.UUID
+	OrderID     *uuid.UUID
+	UserID      *uuid.UUID
+	Note        string
+}
+
+// StockReturn is stock coming back from a cancelled or refunded order.
+// Unreturned marks a product or variant deleted from the catalogue since
+// the sale: the return is recorded in the ledger but nothing goes back
+// into stock.
+type StockReturn struct {
+	ProductID  uuid.UUID
+	VariantID  *uuid.UUID
+	Quantity   int
+	Change     StockChange
+	Unreturned bool
+}
+
+// RestockedProductIDs lists the products that returns put back in stock,
+// each once.
+func RestockedProductIDs(returns []StockReturn) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(returns))
+	var ids []uuid.UUID
+	for _, stock := range returns {
+		if !stock.Unreturned && !seen[stock.ProductID] {
+			seen[stock.ProductID] = true
+			ids = append(ids, stock.ProductID)
+		}
+	}
+	return ids
+}
+
+// StockDiscrepancy is a product or variant whose stock differs from the sum
+// of its ledger movements.
+type StockDiscrepancy struct {
+	ProductID     uuid.UUID  `json:"product_id"`
+	VariantID     *uuid.UUID `json:"variant_id,omitempty"`
+	SKU           string     `json:"sku"`
+	Stock         int        `json:"stock"`
+	LedgerBalance int        `json:"ledger_balance"`
+}
+
+// StockMovementRepository reads the ledger. Movements are only ever written
+// by the product and variant repositories as part of a stock change.
+type StockMovementRepository interface {
+	// ListByProductID returns the movements of a product and its variants,
+	// newest first.
+	ListByProductID(productID uuid.UUID, params pagination.Params) (*pagination.Page[*StockMovement], error)
+	// Reconcile compares the stock of every product without variants, and of
+	// every variant, with the sum of its movements.
+	Reconcile() ([]*StockDiscrepancy, error)
+}
@@ -3,8 +3,10 @@ package entities
 import (
 	"time"
 
+	"prototype-fiber/pkg/password"
+	"prototype-fiber/pkg/pagination"
+
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type User struct {
@@ -33,21 +35,26 @@ type UserRepository interface {
 	GetByEmail(email string) (*User, error)
 	Update(user *User) error
 	Delete(id uuid.UUID) error
-	List(offset, limit int) ([]*User, error)
+	List(params pagination.Params) (*pagination.Page[*User], error)
 }
 
-func (u *User) HashPassword(password string) error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+func (u *User) HashPassword(plain string) error {
+	hashedPassword, err := password.Default().Hash(plain)
 	if err != nil {
 		return err
 	}
-	u.Password = string(hashedPassword)
+	u.Password = hashedPassword
 	return nil
 }
 
-func (u *User) CheckPassword(password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
-	return err == nil
+func (u *User) CheckPassword(plain string) bool {
+	return password.Default().Verify(u.Password, plain)
+}
+
+// PasswordNeedsRehash reports whether the stored hash uses an outdated
+// algorithm or parameters and should be replaced on the next login.
+func (u *User) PasswordNeedsRehash() bool {
+	return password.Default().NeedsRehash(u.Password)
 }
 
 func (u *User) FullName() string {
@@ -0,0 +1,103 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProductOption is a dimension a product comes in, such as size or colour.
+type ProductOption struct {
+	ID        uuid.UUID            `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductID uuid.UUID            `json:"product_id" gorm:"type:uuid;not null;index"`
+	Name      string               `json:"name" gorm:"not null"`
+	Position  int                  `json:"position" gorm:"not null;default:0"`
+	Values    []ProductOptionValue `json:"values" gorm:"foreignKey:OptionID;constraint:OnDelete:CASCADE"`
+}
+
+type ProductOptionValue struct {
+	ID       uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OptionID uuid.UUID `json:"option_id" gorm:"type:uuid;not null;index"`
+	Value    string    `json:"value" gorm:"not null"`
+	Position int       `json:"position" gorm:"not null;default:0"`
+}
+
+// ProductVariant is one purchasable combination of option values with its
+// own SKU and stock. A nil Price falls back to the product's price and an
+// empty ImageURL to the product's image.
+type ProductVariant struct {
+	ID           uuid.UUID            `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductID    uuid.UUID            `json:"product_id" gorm:"type:uuid;not null;index"`
+	SKU          string               `json:"sku" gorm:"uniqueIndex;not null"`
+	Price        *float64             `json:"price"`
+	Stock        int                  `json:"stock" gorm:"not null;default:0"`
+	ImageURL     string               `json:"image_url"`
+	IsActive     bool                 `json:"is_active" gorm:"default:true"`
+	OptionValues []ProductOptionValue `json:"option_values" gorm:"many2many:product_variant_option_values;constraint:OnDelete:CASCADE"`
+	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+}
+
+type ProductVariantRepository interface {
+	// SetOptions replaces every option of a product and its values.
+	SetOptions(productID uuid.UUID, options []ProductOption) error
+	// Create records the variant's initial stock as a receipt.
+	Create(variant *ProductVariant) error
+	GetByID(id uuid.UUID) (*ProductVariant, error)
+	GetBySKU(sku string) (*ProductVariant, error)
+	// Update saves everything but stock; see UpdateStock.
+	Update(variant *ProductVariant) error
+	Delete(id uuid.UUID) error
+	// UpdateStock adds quantity, which may be negative, to the variant's
+	// stock and records the movement.
+	UpdateStock(id uuid.UUID, quantity int, change StockChange) error
+}
+
+// HasVariants reports whether the product is sold through variants. It
+// relies on Variants having been loaded.
+func (p *Product) HasVariants() bool {
+	return len(p.Variants) > 0
+}
+
+// Variant returns the loaded variant with the given id, or nil.
+func (p *Product) Variant(id uuid.UUID) *ProductVariant {
+	for i := range p.Variants {
+		if p.Variants[i].ID == id {
+			return &p.Variants[i]
+		}
+	}
+	return nil
+}
+
+// VariantLabel names a variant by its option values in option order, e.g.
+// "M / Red".
+func (p *Product) VariantLabel(v *ProductVariant) string {
+	selected := make(map[uuid.UUID]string, len(v.OptionValues))
+	for _, value := range v.OptionValues {
+		selected[value.OptionID] = value.Value
+	}
+
+	parts := make([]string, 0, len(p.Options))
+	for _, option := range p.Options {
+		if value, ok := selected[option.ID]; ok {
+			parts = append(parts, value)
+		}
+	}
+	return strings.Join(parts, " / ")
+}
+
+func (v *ProductVariant) EffectivePrice(product *Product) float64 {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return product.Price
+}
+
+func (v *ProductVariant) IsInStock() bool {
+	return v.Stock > 0 && v.IsActive
+}
+
+func (v *ProductVariant) CanFulfillQuantity(quantity int) bool {
+	return v.Stock >= quantity && v.IsActive
+}
@@ -0,0 +1,62 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Warehouse is a location stock is held and shipped from. Lower Priority
+// values are preferred when allocating orders, and the warehouse with the
+// lowest value is the default for stock changes that name no location.
+// Country is the ISO 3166-1 alpha-2 code used by the nearest allocation
+// strategy; Pickup marks locations customers can collect from.
+type Warehouse struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Code      string    `json:"code" gorm:"uniqueIndex;not null"`
+	Name      string    `json:"name" gorm:"not null"`
+	Country   string    `json:"country" gorm:"size:2"`
+	Priority  int       `json:"priority" gorm:"not null;default:0"`
+	Pickup    bool      `json:"pickup" gorm:"not null;default:false"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StockLevel is the stock of a product, or of one of its variants, held at
+// a warehouse. Product.Stock and ProductVariant.Stock are the totals across
+// every warehouse.
+type StockLevel struct {
+	ID          uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	WarehouseID uuid.UUID       `json:"warehouse_id" gorm:"type:uuid;not null;index"`
+	Warehouse   *Warehouse      `json:"-" gorm:"foreignKey:WarehouseID;constraint:OnDelete:RESTRICT"`
+	ProductID   uuid.UUID       `json:"product_id" gorm:"type:uuid;not null;index"`
+	Product     *Product        `json:"-" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	VariantID   *uuid.UUID      `json:"variant_id,omitempty" gorm:"type:uuid;index"`
+	Variant     *ProductVariant `json:"-" gorm:"foreignKey:VariantID;constraint:OnDelete:CASCADE"`
+	Quantity    int             `json:"quantity" gorm:"not null;default:0"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// OrderItemAllocation records how much of an order item ships from a
+// warehouse. An item split across warehouses has one allocation for each.
+type OrderItemAllocation struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrderItemID uuid.UUID `json:"order_item_id" gorm:"type:uuid;not null;index"`
+	WarehouseID uuid.UUID `json:"warehouse_id" gorm:"type:uuid;not null"`
+	Quantity    int       `json:"quantity" gorm:"not null"`
+}
+
+type WarehouseRepository interface {
+	Create(warehouse *Warehouse) error
+	GetByID(id uuid.UUID) (*Warehouse, error)
+	// List returns every warehouse, by priority.
+	List() ([]*Warehouse, error)
+	Update(warehouse *Warehouse) error
+	// Delete removes a warehouse that holds no stock.
+	Delete(id uuid.UUID) error
+	// HasStock reports whether any stock is held at the warehouse.
+	HasStock(id uuid.UUID) (bool, error)
+	// ListStockLevels returns the non-empty stock levels of the given
+	// products and their variants.
+	ListStockLevels(productIDs []uuid.UUID) ([]*StockLevel, error)
+}
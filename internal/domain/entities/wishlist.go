@@ -0,0 +1,80 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Wishlist is a named list of products a customer has saved for later. A
+// customer may keep several. A non-nil ShareToken makes the list readable
+// by anyone who has the token.
+type Wishlist struct {
+	ID         uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     uuid.UUID      `json:"-" gorm:"type:uuid;not null;index"`
+	User       *User          `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Name       string         `json:"name" gorm:"size:100;not null"`
+	ShareToken *string        `json:"share_token,omitempty" gorm:"uniqueIndex"`
+	Items      []WishlistItem `json:"items" gorm:"foreignKey:WishlistID;constraint:OnDelete:CASCADE"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// WishlistItem is a saved product, or one variant of it. Price and InStock
+// are not stored: they describe the product as it is now, and are filled
+// in by Refresh.
+type WishlistItem struct {
+	ID         uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	WishlistID uuid.UUID       `json:"wishlist_id" gorm:"type:uuid;not null;index"`
+	ProductID  uuid.UUID       `json:"product_id" gorm:"type:uuid;not null"`
+	Product    Product         `json:"product" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	VariantID  *uuid.UUID      `json:"variant_id" gorm:"type:uuid"`
+	Variant    *ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID;constraint:OnDelete:CASCADE"`
+	Price      float64         `json:"price" gorm:"-"`
+	InStock    bool            `json:"in_stock" gorm:"-"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type WishlistRepository interface {
+	Create(wishlist *Wishlist) error
+	// GetByID and GetByShareToken load the wishlist with its items'
+	// products and variants.
+	GetByID(id uuid.UUID) (*Wishlist, error)
+	GetByShareToken(token string) (*Wishlist, error)
+	// ListByUserID returns the user's wishlists, oldest first.
+	ListByUserID(userID uuid.UUID) ([]*Wishlist, error)
+	// Update saves the wishlist's own columns; items are written through
+	// AddItem and RemoveItem.
+	Update(wishlist *Wishlist) error
+	Delete(id uuid.UUID) error
+	// AddItem saves the item unless the wishlist already holds the same
+	// product and variant.
+	AddItem(wishlistID uuid.UUID, item *WishlistItem) error
+	// RemoveItem addresses the item for productID and variantID; a nil
+	// variantID is the item without a variant.
+	RemoveItem(wishlistID, productID uuid.UUID, variantID *uuid.UUID) error
+}
+
+// Refresh fills in the current price and stock status of every item.
+func (w *Wishlist) Refresh() {
+	for i := range w.Items {
+		item := &w.Items[i]
+		item.Price = item.Product.Price
+		item.InStock = item.Product.IsInStock()
+		if item.Variant != nil {
+			item.Price = item.Variant.EffectivePrice(&item.Product)
+			item.InStock = item.Product.IsActive && item.Variant.IsInStock()
+		}
+	}
+}
+
+// Contains reports whether the wishlist holds the product, or the given
+// variant of it.
+func (w *Wishlist) Contains(productID uuid.UUID, variantID *uuid.UUID) bool {
+	for _, item := range w.Items {
+		if item.ProductID == productID && sameVariant(item.VariantID, variantID) {
+			return true
+		}
+	}
+	return false
+}
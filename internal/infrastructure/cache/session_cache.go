@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"prototype-fiber/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	sessionStatusPrefix   = "session:status:"
+	sessionLastSeenPrefix = "session:last_seen:"
+)
+
+type RedisSessionCache struct {
+	client *redis.Client
+}
+
+func NewSessionCache(client *redis.Client) entities.SessionCache {
+	return &RedisSessionCache{client: client}
+}
+
+func (c *RedisSessionCache) GetStatus(id uuid.UUID) (bool, bool, error) {
+	value, err := c.client.Get(context.Background(), sessionStatusPrefix+id.String()).Result()
+	if err == redis.Nil {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	return value == "active", true, nil
+}
+
+func (c *RedisSessionCache) SetStatus(id uuid.UUID, active bool, ttl time.Duration) error {
+	value := "revoked"
+	if active {
+		value = "active"
+	}
+	return c.client.Set(context.Background(), sessionStatusPrefix+id.String(), value, ttl).Err()
+}
+
+func (c *RedisSessionCache) SetLastSeen(id uuid.UUID, lastSeen time.Time, ttl time.Duration) error {
+	return c.client.Set(context.Background(), sessionLastSeenPrefix+id.String(), lastSeen.Unix(), ttl).Err()
+}
+
+func (c *RedisSessionCache) GetLastSeen(ids []uuid.UUID) (map[uuid.UUID]time.Time, error) {
+	result := make(map[uuid.UUID]time.Time)
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = sessionLastSeenPrefix + id.String()
+	}
+
+	values, err := c.client.MGet(context.Background(), keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, value := range values {
+		str, ok := value.(string)
+		if !ok {
+			continue
+		}
+		unix, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			continue
+		}
+		result[ids[i]] = time.Unix(unix, 0)
+	}
+	return result, nil
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"prototype-fiber/internal/domain/entities"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const suggestionPrefix = "products:suggest:"
+
+type RedisSuggestionCache struct {
+	client *redis.Client
+}
+
+func NewSuggestionCache(client *redis.Client) entities.SuggestionCache {
+	return &RedisSuggestionCache{client: client}
+}
+
+func suggestionKey(prefix string, limit int) string {
+	return fmt.Sprintf("%s%d:%s", suggestionPrefix, limit, prefix)
+}
+
+func (c *RedisSuggestionCache) GetSuggestions(prefix string, limit int) ([]*entities.ProductSuggestion, bool, error) {
+	data, err := c.client.Get(context.Background(), suggestionKey(prefix, limit)).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var suggestions []*entities.ProductSuggestion
+	if err := json.Unmarshal(data, &suggestions); err != nil {
+		return nil, false, err
+	}
+	return suggestions, true, nil
+}
+
+func (c *RedisSuggestionCache) SetSuggestions(prefix string, limit int, suggestions []*entities.ProductSuggestion, ttl time.Duration) error {
+	data, err := json.Marshal(suggestions)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(context.Background(), suggestionKey(prefix, limit), data, ttl).Err()
+}
@@ -14,23 +14,266 @@ func NewPostgresDB(cfg config.DatabaseConfig) (*gorm.DB, error) {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
 		cfg.Host, cfg.User, cfg.Password, cfg.Name, cfg.Port, cfg.SSLMode)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		TranslateError: true,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	// Free-text categories predate the category tree; convert them once,
+	// when the tree's table is first created.
+	convertCategories := !db.Migrator().HasTable(&entities.Category{})
+	// Stock from before the ledger is carried into it as opening balances.
+	openLedger := !db.Migrator().HasTable(&entities.StockMovement{})
+	// Stock from before warehouses is placed at a default warehouse.
+	openWarehouses := !db.Migrator().HasTable(&entities.Warehouse{})
+
 	// Auto migrate tables
 	if err := db.AutoMigrate(
 		&entities.User{},
+		&entities.Session{},
+		&entities.ImpersonationLog{},
+		&entities.Category{},
 		&entities.Product{},
+		&entities.ProductOption{},
+		&entities.ProductOptionValue{},
+		&entities.ProductVariant{},
+		&entities.Warehouse{},
+		&entities.StockLevel{},
+		&entities.StockMovement{},
+		&entities.StockAlert{},
+		&entities.BackInStockSubscription{},
+		&entities.Review{},
+		&entities.ReviewVote{},
+		&entities.ProductMedia{},
+		&entities.MediaThumbnail{},
+		&entities.ImportJob{},
+		&entities.ImportRowError{},
+		&entities.Wishlist{},
+		&entities.WishlistItem{},
 		&entities.Cart{},
 		&entities.CartItem{},
+		&entities.AbandonedCart{},
 		&entities.Order{},
 		&entities.OrderItem{},
+		&entities.OrderItemAllocation{},
 		&entities.Payment{},
 	); err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
+	if err := migrateProductSearch(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate product search: %w", err)
+	}
+
+	if convertCategories {
+		if err := migrateCategories(db); err != nil {
+			return nil, fmt.Errorf("failed to migrate categories: %w", err)
+		}
+	}
+
+	if err := migrateStockLedger(db, openLedger); err != nil {
+		return nil, fmt.Errorf("failed to migrate stock ledger: %w", err)
+	}
+
+	if err := migrateWarehouses(db, openWarehouses); err != nil {
+		return nil, fmt.Errorf("failed to migrate warehouses: %w", err)
+	}
+
+	if err := migrateStockAlerts(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate stock alerts: %w", err)
+	}
+
+	if err := migrateWishlists(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate wishlists: %w", err)
+	}
+
+	if err := migrateOrderItemSnapshots(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate order item snapshots: %w", err)
+	}
+
 	return db, nil
-}
\ No newline at end of file
+}
+
+// migrateProductSearch adds what AutoMigrate cannot express: a generated
+// tsvector weighting name over category over description, its GIN index,
+// a trigram index on name for typo-tolerant matching and a prefix index for
+// autocomplete.
+func migrateProductSearch(db *gorm.DB) error {
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		`ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+			setweight(to_tsvector('english', replace(coalesce(name, ''), '-', '')), 'A') ||
+			setweight(to_tsvector('english', coalesce(category, '')), 'B') ||
+			setweight(to_tsvector('english', coalesce(description, '')), 'C')
+		) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector)`,
+		`CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (name gin_trgm_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_products_name_prefix ON products (lower(name) text_pattern_ops)`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateStockLedger makes stock_movements append-only and, when the table
+// is new, opens the ledger with the current stock of every product without
+// variants and of every variant.
+func migrateStockLedger(db *gorm.DB, open bool) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			`CREATE OR REPLACE FUNCTION stock_movements_append_only() RETURNS trigger AS $$
+			BEGIN
+				RAISE EXCEPTION 'stock_movements is append-only';
+			END
+			$$ LANGUAGE plpgsql`,
+			`DROP TRIGGER IF EXISTS stock_movements_append_only ON stock_movements`,
+			`CREATE TRIGGER stock_movements_append_only BEFORE UPDATE OR DELETE ON stock_movements
+			FOR EACH ROW EXECUTE FUNCTION stock_movements_append_only()`,
+		}
+		if open {
+			statements = append(statements,
+				`INSERT INTO stock_movements (product_id, delta, reason, note, balance, created_at)
+				SELECT id, stock, 'adjustment', 'opening balance', stock, now()
+				FROM products
+				WHERE stock <> 0
+					AND NOT EXISTS (SELECT 1 FROM product_variants WHERE product_variants.product_id = products.id)`,
+				`INSERT INTO stock_movements (product_id, variant_id, delta, reason, note, balance, created_at)
+				SELECT product_id, id, stock, 'adjustment', 'opening balance', stock, now()
+				FROM product_variants
+				WHERE stock <> 0`,
+			)
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// migrateWarehouses makes stock levels unique per location, which a plain
+// unique index cannot do while variant_id is null for products without
+// variants. When the table is new, it creates a default warehouse holding
+// all existing stock.
+func migrateWarehouses(db *gorm.DB, open bool) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			`CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_levels_location ON stock_levels
+			(warehouse_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'))`,
+		}
+		if open {
+			statements = append(statements,
+				`INSERT INTO warehouses (code, name, country, priority, pickup, created_at, updated_at)
+				VALUES ('MAIN', 'Main warehouse', '', 0, false, now(), now())`,
+				`INSERT INTO stock_levels (warehouse_id, product_id, quantity, updated_at)
+				SELECT (SELECT id FROM warehouses WHERE code = 'MAIN'), id, stock, now()
+				FROM products
+				WHERE stock <> 0
+					AND NOT EXISTS (SELECT 1 FROM product_variants WHERE product_variants.product_id = products.id)`,
+				`INSERT INTO stock_levels (warehouse_id, product_id, variant_id, quantity, updated_at)
+				SELECT (SELECT id FROM warehouses WHERE code = 'MAIN'), product_id, id, stock, now()
+				FROM product_variants
+				WHERE stock <> 0`,
+			)
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// migrateStockAlerts allows at most one open alert per product, which
+// StockAlertRepository.Open depends on.
+func migrateStockAlerts(db *gorm.DB) error {
+	return db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_alerts_open ON stock_alerts (product_id)
+		WHERE status = 'open'`).Error
+}
+
+// migrateWishlists saves each product or variant once per wishlist, which
+// like stock levels needs an expression index while variant_id is null for
+// products without variants.
+func migrateWishlists(db *gorm.DB) error {
+	return db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_wishlist_items_product ON wishlist_items
+		(wishlist_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'))`).Error
+}
+
+// migrateOrderItemSnapshots drops the foreign keys from order items to
+// products and variants, which kept ordered products from being deleted,
+// and fills in the snapshot of items ordered before there was one from the
+// catalogue as it is now.
+func migrateOrderItemSnapshots(db *gorm.DB) error {
+	statements := []string{
+		`ALTER TABLE order_items DROP CONSTRAINT IF EXISTS fk_order_items_product`,
+		`ALTER TABLE order_items DROP CONSTRAINT IF EXISTS fk_order_items_variant`,
+		// Variants first: the product's details fill in what they leave empty
+		`UPDATE order_items SET sku = product_variants.sku,
+			image_url = NULLIF(product_variants.image_url, ''),
+			variant_options = (
+				SELECT jsonb_agg(jsonb_build_object('name', product_options.name, 'value', product_option_values.value)
+					ORDER BY product_options.position)
+				FROM product_variant_option_values
+				JOIN product_option_values ON product_option_values.id = product_variant_option_values.product_option_value_id
+				JOIN product_options ON product_options.id = product_option_values.option_id
+				WHERE product_variant_option_values.product_variant_id = product_variants.id
+			)
+			FROM product_variants
+			WHERE product_variants.id = order_items.variant_id AND order_items.product_name = ''`,
+		`UPDATE order_items SET product_name = products.name,
+			sku = COALESCE(NULLIF(order_items.sku, ''), products.sku),
+			image_url = COALESCE(NULLIF(order_items.image_url, ''), products.image_url)
+			FROM products
+			WHERE products.id = order_items.product_id AND order_items.product_name = ''`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// legacyCategorySlug is slug.Make in SQL.
+const legacyCategorySlug = `trim(both '-' from regexp_replace(lower(products.category), '[^a-z0-9]+', '-', 'g'))`
+
+// migrateCategories turns the distinct free-text product categories into
+// top-level categories and assigns each product to its own. Strings that
+// differ only in case or punctuation share a category, named after the
+// first spelling alphabetically.
+func migrateCategories(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO categories (name, slug, position, is_active, created_at, updated_at)
+			SELECT min(trim(products.category)), ` + legacyCategorySlug + `, 0, true, now(), now()
+			FROM products
+			WHERE ` + legacyCategorySlug + ` <> ''
+			GROUP BY ` + legacyCategorySlug + `
+			ON CONFLICT (slug) DO NOTHING`).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec(`INSERT INTO product_categories (product_id, category_id)
+			SELECT products.id, categories.id
+			FROM products JOIN categories ON categories.slug = ` + legacyCategorySlug + `
+			ON CONFLICT DO NOTHING`).Error
+		if err != nil {
+			return err
+		}
+
+		// Normalise the search text to the category names.
+		return tx.Exec(`UPDATE products SET category = COALESCE((
+			SELECT string_agg(categories.name, ' ' ORDER BY categories.name)
+			FROM product_categories JOIN categories ON categories.id = product_categories.category_id
+			WHERE product_categories.product_id = products.id
+		), '')`).Error
+	})
+}
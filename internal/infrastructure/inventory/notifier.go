@@ -0,0 +1,26 @@
+package inventory
+
+import (
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/logger"
+)
+
+// LogNotifier writes stock notifications to the application log, standing
+// in until they are delivered by email.
+type LogNotifier struct {
+	logger *logger.Logger
+}
+
+func NewLogNotifier(logger *logger.Logger) entities.StockNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) LowStock(product *entities.Product, alert *entities.StockAlert) {
+	n.logger.Warnf("Low stock: %s (%s) is down to %d, reorder threshold %d", product.Name, product.SKU, alert.Stock, alert.Threshold)
+}
+
+func (n *LogNotifier) BackInStock(product *entities.Product, subscriptions []*entities.BackInStockSubscription) {
+	for _, subscription := range subscriptions {
+		n.logger.Infof("Back in stock: notifying user %s that %s (%s) is available", subscription.UserID, product.Name, product.SKU)
+	}
+}
@@ -0,0 +1,178 @@
+// Package inventory watches stock writes for the moments people want to hear
+// about: a product running low, and a product coming back in stock.
+package inventory
+
+import (
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// Watcher compares a product before and after a write that can move its
+// stock. Falling to the reorder threshold opens a stock alert, rising back
+// above it resolves the alert, and becoming purchasable again notifies the
+// product's back-in-stock subscribers, each of them once.
+type Watcher struct {
+	products      entities.ProductRepository
+	alerts        entities.StockAlertRepository
+	subscriptions entities.BackInStockRepository
+	notifier      entities.StockNotifier
+	logger        *logger.Logger
+}
+
+func NewWatcher(products entities.ProductRepository, alerts entities.StockAlertRepository, subscriptions entities.BackInStockRepository, notifier entities.StockNotifier, logger *logger.Logger) *Watcher {
+	return &Watcher{
+		products:      products,
+		alerts:        alerts,
+		subscriptions: subscriptions,
+		notifier:      notifier,
+		logger:        logger,
+	}
+}
+
+// watch runs write and reacts to what it did to the product's stock. Once
+// the write has succeeded its caller must not see an error, or an order
+// that was placed would be reported as failed, so reacting only logs.
+func (w *Watcher) watch(productID uuid.UUID, write func() error) error {
+	return w.watchAll([]uuid.UUID{productID}, write)
+}
+
+// watchAll is watch for a write that moves the stock of several products.
+// It reads only their stock levels, once before the write and once after.
+func (w *Watcher) watchAll(productIDs []uuid.UUID, write func() error) error {
+	before, err := w.products.GetStockLevels(productIDs)
+	if err != nil {
+		return err
+	}
+	for _, id := range productIDs {
+		if before[id] == nil {
+			return apperrors.ErrNotFound
+		}
+	}
+	if err := write(); err != nil {
+		return err
+	}
+	after, err := w.products.GetStockLevels(productIDs)
+	if err != nil {
+		w.logger.Errorf("Updating stock alerts for products %v: %v", productIDs, err)
+		return nil
+	}
+	for _, id := range productIDs {
+		if after[id] == nil {
+			continue
+		}
+		if err := w.react(before[id], after[id]); err != nil {
+			w.logger.Errorf("Updating stock alerts for product %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) react(before, after *entities.Product) error {
+	switch {
+	case after.CrossedReorderThreshold(before.Stock):
+		alert := &entities.StockAlert{ProductID: after.ID, Threshold: after.ReorderThreshold, Stock: after.Stock}
+		opened, err := w.alerts.Open(alert)
+		if err != nil {
+			return err
+		}
+		if opened {
+			w.notifier.LowStock(after, alert)
+		}
+	case after.RecoveredFromThreshold(before.Stock):
+		if err := w.alerts.ResolveOpen(after.ID); err != nil {
+			return err
+		}
+	}
+
+	if !before.IsInStock() && after.IsInStock() {
+		subscriptions, err := w.subscriptions.ClaimPending(after.ID)
+		if err != nil {
+			return err
+		}
+		if len(subscriptions) > 0 {
+			w.notifier.BackInStock(after, subscriptions)
+		}
+	}
+	return nil
+}
+
+// WatchedProductRepository watches the product writes that move stock or
+// decide whether the product can be bought.
+type WatchedProductRepository struct {
+	entities.ProductRepository
+	watcher *Watcher
+}
+
+func NewWatchedProductRepository(repo entities.ProductRepository, watcher *Watcher) entities.ProductRepository {
+	return &WatchedProductRepository{ProductRepository: repo, watcher: watcher}
+}
+
+func (r *WatchedProductRepository) Update(product *entities.Product) error {
+	return r.watcher.watch(product.ID, func() error {
+		return r.ProductRepository.Update(product)
+	})
+}
+
+func (r *WatchedProductRepository) UpdateStock(id uuid.UUID, quantity int, change entities.StockChange) error {
+	return r.watcher.watch(id, func() error {
+		return r.ProductRepository.UpdateStock(id, quantity, change)
+	})
+}
+
+func (r *WatchedProductRepository) RecordOrder(order *entities.Order) error {
+	return r.watcher.watchAll(order.ProductIDs(), func() error {
+		return r.ProductRepository.RecordOrder(order)
+	})
+}
+
+func (r *WatchedProductRepository) ReturnOrder(order *entities.Order, status entities.OrderStatus, returns []entities.StockReturn) error {
+	return r.watcher.watchAll(entities.RestockedProductIDs(returns), func() error {
+		return r.ProductRepository.ReturnOrder(order, status, returns)
+	})
+}
+
+// WatchedVariantRepository watches variant writes, which move the stock of
+// the product they belong to.
+type WatchedVariantRepository struct {
+	entities.ProductVariantRepository
+	watcher *Watcher
+}
+
+func NewWatchedVariantRepository(repo entities.ProductVariantRepository, watcher *Watcher) entities.ProductVariantRepository {
+	return &WatchedVariantRepository{ProductVariantRepository: repo, watcher: watcher}
+}
+
+func (r *WatchedVariantRepository) Create(variant *entities.ProductVariant) error {
+	return r.watcher.watch(variant.ProductID, func() error {
+		return r.ProductVariantRepository.Create(variant)
+	})
+}
+
+func (r *WatchedVariantRepository) Update(variant *entities.ProductVariant) error {
+	return r.watcher.watch(variant.ProductID, func() error {
+		return r.ProductVariantRepository.Update(variant)
+	})
+}
+
+func (r *WatchedVariantRepository) Delete(id uuid.UUID) error {
+	variant, err := r.ProductVariantRepository.GetByID(id)
+	if err != nil {
+		return err
+	}
+	return r.watcher.watch(variant.ProductID, func() error {
+		return r.ProductVariantRepository.Delete(id)
+	})
+}
+
+func (r *WatchedVariantRepository) UpdateStock(id uuid.UUID, quantity int, change entities.StockChange) error {
+	variant, err := r.ProductVariantRepository.GetByID(id)
+	if err != nil {
+		return err
+	}
+	return r.watcher.watch(variant.ProductID, func() error {
+		return r.ProductVariantRepository.UpdateStock(id, quantity, change)
+	})
+}
@@ -0,0 +1,22 @@
+package mail
+
+import (
+	"fmt"
+
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/config"
+)
+
+const (
+	DriverFile = "file"
+)
+
+// New returns the mailer selected by cfg.Driver.
+func New(cfg config.MailConfig) (entities.Mailer, error) {
+	switch cfg.Driver {
+	case "", DriverFile:
+		return NewFileMailer(cfg.FileDir, cfg.From)
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,313 @@
+package search
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"sync"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+// Field weights mirror the Postgres search_vector: name > category >
+// description.
+const (
+	nameWeight        = 3.0
+	categoryWeight    = 2.0
+	descriptionWeight = 1.0
+	// typoPenalty scales the weight of a term matched with edits.
+	typoPenalty = 0.5
+
+	relevanceSort     = "relevance"
+	maxCategoryFacets = 20
+)
+
+type document struct {
+	product entities.Product
+	terms   map[string]float64
+}
+
+// EmbeddedIndex is an in-memory inverted index. It needs no external
+// service but lives only as long as the process, so it is filled by a
+// reindex at startup.
+type EmbeddedIndex struct {
+	mu       sync.RWMutex
+	docs     map[uuid.UUID]*document
+	postings map[string]map[uuid.UUID]float64
+}
+
+func NewEmbeddedIndex() entities.SearchIndex {
+	return &EmbeddedIndex{
+		docs:     make(map[uuid.UUID]*document),
+		postings: make(map[string]map[uuid.UUID]float64),
+	}
+}
+
+func (i *EmbeddedIndex) StoresProducts() bool {
+	return true
+}
+
+func (i *EmbeddedIndex) Index(products ...*entities.Product) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, product := range products {
+		i.remove(product.ID)
+
+		doc := &document{product: *product, terms: make(map[string]float64)}
+		doc.product.NameHighlight, doc.product.DescriptionHighlight = "", ""
+		for _, field := range []struct {
+			text   string
+			weight float64
+		}{
+			{product.Name, nameWeight},
+			{strings.Join(product.CategoryNames(), " "), categoryWeight},
+			{product.Description, descriptionWeight},
+		} {
+			for _, term := range tokenize(field.text) {
+				doc.terms[term] += field.weight
+			}
+		}
+
+		i.docs[product.ID] = doc
+		for term, weight := range doc.terms {
+			if i.postings[term] == nil {
+				i.postings[term] = make(map[uuid.UUID]float64)
+			}
+			i.postings[term][product.ID] = weight
+		}
+	}
+	return nil
+}
+
+func (i *EmbeddedIndex) Delete(id uuid.UUID) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.remove(id)
+	return nil
+}
+
+func (i *EmbeddedIndex) Reset() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.docs = make(map[uuid.UUID]*document)
+	i.postings = make(map[string]map[uuid.UUID]float64)
+	return nil
+}
+
+// remove drops a document; the caller holds the write lock.
+func (i *EmbeddedIndex) remove(id uuid.UUID) {
+	doc, ok := i.docs[id]
+	if !ok {
+		return
+	}
+	for term := range doc.terms {
+		delete(i.postings[term], id)
+		if len(i.postings[term]) == 0 {
+			delete(i.postings, term)
+		}
+	}
+	delete(i.docs, id)
+}
+
+type hit struct {
+	doc   *document
+	score float64
+}
+
+func (i *EmbeddedIndex) Search(query entities.SearchQuery) (*entities.SearchResult, error) {
+	params := query.Page
+	if params.UsesCursor() && params.After.Sort != relevanceSort {
+		return nil, apperrors.Validation(apperrors.CodeInvalidQuery, "cursor does not match the requested sort")
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	hits, matched := i.match(query.Text)
+
+	filtered := hits[:0]
+	for _, h := range hits {
+		if h.doc.product.IsActive && matchesFilter(&h.doc.product, query.Filter) {
+			filtered = append(filtered, h)
+		}
+	}
+	hits = filtered
+
+	sort.Slice(hits, func(a, b int) bool { return ranksAfter(hits[b], hits[a].score, hits[a].doc.product.ID) })
+
+	page := &pagination.Page[*entities.Product]{}
+	if params.WithTotal {
+		total := int64(len(hits))
+		page.Total = &total
+	}
+
+	start := min(params.Offset, len(hits))
+	if params.UsesCursor() {
+		after, ok := params.After.Value.(float64)
+		if !ok {
+			return nil, apperrors.Validation(apperrors.CodeInvalidQuery, "cursor is invalid")
+		}
+		start = sort.Search(len(hits), func(n int) bool {
+			return ranksAfter(hits[n], after, params.After.ID)
+		})
+	}
+	end := min(len(hits), start+params.Limit)
+
+	page.Items = make([]*entities.Product, 0, end-start)
+	for _, h := range hits[start:end] {
+		product := h.doc.product
+		product.NameHighlight = highlight(product.Name, matched)
+		product.DescriptionHighlight = snippet(product.Description, matched)
+		product.SearchRank = h.score
+		page.Items = append(page.Items, &product)
+	}
+	if end < len(hits) {
+		last := hits[end-1]
+		page.HasMore = true
+		page.NextCursor = pagination.Cursor{
+			CreatedAt: last.doc.product.CreatedAt,
+			ID:        last.doc.product.ID,
+			Sort:      relevanceSort,
+			Value:     last.score,
+		}.Encode()
+	}
+
+	return &entities.SearchResult{Products: page, Facets: facets(hits)}, nil
+}
+
+// ranksAfter reports whether h comes after the position (score, id). Hits
+// are ordered by score, then id, both descending, as in the Postgres index.
+func ranksAfter(h hit, score float64, id uuid.UUID) bool {
+	if h.score != score {
+		return h.score < score
+	}
+	return bytes.Compare(h.doc.product.ID[:], id[:]) < 0
+}
+
+// match returns the documents containing every query term, exactly or
+// within maxTypos edits, and the set of index terms that matched.
+func (i *EmbeddedIndex) match(text string) ([]hit, map[string]bool) {
+	matched := make(map[string]bool)
+	var scores map[uuid.UUID]float64
+
+	seen := make(map[string]bool)
+	for _, term := range tokenize(text) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+
+		termScores := make(map[uuid.UUID]float64)
+		if postings, ok := i.postings[term]; ok {
+			matched[term] = true
+			for id, weight := range postings {
+				termScores[id] += weight
+			}
+		} else if limit := maxTypos(term); limit > 0 {
+			for candidate, postings := range i.postings {
+				if editDistance(term, candidate, limit) > limit {
+					continue
+				}
+				matched[candidate] = true
+				for id, weight := range postings {
+					termScores[id] = max(termScores[id], weight*typoPenalty)
+				}
+			}
+		}
+
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for id := range scores {
+			if weight, ok := termScores[id]; ok {
+				scores[id] += weight
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	hits := make([]hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, hit{doc: i.docs[id], score: score})
+	}
+	return hits, matched
+}
+
+func matchesFilter(p *entities.Product, filter entities.ProductFilter) bool {
+	if len(filter.CategoryIDs) > 0 && !p.InCategory(filter.CategoryIDs) {
+		return false
+	}
+	if filter.MinPrice != nil && p.Price < *filter.MinPrice {
+		return false
+	}
+	if filter.MaxPrice != nil && p.Price > *filter.MaxPrice {
+		return false
+	}
+	if filter.InStockOnly && p.Stock <= 0 {
+		return false
+	}
+	if filter.CreatedAfter != nil && !p.CreatedAt.After(*filter.CreatedAfter) {
+		return false
+	}
+	return true
+}
+
+// priceBucket is the index of the bucket holding price: the first bound
+// above it, or the open-ended last bucket.
+func priceBucket(price float64) int {
+	return sort.Search(len(entities.PriceBucketBounds), func(n int) bool {
+		return entities.PriceBucketBounds[n] > price
+	})
+}
+
+func facets(hits []hit) *entities.SearchFacets {
+	result := &entities.SearchFacets{Categories: []entities.FacetCount{}}
+
+	categories := make(map[string]*entities.FacetCount)
+	buckets := make([]int64, len(entities.PriceBucketBounds)+1)
+	for _, h := range hits {
+		p := &h.doc.product
+		for _, category := range p.Categories {
+			if categories[category.Slug] == nil {
+				categories[category.Slug] = &entities.FacetCount{Value: category.Slug, Name: category.Name}
+			}
+			categories[category.Slug].Count++
+		}
+		buckets[priceBucket(p.Price)]++
+		if p.Stock > 0 {
+			result.InStock++
+		} else {
+			result.OutOfStock++
+		}
+	}
+
+	for _, count := range categories {
+		result.Categories = append(result.Categories, *count)
+	}
+	sort.Slice(result.Categories, func(a, b int) bool {
+		if result.Categories[a].Count != result.Categories[b].Count {
+			return result.Categories[a].Count > result.Categories[b].Count
+		}
+		return result.Categories[a].Value < result.Categories[b].Value
+	})
+	if len(result.Categories) > maxCategoryFacets {
+		result.Categories = result.Categories[:maxCategoryFacets]
+	}
+
+	lower := 0.0
+	for n, upper := range entities.PriceBucketBounds {
+		max := upper
+		result.PriceBuckets = append(result.PriceBuckets, entities.PriceBucket{Min: lower, Max: &max, Count: buckets[n]})
+		lower = upper
+	}
+	result.PriceBuckets = append(result.PriceBuckets, entities.PriceBucket{Min: lower, Count: buckets[len(buckets)-1]})
+
+	return result
+}
@@ -0,0 +1,57 @@
+package search
+
+import (
+	"prototype-fiber/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PostgresIndex searches the products table directly. Its search_vector is a
+// generated column, so Postgres keeps it current on every write and Index and
+// Delete have nothing to do.
+type PostgresIndex struct {
+	db          *gorm.DB
+	productRepo entities.ProductRepository
+}
+
+func NewPostgresIndex(db *gorm.DB, productRepo entities.ProductRepository) entities.SearchIndex {
+	return &PostgresIndex{db: db, productRepo: productRepo}
+}
+
+func (i *PostgresIndex) StoresProducts() bool {
+	return false
+}
+
+func (i *PostgresIndex) Index(products ...*entities.Product) error {
+	return nil
+}
+
+func (i *PostgresIndex) Delete(id uuid.UUID) error {
+	return nil
+}
+
+func (i *PostgresIndex) Search(query entities.SearchQuery) (*entities.SearchResult, error) {
+	products, err := i.productRepo.SearchFiltered(query.Text, query.Filter, query.Page)
+	if err != nil {
+		return nil, err
+	}
+
+	facets, err := i.productRepo.SearchFacets(query.Text, query.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.SearchResult{Products: products, Facets: facets}, nil
+}
+
+// Reset rebuilds the search indexes from the table, which repairs bloat and
+// any corruption; the indexed data itself cannot drift.
+func (i *PostgresIndex) Reset() error {
+	for _, index := range []string{"idx_products_search_vector", "idx_products_name_trgm"} {
+		if err := i.db.Exec("REINDEX INDEX " + index).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
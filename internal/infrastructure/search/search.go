@@ -0,0 +1,28 @@
+package search
+
+import (
+	"fmt"
+
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/config"
+
+	"gorm.io/gorm"
+)
+
+const (
+	EnginePostgres = "postgres"
+	EngineEmbedded = "embedded"
+)
+
+// New returns the search index selected by cfg.Engine. An embedded index
+// starts empty and must be filled with a reindex.
+func New(cfg config.SearchConfig, db *gorm.DB, productRepo entities.ProductRepository) (entities.SearchIndex, error) {
+	switch cfg.Engine {
+	case "", EnginePostgres:
+		return NewPostgresIndex(db, productRepo), nil
+	case EngineEmbedded:
+		return NewEmbeddedIndex(), nil
+	default:
+		return nil, fmt.Errorf("unknown search engine %q", cfg.Engine)
+	}
+}
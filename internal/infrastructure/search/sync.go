@@ -0,0 +1,220 @@
+package search
+
+import (
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// SyncedProductRepository forwards every product write to the search index
+// after it succeeds, so the index follows admin edits and stock movements
+// from orders alike. If indexing fails the write has still happened, so the
+// failure is logged rather than returned; a reindex repairs the drift.
+type SyncedProductRepository struct {
+	entities.ProductRepository
+	index  entities.SearchIndex
+	logger *logger.Logger
+}
+
+// NewSyncedProductRepository returns repo itself when the index reads the
+// products table and has nothing to sync, sparing every write a reload.
+func NewSyncedProductRepository(repo entities.ProductRepository, index entities.SearchIndex, logger *logger.Logger) entities.ProductRepository {
+	if !index.StoresProducts() {
+		return repo
+	}
+	return &SyncedProductRepository{ProductRepository: repo, index: index, logger: logger}
+}
+
+func (r *SyncedProductRepository) Create(product *entities.Product) error {
+	if err := r.ProductRepository.Create(product); err != nil {
+		return err
+	}
+	r.indexProduct(product)
+	return nil
+}
+
+func (r *SyncedProductRepository) Update(product *entities.Product) error {
+	if err := r.ProductRepository.Update(product); err != nil {
+		return err
+	}
+	r.indexProduct(product)
+	return nil
+}
+
+func (r *SyncedProductRepository) UpdateImageURL(id uuid.UUID, url string) error {
+	if err := r.ProductRepository.UpdateImageURL(id, url); err != nil {
+		return err
+	}
+	r.reindex(id)
+	return nil
+}
+
+func (r *SyncedProductRepository) Delete(id uuid.UUID) error {
+	if err := r.ProductRepository.Delete(id); err != nil {
+		return err
+	}
+	if err := r.index.Delete(id); err != nil {
+		r.logger.Errorf("Removing product %s from the search index: %v", id, err)
+	}
+	return nil
+}
+
+func (r *SyncedProductRepository) SetCategories(id uuid.UUID, categoryIDs []uuid.UUID) error {
+	if err := r.ProductRepository.SetCategories(id, categoryIDs); err != nil {
+		return err
+	}
+	r.reindex(id)
+	return nil
+}
+
+func (r *SyncedProductRepository) UpdateStock(id uuid.UUID, quantity int, change entities.StockChange) error {
+	if err := r.ProductRepository.UpdateStock(id, quantity, change); err != nil {
+		return err
+	}
+	r.reindex(id)
+	return nil
+}
+
+func (r *SyncedProductRepository) RecordOrder(order *entities.Order) error {
+	if err := r.ProductRepository.RecordOrder(order); err != nil {
+		return err
+	}
+	for _, id := range order.ProductIDs() {
+		r.reindex(id)
+	}
+	return nil
+}
+
+func (r *SyncedProductRepository) ReturnOrder(order *entities.Order, status entities.OrderStatus, returns []entities.StockReturn) error {
+	if err := r.ProductRepository.ReturnOrder(order, status, returns); err != nil {
+		return err
+	}
+	for _, id := range entities.RestockedProductIDs(returns) {
+		r.reindex(id)
+	}
+	return nil
+}
+
+func (r *SyncedProductRepository) reindex(id uuid.UUID) {
+	product, err := r.ProductRepository.GetByID(id)
+	if err != nil {
+		r.logger.Errorf("Updating the search index for product %s: %v", id, err)
+		return
+	}
+	r.indexProduct(product)
+}
+
+func (r *SyncedProductRepository) indexProduct(product *entities.Product) {
+	if err := r.index.Index(product); err != nil {
+		r.logger.Errorf("Updating the search index for product %s: %v", product.ID, err)
+	}
+}
+
+// SyncedVariantRepository re-indexes a variant's product after the variant
+// is written, since variants make up the product's stock and options.
+type SyncedVariantRepository struct {
+	entities.ProductVariantRepository
+	products *SyncedProductRepository
+}
+
+// NewSyncedVariantRepository returns repo itself when the index has nothing
+// to sync, as NewSyncedProductRepository does.
+func NewSyncedVariantRepository(repo entities.ProductVariantRepository, products entities.ProductRepository, index entities.SearchIndex, logger *logger.Logger) entities.ProductVariantRepository {
+	if !index.StoresProducts() {
+		return repo
+	}
+	return &SyncedVariantRepository{
+		ProductVariantRepository: repo,
+		products:                 &SyncedProductRepository{ProductRepository: products, index: index, logger: logger},
+	}
+}
+
+func (r *SyncedVariantRepository) Create(variant *entities.ProductVariant) error {
+	if err := r.ProductVariantRepository.Create(variant); err != nil {
+		return err
+	}
+	r.products.reindex(variant.ProductID)
+	return nil
+}
+
+func (r *SyncedVariantRepository) Update(variant *entities.ProductVariant) error {
+	if err := r.ProductVariantRepository.Update(variant); err != nil {
+		return err
+	}
+	r.products.reindex(variant.ProductID)
+	return nil
+}
+
+func (r *SyncedVariantRepository) Delete(id uuid.UUID) error {
+	variant, err := r.ProductVariantRepository.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := r.ProductVariantRepository.Delete(id); err != nil {
+		return err
+	}
+	r.products.reindex(variant.ProductID)
+	return nil
+}
+
+func (r *SyncedVariantRepository) UpdateStock(id uuid.UUID, quantity int, change entities.StockChange) error {
+	variant, err := r.ProductVariantRepository.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := r.ProductVariantRepository.UpdateStock(id, quantity, change); err != nil {
+		return err
+	}
+	r.products.reindex(variant.ProductID)
+	return nil
+}
+
+// SyncedCategoryRepository re-indexes the products of a category after it
+// is renamed or deleted, since products are indexed with their category
+// names.
+type SyncedCategoryRepository struct {
+	entities.CategoryRepository
+	products *SyncedProductRepository
+}
+
+// NewSyncedCategoryRepository returns repo itself when the index has nothing
+// to sync, as NewSyncedProductRepository does.
+func NewSyncedCategoryRepository(repo entities.CategoryRepository, products entities.ProductRepository, index entities.SearchIndex, logger *logger.Logger) entities.CategoryRepository {
+	if !index.StoresProducts() {
+		return repo
+	}
+	return &SyncedCategoryRepository{
+		CategoryRepository: repo,
+		products:           &SyncedProductRepository{ProductRepository: products, index: index, logger: logger},
+	}
+}
+
+func (r *SyncedCategoryRepository) Update(category *entities.Category) error {
+	if err := r.CategoryRepository.Update(category); err != nil {
+		return err
+	}
+	productIDs, err := r.CategoryRepository.ProductIDs(category.ID)
+	if err != nil {
+		r.products.logger.Errorf("Updating the search index for category %s: %v", category.ID, err)
+		return nil
+	}
+	for _, id := range productIDs {
+		r.products.reindex(id)
+	}
+	return nil
+}
+
+func (r *SyncedCategoryRepository) Delete(id uuid.UUID) error {
+	productIDs, err := r.CategoryRepository.ProductIDs(id)
+	if err != nil {
+		return err
+	}
+	if err := r.CategoryRepository.Delete(id); err != nil {
+		return err
+	}
+	for _, productID := range productIDs {
+		r.products.reindex(productID)
+	}
+	return nil
+}
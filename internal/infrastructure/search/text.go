@@ -0,0 +1,138 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "at": true, "by": true, "for": true,
+	"in": true, "of": true, "on": true, "or": true, "the": true, "to": true, "with": true,
+}
+
+// tokenize lowercases text and splits it into stemmed terms. Hyphenated words
+// yield their parts and the joined form, so "T-Shirt" indexes "t", "shirt"
+// and "tshirt".
+func tokenize(text string) []string {
+	chunks := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+	})
+
+	var terms []string
+	add := func(word string) {
+		if word == "" || stopWords[word] {
+			return
+		}
+		terms = append(terms, stem(word))
+	}
+
+	for _, chunk := range chunks {
+		parts := strings.FieldsFunc(chunk, func(r rune) bool { return r == '-' })
+		for _, part := range parts {
+			add(part)
+		}
+		if len(parts) > 1 {
+			add(strings.Join(parts, ""))
+		}
+	}
+	return terms
+}
+
+// stem strips common English plural endings; it is deliberately crude but
+// applied identically to documents and queries.
+func stem(word string) string {
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case len(word) > 4 && (strings.HasSuffix(word, "ses") || strings.HasSuffix(word, "xes") ||
+		strings.HasSuffix(word, "ches") || strings.HasSuffix(word, "shes")):
+		return word[:len(word)-2]
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// maxTypos is how many edits a query term may be from an indexed term.
+func maxTypos(term string) int {
+	switch n := len([]rune(term)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance is the Levenshtein distance between a and b, giving up once
+// it exceeds limit.
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if diff := len(ra) - len(rb); diff > limit || -diff > limit {
+		return limit + 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+const (
+	markStart      = "<mark>"
+	markEnd        = "</mark>"
+	snippetWords   = 30
+	snippetContext = 10
+)
+
+func markWord(word string, matched map[string]bool) string {
+	for _, term := range tokenize(word) {
+		if matched[term] {
+			return markStart + word + markEnd
+		}
+	}
+	return word
+}
+
+// highlight wraps every word of text containing a matched term.
+func highlight(text string, matched map[string]bool) string {
+	words := strings.Fields(text)
+	for i, word := range words {
+		words[i] = markWord(word, matched)
+	}
+	return strings.Join(words, " ")
+}
+
+// snippet returns up to snippetWords words of text around the first match,
+// with matches highlighted.
+func snippet(text string, matched map[string]bool) string {
+	words := strings.Fields(text)
+	start := 0
+	for i, word := range words {
+		if markWord(word, matched) != word {
+			start = max(0, i-snippetContext)
+			break
+		}
+	}
+	end := min(len(words), start+snippetWords)
+	return highlight(strings.Join(words[start:end], " "), matched)
+}
@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"fmt"
+
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/config"
+)
+
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// New returns the blob storage selected by cfg.Driver.
+func New(cfg config.StorageConfig) (entities.BlobStorage, error) {
+	switch cfg.Driver {
+	case "", DriverLocal:
+		return NewLocalStorage(cfg.LocalDir, cfg.PublicURL)
+	case DriverS3:
+		return NewS3Storage(S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+			PublicURL: cfg.PublicURL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"time"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/pagination"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AbandonedCartHandler struct {
+	abandonedCartUseCase *usecases.AbandonedCartUseCase
+}
+
+func NewAbandonedCartHandler(abandonedCartUseCase *usecases.AbandonedCartUseCase) *AbandonedCartHandler {
+	return &AbandonedCartHandler{
+		abandonedCartUseCase: abandonedCartUseCase,
+	}
+}
+
+func (h *AbandonedCartHandler) ListAbandonedCarts(c *fiber.Ctx) error {
+	params, err := parsePagination(c, pagination.DefaultLimit)
+	if err != nil {
+		return err
+	}
+
+	carts, err := h.abandonedCartUseCase.ListAbandonedCarts(params)
+	if err != nil {
+		return err
+	}
+
+	return writePage(c, "abandoned_carts", params, carts, nil)
+}
+
+func (h *AbandonedCartHandler) Report(c *fiber.Ctx) error {
+	var query usecases.AbandonedCartReportQuery
+	if err := c.QueryParser(&query); err != nil {
+		return apperrors.Validation(apperrors.CodeInvalidQuery, "Invalid query parameters")
+	}
+
+	report, err := h.abandonedCartUseCase.Report(&query, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(report)
+}
@@ -2,52 +2,71 @@ package handlers
 
 import (
 	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/logger"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type AuthHandler struct {
 	userUseCase *usecases.UserUseCase
+	cartUseCase *usecases.CartUseCase
+	logger      *logger.Logger
 }
 
-func NewAuthHandler(userUseCase *usecases.UserUseCase) *AuthHandler {
+func NewAuthHandler(userUseCase *usecases.UserUseCase, cartUseCase *usecases.CartUseCase, logger *logger.Logger) *AuthHandler {
 	return &AuthHandler{
 		userUseCase: userUseCase,
+		cartUseCase: cartUseCase,
+		logger:      logger,
 	}
 }
 
 func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	var req usecases.RegisterRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
-	resp, err := h.userUseCase.Register(&req)
+	resp, err := h.userUseCase.Register(&req, clientInfo(c))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
+	h.mergeGuestCart(c, resp)
 
 	return c.Status(fiber.StatusCreated).JSON(resp)
 }
 
 func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	var req usecases.AuthRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
-	resp, err := h.userUseCase.Login(&req)
+	resp, err := h.userUseCase.Login(&req, clientInfo(c))
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
+	h.mergeGuestCart(c, resp)
 
 	return c.JSON(resp)
-}
\ No newline at end of file
+}
+
+// mergeGuestCart moves the guest cart named by the cart token header, if
+// any, into the user's cart. Signing in must not fail because of the cart,
+// so a failed merge is logged and leaves the guest cart to be merged next
+// time.
+func (h *AuthHandler) mergeGuestCart(c *fiber.Ctx, resp *usecases.AuthResponse) {
+	cart, err := h.cartUseCase.MergeGuestCart(resp.User.ID, c.Get(CartTokenHeader))
+	if err != nil {
+		h.logger.Warnf("Merging guest cart into the cart of user %s: %v", resp.User.ID, err)
+		return
+	}
+	resp.Cart = cart
+}
+
+func clientInfo(c *fiber.Ctx) usecases.ClientInfo {
+	return usecases.ClientInfo{
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IPAddress: c.IP(),
+	}
+}
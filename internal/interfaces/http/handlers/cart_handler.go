@@ -2,12 +2,13 @@ package handlers
 
 import (
 	"prototype-fiber/internal/usecases"
-	"prototype-fiber/pkg/utils"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
+// CartTokenHeader carries the token of a guest cart.
+const CartTokenHeader = "X-Cart-Token"
+
 type CartHandler struct {
 	cartUseCase *usecases.CartUseCase
 }
@@ -19,120 +20,200 @@ func NewCartHandler(cartUseCase *usecases.CartUseCase) *CartHandler {
 }
 
 func (h *CartHandler) GetCart(c *fiber.Ctx) error {
-	userID, err := utils.GetUserIDFromContext(c)
+	userID, err := currentUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return err
 	}
 
 	cart, err := h.cartUseCase.GetCart(userID)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Cart not found",
-		})
+		return err
 	}
 
 	return c.JSON(cart)
 }
 
 func (h *CartHandler) AddToCart(c *fiber.Ctx) error {
-	userID, err := utils.GetUserIDFromContext(c)
+	userID, err := currentUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return err
 	}
 
 	var req usecases.AddToCartRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
 	cart, err := h.cartUseCase.AddToCart(userID, &req)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(cart)
 }
 
 func (h *CartHandler) UpdateCartItem(c *fiber.Ctx) error {
-	userID, err := utils.GetUserIDFromContext(c)
+	userID, err := currentUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return err
 	}
 
-	productID, err := uuid.Parse(c.Params("productId"))
+	productID, err := parseIDParam(c, "productId", "product")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid product ID",
-		})
+		return err
+	}
+
+	variantID, err := parseOptionalIDQuery(c, "variant_id", "variant")
+	if err != nil {
+		return err
 	}
 
 	var req struct {
-		Quantity int `json:"quantity" validate:"required,gte=0"`
+		Quantity *int `json:"quantity" validate:"required,gte=0,lte=1000"`
 	}
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
-	cart, err := h.cartUseCase.UpdateCartItem(userID, productID, req.Quantity)
+	cart, err := h.cartUseCase.UpdateCartItem(userID, productID, variantID, *req.Quantity)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(cart)
 }
 
 func (h *CartHandler) RemoveFromCart(c *fiber.Ctx) error {
-	userID, err := utils.GetUserIDFromContext(c)
+	userID, err := currentUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return err
 	}
 
-	productID, err := uuid.Parse(c.Params("productId"))
+	productID, err := parseIDParam(c, "productId", "product")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid product ID",
-		})
+		return err
 	}
 
-	cart, err := h.cartUseCase.RemoveFromCart(userID, productID)
+	variantID, err := parseOptionalIDQuery(c, "variant_id", "variant")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
+	}
+
+	cart, err := h.cartUseCase.RemoveFromCart(userID, productID, variantID)
+	if err != nil {
+		return err
 	}
 
 	return c.JSON(cart)
 }
 
 func (h *CartHandler) ClearCart(c *fiber.Ctx) error {
-	userID, err := utils.GetUserIDFromContext(c)
+	userID, err := currentUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return err
 	}
 
 	if err := h.cartUseCase.ClearCart(userID); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to clear cart",
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusNoContent).Send(nil)
-}
\ No newline at end of file
+}
+
+// CreateGuestCart starts a cart for a shopper who has not signed in. The
+// returned token goes in the X-Cart-Token header of later guest cart
+// requests and of login or registration, which merge the cart.
+func (h *CartHandler) CreateGuestCart(c *fiber.Ctx) error {
+	guest, err := h.cartUseCase.CreateGuestCart()
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(guest)
+}
+
+func (h *CartHandler) GetGuestCart(c *fiber.Ctx) error {
+	cart, err := h.cartUseCase.GetGuestCart(c.Get(CartTokenHeader))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(cart)
+}
+
+func (h *CartHandler) AddToGuestCart(c *fiber.Ctx) error {
+	var req usecases.AddToCartRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	cart, err := h.cartUseCase.AddToGuestCart(c.Get(CartTokenHeader), &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(cart)
+}
+
+func (h *CartHandler) UpdateGuestCartItem(c *fiber.Ctx) error {
+	productID, err := parseIDParam(c, "productId", "product")
+	if err != nil {
+		return err
+	}
+
+	variantID, err := parseOptionalIDQuery(c, "variant_id", "variant")
+	if err != nil {
+		return err
+	}
+
+	var req struct {
+		Quantity *int `json:"quantity" validate:"required,gte=0,lte=1000"`
+	}
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	cart, err := h.cartUseCase.UpdateGuestCartItem(c.Get(CartTokenHeader), productID, variantID, *req.Quantity)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(cart)
+}
+
+func (h *CartHandler) RemoveFromGuestCart(c *fiber.Ctx) error {
+	productID, err := parseIDParam(c, "productId", "product")
+	if err != nil {
+		return err
+	}
+
+	variantID, err := parseOptionalIDQuery(c, "variant_id", "variant")
+	if err != nil {
+		return err
+	}
+
+	cart, err := h.cartUseCase.RemoveFromGuestCart(c.Get(CartTokenHeader), productID, variantID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(cart)
+}
+
+func (h *CartHandler) ClearGuestCart(c *fiber.Ctx) error {
+	if err := h.cartUseCase.ClearGuestCart(c.Get(CartTokenHeader)); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// RecoverCart opens the cart linked from an abandoned cart email.
+func (h *CartHandler) RecoverCart(c *fiber.Ctx) error {
+	cart, err := h.cartUseCase.RecoverCart(c.Params("token"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(cart)
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"prototype-fiber/internal/usecases"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CategoryHandler struct {
+	categoryUseCase *usecases.CategoryUseCase
+}
+
+func NewCategoryHandler(categoryUseCase *usecases.CategoryUseCase) *CategoryHandler {
+	return &CategoryHandler{
+		categoryUseCase: categoryUseCase,
+	}
+}
+
+// GetTree returns the active categories, nested.
+func (h *CategoryHandler) GetTree(c *fiber.Ctx) error {
+	tree, err := h.categoryUseCase.GetTree(false)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"categories": tree,
+	})
+}
+
+// AdminGetTree returns every category, inactive ones included.
+func (h *CategoryHandler) AdminGetTree(c *fiber.Ctx) error {
+	tree, err := h.categoryUseCase.GetTree(true)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"categories": tree,
+	})
+}
+
+func (h *CategoryHandler) CreateCategory(c *fiber.Ctx) error {
+	var req usecases.CreateCategoryRequest
+	if err := parseStrictBody(c, &req); err != nil {
+		return err
+	}
+
+	category, err := h.categoryUseCase.CreateCategory(&req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(category)
+}
+
+func (h *CategoryHandler) UpdateCategory(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "category")
+	if err != nil {
+		return err
+	}
+
+	var req usecases.UpdateCategoryRequest
+	nulls, err := parseMergePatch(c, &req)
+	if err != nil {
+		return err
+	}
+
+	category, err := h.categoryUseCase.UpdateCategory(id, &req, nulls)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(category)
+}
+
+func (h *CategoryHandler) DeleteCategory(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "category")
+	if err != nil {
+		return err
+	}
+
+	if err := h.categoryUseCase.DeleteCategory(id); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"net/url"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/pkg/pagination"
+	"prototype-fiber/pkg/patch"
+	"prototype-fiber/pkg/utils"
+	"prototype-fiber/pkg/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+var validate = validation.New()
+
+// parseBody decodes the request body into out and enforces its validate
+// tags, reporting every failing field at once.
+func parseBody(c *fiber.Ctx, out interface{}) error {
+	if err := c.BodyParser(out); err != nil {
+		return apperrors.Validation(apperrors.CodeInvalidBody, "Invalid request body")
+	}
+	return validateStruct(out)
+}
+
+// parseStrictBody is parseBody for full replacements: members the DTO does
+// not declare are rejected instead of silently dropped.
+func parseStrictBody(c *fiber.Ctx, out interface{}) error {
+	if err := patch.DecodeStrict(c.Body(), out); err != nil {
+		return bodyError(err)
+	}
+	return validateStruct(out)
+}
+
+// parseMergePatch decodes an RFC 7396 merge patch into out and returns the
+// members explicitly set to null.
+func parseMergePatch(c *fiber.Ctx, out interface{}) (patch.Nulls, error) {
+	if !patch.IsMergePatch(c.Get(fiber.HeaderContentType)) {
+		return nil, apperrors.UnsupportedMedia(apperrors.CodeUnsupportedMedia, "PATCH bodies must be sent as "+patch.ContentTypeMergePatch)
+	}
+	nulls, err := patch.DecodeMergePatch(c.Body(), out)
+	if err != nil {
+		return nil, bodyError(err)
+	}
+	return nulls, validateStruct(out)
+}
+
+func bodyError(err error) error {
+	var fieldErr *patch.FieldError
+	if errors.As(err, &fieldErr) {
+		return apperrors.Validation(apperrors.CodeValidation, "Request validation failed").WithDetails(validation.Errors{
+			{Field: fieldErr.Field, Rule: fieldErr.Rule, Message: fieldErr.Message},
+		})
+	}
+	return apperrors.Validation(apperrors.CodeInvalidBody, "Invalid request body")
+}
+
+func validateStruct(s interface{}) error {
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrors validation.Errors
+	if errors.As(err, &fieldErrors) {
+		return apperrors.Validation(apperrors.CodeValidation, "Request validation failed").WithDetails(fieldErrors)
+	}
+	return err
+}
+
+func parseIDParam(c *fiber.Ctx, param, label string) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Params(param))
+	if err != nil {
+		return uuid.Nil, apperrors.Validation(apperrors.CodeInvalidID, "Invalid "+label+" ID")
+	}
+	return id, nil
+}
+
+// parseOptionalIDQuery reads an optional UUID from the query string; it
+// returns nil when the parameter is absent.
+func parseOptionalIDQuery(c *fiber.Ctx, key, label string) (*uuid.UUID, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, apperrors.Validation(apperrors.CodeInvalidID, "Invalid "+label+" ID")
+	}
+	return &id, nil
+}
+
+// parsePagination reads limit, cursor, page and include_total from the query
+// string, rejecting out-of-range values instead of clamping them.
+func parsePagination(c *fiber.Ctx, defaultLimit int) (pagination.Params, error) {
+	params, err := pagination.Parse(func(key string) string { return c.Query(key) }, defaultLimit)
+	if err != nil {
+		return params, apperrors.Validation(apperrors.CodeInvalidQuery, err.Error())
+	}
+	return params, nil
+}
+
+// writePage sends the page items under key along with the paging metadata
+// and a Link header. extra is merged into the body for endpoint-specific
+// fields such as the search query.
+func writePage[T any](c *fiber.Ctx, key string, params pagination.Params, page *pagination.Page[T], extra fiber.Map) error {
+	base := &url.URL{Path: c.Path(), RawQuery: string(c.Request().URI().QueryString())}
+	c.Set(fiber.HeaderLink, pagination.Links(base, params, page))
+
+	body := fiber.Map{
+		key:           page.Items,
+		"limit":       params.Limit,
+		"has_more":    page.HasMore,
+		"next_cursor": nil,
+	}
+	if page.NextCursor != "" {
+		body["next_cursor"] = page.NextCursor
+	}
+	if !params.UsesCursor() {
+		body["page"] = params.Page()
+	}
+	if page.Total != nil {
+		body["total"] = *page.Total
+	}
+	for k, v := range extra {
+		body[k] = v
+	}
+	return c.JSON(body)
+}
+
+func currentUserID(c *fiber.Ctx) (uuid.UUID, error) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return uuid.Nil, apperrors.Unauthorized(apperrors.CodeUnauthorized, "Unauthorized")
+	}
+	return userID, nil
+}
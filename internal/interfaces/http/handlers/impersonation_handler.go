@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"prototype-fiber/internal/usecases"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ImpersonationHandler struct {
+	impersonationUseCase *usecases.ImpersonationUseCase
+}
+
+func NewImpersonationHandler(impersonationUseCase *usecases.ImpersonationUseCase) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonationUseCase: impersonationUseCase,
+	}
+}
+
+func (h *ImpersonationHandler) Impersonate(c *fiber.Ctx) error {
+	adminID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := parseIDParam(c, "id", "user")
+	if err != nil {
+		return err
+	}
+
+	var req usecases.ImpersonateRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	resp, err := h.impersonationUseCase.Impersonate(adminID, userID, &req, clientInfo(c))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+func (h *ImpersonationHandler) ListLogs(c *fiber.Ctx) error {
+	userID, err := parseIDParam(c, "id", "user")
+	if err != nil {
+		return err
+	}
+
+	params, err := parsePagination(c, 50)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.impersonationUseCase.ListLogs(userID, params)
+	if err != nil {
+		return err
+	}
+
+	return writePage(c, "entries", params, entries, nil)
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"prototype-fiber/internal/usecases"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type InventoryHandler struct {
+	inventoryUseCase *usecases.InventoryUseCase
+}
+
+func NewInventoryHandler(inventoryUseCase *usecases.InventoryUseCase) *InventoryHandler {
+	return &InventoryHandler{
+		inventoryUseCase: inventoryUseCase,
+	}
+}
+
+func (h *InventoryHandler) ListStockMovements(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "product")
+	if err != nil {
+		return err
+	}
+
+	params, err := parsePagination(c, 50)
+	if err != nil {
+		return err
+	}
+
+	movements, err := h.inventoryUseCase.ListStockMovements(id, params)
+	if err != nil {
+		return err
+	}
+
+	return writePage(c, "movements", params, movements, nil)
+}
+
+func (h *InventoryHandler) Reconcile(c *fiber.Ctx) error {
+	reconciliation, err := h.inventoryUseCase.Reconcile()
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(reconciliation)
+}
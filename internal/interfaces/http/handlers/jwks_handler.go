@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"prototype-fiber/pkg/tokens"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type JWKSHandler struct {
+	keySet *tokens.KeySet
+}
+
+func NewJWKSHandler(keySet *tokens.KeySet) *JWKSHandler {
+	return &JWKSHandler{
+		keySet: keySet,
+	}
+}
+
+func (h *JWKSHandler) GetJWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(h.keySet.JWKS())
+}
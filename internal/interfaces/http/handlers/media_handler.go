@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/usecases"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MediaHandler struct {
+	mediaUseCase *usecases.MediaUseCase
+}
+
+func NewMediaHandler(mediaUseCase *usecases.MediaUseCase) *MediaHandler {
+	return &MediaHandler{
+		mediaUseCase: mediaUseCase,
+	}
+}
+
+func (h *MediaHandler) ListMedia(c *fiber.Ctx) error {
+	productID, err := parseIDParam(c, "id", "product")
+	if err != nil {
+		return err
+	}
+
+	media, err := h.mediaUseCase.ListMedia(productID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"media": media,
+	})
+}
+
+// UploadMedia accepts a multipart/form-data upload with the image in the
+// "file" field and an optional "alt_text" field.
+func (h *MediaHandler) UploadMedia(c *fiber.Ctx) error {
+	productID, err := parseIDParam(c, "id", "product")
+	if err != nil {
+		return err
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return apperrors.Validation(apperrors.CodeInvalidBody, "a multipart form with a \"file\" field is required")
+	}
+	file, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	media, err := h.mediaUseCase.UploadMedia(productID, &usecases.UploadMediaInput{
+		Body:    file,
+		Size:    header.Size,
+		AltText: c.FormValue("alt_text"),
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(media)
+}
+
+func (h *MediaHandler) UpdateMedia(c *fiber.Ctx) error {
+	productID, err := parseIDParam(c, "id", "product")
+	if err != nil {
+		return err
+	}
+	mediaID, err := parseIDParam(c, "mediaId", "media")
+	if err != nil {
+		return err
+	}
+
+	var req usecases.UpdateMediaRequest
+	nulls, err := parseMergePatch(c, &req)
+	if err != nil {
+		return err
+	}
+
+	media, err := h.mediaUseCase.UpdateMedia(productID, mediaID, &req, nulls)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(media)
+}
+
+func (h *MediaHandler) ReorderMedia(c *fiber.Ctx) error {
+	productID, err := parseIDParam(c, "id", "product")
+	if err != nil {
+		return err
+	}
+
+	var req usecases.ReorderMediaRequest
+	if err := parseStrictBody(c, &req); err != nil {
+		return err
+	}
+
+	media, err := h.mediaUseCase.ReorderMedia(productID, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"media": media,
+	})
+}
+
+func (h *MediaHandler) DeleteMedia(c *fiber.Ctx) error {
+	productID, err := parseIDParam(c, "id", "product")
+	if err != nil {
+		return err
+	}
+	mediaID, err := parseIDParam(c, "mediaId", "media")
+	if err != nil {
+		return err
+	}
+
+	if err := h.mediaUseCase.DeleteMedia(productID, mediaID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
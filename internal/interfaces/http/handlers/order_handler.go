@@ -1,13 +1,10 @@
 package handlers
 
 import (
-	"strconv"
-
 	"prototype-fiber/internal/usecases"
-	"prototype-fiber/pkg/utils"
+	"prototype-fiber/pkg/pagination"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
 type OrderHandler struct {
@@ -21,94 +18,71 @@ func NewOrderHandler(orderUseCase *usecases.OrderUseCase) *OrderHandler {
 }
 
 func (h *OrderHandler) CreateOrder(c *fiber.Ctx) error {
-	userID, err := utils.GetUserIDFromContext(c)
+	userID, err := currentUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return err
 	}
 
 	var req usecases.CreateOrderRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
 	order, err := h.orderUseCase.CreateOrder(userID, &req)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(order)
 }
 
 func (h *OrderHandler) GetOrder(c *fiber.Ctx) error {
-	id, err := uuid.Parse(c.Params("id"))
+	id, err := parseIDParam(c, "id", "order")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid order ID",
-		})
+		return err
 	}
 
 	order, err := h.orderUseCase.GetOrder(id)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Order not found",
-		})
+		return err
 	}
 
 	return c.JSON(order)
 }
 
 func (h *OrderHandler) GetUserOrders(c *fiber.Ctx) error {
-	userID, err := utils.GetUserIDFromContext(c)
+	userID, err := currentUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return err
 	}
 
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "20"))
-	offset := (page - 1) * limit
+	params, err := parsePagination(c, pagination.DefaultLimit)
+	if err != nil {
+		return err
+	}
 
-	orders, err := h.orderUseCase.GetUserOrders(userID, offset, limit)
+	orders, err := h.orderUseCase.GetUserOrders(userID, params)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to fetch orders",
-		})
+		return err
 	}
 
-	return c.JSON(fiber.Map{
-		"orders": orders,
-		"page":   page,
-		"limit":  limit,
-	})
+	return writePage(c, "orders", params, orders, nil)
 }
 
 func (h *OrderHandler) CancelOrder(c *fiber.Ctx) error {
-	userID, err := utils.GetUserIDFromContext(c)
+	userID, err := currentUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return err
 	}
 
-	orderID, err := uuid.Parse(c.Params("id"))
+	orderID, err := parseIDParam(c, "id", "order")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid order ID",
-		})
+		return err
 	}
 
 	if err := h.orderUseCase.CancelOrder(userID, orderID); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusNoContent).Send(nil)
-}
\ No newline at end of file
+}
@@ -1,12 +1,11 @@
 package handlers
 
 import (
-	"strconv"
-
+	"prototype-fiber/internal/domain/apperrors"
 	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/pagination"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
 type ProductHandler struct {
@@ -21,126 +20,313 @@ func NewProductHandler(productUseCase *usecases.ProductUseCase) *ProductHandler
 
 func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 	var req usecases.CreateProductRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
 	product, err := h.productUseCase.CreateProduct(&req)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(product)
 }
 
 func (h *ProductHandler) GetProduct(c *fiber.Ctx) error {
-	id, err := uuid.Parse(c.Params("id"))
+	id, err := parseIDParam(c, "id", "product")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid product ID",
-		})
+		return err
 	}
 
 	product, err := h.productUseCase.GetProduct(id)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Product not found",
-		})
+		return err
 	}
 
 	return c.JSON(product)
 }
 
 func (h *ProductHandler) ListProducts(c *fiber.Ctx) error {
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "20"))
-	category := c.Query("category")
-	
-	offset := (page - 1) * limit
+	params, err := parsePagination(c, pagination.DefaultLimit)
+	if err != nil {
+		return err
+	}
 
-	products, err := h.productUseCase.ListProducts(offset, limit, category)
+	var query usecases.ListProductsQuery
+	if err := c.QueryParser(&query); err != nil {
+		return apperrors.Validation(apperrors.CodeInvalidQuery, "Invalid query parameters")
+	}
+	if err := validateStruct(&query); err != nil {
+		return err
+	}
+	filter, err := query.Filter()
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to fetch products",
-		})
+		return err
 	}
 
-	return c.JSON(fiber.Map{
-		"products": products,
-		"page":     page,
-		"limit":    limit,
-	})
+	products, err := h.productUseCase.ListProducts(filter, params)
+	if err != nil {
+		return err
+	}
+
+	return writePage(c, "products", params, products, nil)
 }
 
 func (h *ProductHandler) SearchProducts(c *fiber.Ctx) error {
 	query := c.Query("q")
 	if query == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Search query is required",
-		})
+		return apperrors.Validation(apperrors.CodeInvalidQuery, "Search query is required")
+	}
+
+	params, err := parsePagination(c, pagination.DefaultLimit)
+	if err != nil {
+		return err
+	}
+
+	var filterQuery usecases.ListProductsQuery
+	if err := c.QueryParser(&filterQuery); err != nil {
+		return apperrors.Validation(apperrors.CodeInvalidQuery, "Invalid query parameters")
+	}
+	if err := validateStruct(&filterQuery); err != nil {
+		return err
 	}
+	filter, err := filterQuery.Filter()
+	if err != nil {
+		return err
+	}
+
+	result, err := h.productUseCase.SearchProducts(query, filter, params)
+	if err != nil {
+		return err
+	}
+
+	return writePage(c, "products", params, result.Products, fiber.Map{
+		"query":  query,
+		"facets": result.Facets,
+	})
+}
+
+func (h *ProductHandler) ReindexSearch(c *fiber.Ctx) error {
+	indexed, err := h.productUseCase.ReindexSearch()
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"indexed": indexed,
+	})
+}
 
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "20"))
-	offset := (page - 1) * limit
+func (h *ProductHandler) SuggestProducts(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit")
+	if limit < 0 {
+		return apperrors.Validation(apperrors.CodeInvalidQuery, "limit must be a positive integer")
+	}
 
-	products, err := h.productUseCase.SearchProducts(query, offset, limit)
+	suggestions, err := h.productUseCase.SuggestProducts(c.Query("q"), limit)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to search products",
-		})
+		return err
 	}
 
 	return c.JSON(fiber.Map{
-		"products": products,
-		"query":    query,
-		"page":     page,
-		"limit":    limit,
+		"suggestions": suggestions,
 	})
 }
 
+func (h *ProductHandler) ReplaceProduct(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "product")
+	if err != nil {
+		return err
+	}
+
+	var req usecases.ReplaceProductRequest
+	if err := parseStrictBody(c, &req); err != nil {
+		return err
+	}
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	product, err := h.productUseCase.ReplaceProduct(id, &req, userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(product)
+}
+
 func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
-	id, err := uuid.Parse(c.Params("id"))
+	id, err := parseIDParam(c, "id", "product")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid product ID",
-		})
+		return err
 	}
 
-	var updates map[string]interface{}
-	if err := c.BodyParser(&updates); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	var req usecases.UpdateProductRequest
+	nulls, err := parseMergePatch(c, &req)
+	if err != nil {
+		return err
 	}
 
-	product, err := h.productUseCase.UpdateProduct(id, updates)
+	userID, err := currentUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
+	}
+
+	product, err := h.productUseCase.UpdateProduct(id, &req, nulls, userID)
+	if err != nil {
+		return err
 	}
 
 	return c.JSON(product)
 }
 
 func (h *ProductHandler) DeleteProduct(c *fiber.Ctx) error {
-	id, err := uuid.Parse(c.Params("id"))
+	id, err := parseIDParam(c, "id", "product")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid product ID",
-		})
+		return err
 	}
 
 	if err := h.productUseCase.DeleteProduct(id); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to delete product",
-		})
+		return err
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// AdjustStock adds quantity, which may be negative, to the stock of a
+// product without variants.
+func (h *ProductHandler) AdjustStock(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "product")
+	if err != nil {
+		return err
+	}
+
+	var req usecases.AdjustStockRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.productUseCase.UpdateStock(id, &req, userID); err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusNoContent).Send(nil)
-}
\ No newline at end of file
+}
+
+func (h *ProductHandler) SetProductOptions(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "product")
+	if err != nil {
+		return err
+	}
+
+	var req usecases.SetProductOptionsRequest
+	if err := parseStrictBody(c, &req); err != nil {
+		return err
+	}
+
+	product, err := h.productUseCase.SetOptions(id, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(product)
+}
+
+func (h *ProductHandler) CreateVariant(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "product")
+	if err != nil {
+		return err
+	}
+
+	var req usecases.CreateVariantRequest
+	if err := parseStrictBody(c, &req); err != nil {
+		return err
+	}
+
+	variant, err := h.productUseCase.CreateVariant(id, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(variant)
+}
+
+func (h *ProductHandler) UpdateVariant(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "product")
+	if err != nil {
+		return err
+	}
+	variantID, err := parseIDParam(c, "variantId", "variant")
+	if err != nil {
+		return err
+	}
+
+	var req usecases.UpdateVariantRequest
+	nulls, err := parseMergePatch(c, &req)
+	if err != nil {
+		return err
+	}
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	variant, err := h.productUseCase.UpdateVariant(id, variantID, &req, nulls, userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(variant)
+}
+
+// AdjustVariantStock adds quantity, which may be negative, to a variant's
+// stock.
+func (h *ProductHandler) AdjustVariantStock(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "product")
+	if err != nil {
+		return err
+	}
+	variantID, err := parseIDParam(c, "variantId", "variant")
+	if err != nil {
+		return err
+	}
+
+	var req usecases.AdjustStockRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.productUseCase.UpdateVariantStock(id, variantID, &req, userID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+func (h *ProductHandler) DeleteVariant(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "product")
+	if err != nil {
+		return err
+	}
+	variantID, err := parseIDParam(c, "variantId", "variant")
+	if err != nil {
+		return err
+	}
+
+	if err := h.productUseCase.DeleteVariant(id, variantID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
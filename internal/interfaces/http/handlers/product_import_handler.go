@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"bufio"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/usecases"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ProductImportHandler struct {
+	importUseCase *usecases.ProductImportUseCase
+}
+
+func NewProductImportHandler(importUseCase *usecases.ProductImportUseCase) *ProductImportHandler {
+	return &ProductImportHandler{
+		importUseCase: importUseCase,
+	}
+}
+
+// ImportProducts accepts a CSV or JSON file in the "file" field of a
+// multipart form. The format comes from ?format= or the file extension.
+// With ?dry_run=true the report is returned straight away and nothing is
+// written; otherwise the import runs in the background and the job is
+// returned for polling.
+func (h *ProductImportHandler) ImportProducts(c *fiber.Ctx) error {
+	header, err := c.FormFile("file")
+	if err != nil {
+		return apperrors.Validation(apperrors.CodeInvalidBody, "a multipart form with a \"file\" field is required")
+	}
+	format, err := usecases.ParseImportFormat(c.Query("format"), header.Filename)
+	if err != nil {
+		return err
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if c.QueryBool("dry_run") {
+		report, err := h.importUseCase.RunImport(format, file, true)
+		if err != nil {
+			return err
+		}
+		return c.JSON(report)
+	}
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	job, err := h.importUseCase.StartImport(format, file, &userID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
+
+func (h *ProductImportHandler) GetImportJob(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "jobId", "import job")
+	if err != nil {
+		return err
+	}
+
+	job, err := h.importUseCase.GetImportJob(id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(job)
+}
+
+// ExportProducts streams the catalogue as ?format=csv (the default) or json.
+// The response is already under way when products are read, so a failure
+// part-way ends it early instead of returning an error.
+func (h *ProductImportHandler) ExportProducts(c *fiber.Ctx) error {
+	format, err := usecases.ParseImportFormat(c.Query("format", usecases.ImportFormatCSV), "")
+	if err != nil {
+		return err
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == usecases.ImportFormatJSON {
+		contentType = fiber.MIMEApplicationJSONCharsetUTF8
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="products.`+format+`"`)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		_ = h.importUseCase.ExportProducts(format, w)
+		_ = w.Flush()
+	})
+	return nil
+}
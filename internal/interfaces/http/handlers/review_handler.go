@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/pagination"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReviewHandler struct {
+	reviewUseCase *usecases.ReviewUseCase
+}
+
+func NewReviewHandler(reviewUseCase *usecases.ReviewUseCase) *ReviewHandler {
+	return &ReviewHandler{
+		reviewUseCase: reviewUseCase,
+	}
+}
+
+func (h *ReviewHandler) ListProductReviews(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "product")
+	if err != nil {
+		return err
+	}
+
+	params, err := parsePagination(c, pagination.DefaultLimit)
+	if err != nil {
+		return err
+	}
+
+	reviews, err := h.reviewUseCase.ListProductReviews(id, params)
+	if err != nil {
+		return err
+	}
+
+	return writePage(c, "reviews", params, reviews, nil)
+}
+
+func (h *ReviewHandler) CreateReview(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "product")
+	if err != nil {
+		return err
+	}
+
+	var req usecases.CreateReviewRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	review, err := h.reviewUseCase.CreateReview(id, userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(review)
+}
+
+func (h *ReviewHandler) UpdateReview(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "review")
+	if err != nil {
+		return err
+	}
+
+	var req usecases.UpdateReviewRequest
+	nulls, err := parseMergePatch(c, &req)
+	if err != nil {
+		return err
+	}
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	review, err := h.reviewUseCase.UpdateReview(id, userID, &req, nulls)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(review)
+}
+
+func (h *ReviewHandler) DeleteReview(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "review")
+	if err != nil {
+		return err
+	}
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.reviewUseCase.DeleteReview(id, userID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+func (h *ReviewHandler) MarkHelpful(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "review")
+	if err != nil {
+		return err
+	}
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.reviewUseCase.MarkHelpful(id, userID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+func (h *ReviewHandler) UnmarkHelpful(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "review")
+	if err != nil {
+		return err
+	}
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.reviewUseCase.UnmarkHelpful(id, userID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+func (h *ReviewHandler) ListReviews(c *fiber.Ctx) error {
+	params, err := parsePagination(c, pagination.DefaultLimit)
+	if err != nil {
+		return err
+	}
+
+	var query usecases.ListReviewsQuery
+	if err := c.QueryParser(&query); err != nil {
+		return apperrors.Validation(apperrors.CodeInvalidQuery, "Invalid query parameters")
+	}
+	if err := validateStruct(&query); err != nil {
+		return err
+	}
+
+	reviews, err := h.reviewUseCase.ListReviews(entities.ReviewStatus(query.Status), params)
+	if err != nil {
+		return err
+	}
+
+	return writePage(c, "reviews", params, reviews, nil)
+}
+
+func (h *ReviewHandler) ModerateReview(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "review")
+	if err != nil {
+		return err
+	}
+
+	var req usecases.ModerateReviewRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	review, err := h.reviewUseCase.ModerateReview(id, &req, userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(review)
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SessionHandler struct {
+	sessionUseCase *usecases.SessionUseCase
+}
+
+func NewSessionHandler(sessionUseCase *usecases.SessionUseCase) *SessionHandler {
+	return &SessionHandler{
+		sessionUseCase: sessionUseCase,
+	}
+}
+
+func (h *SessionHandler) ListSessions(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := h.sessionUseCase.ListSessions(userID)
+	if err != nil {
+		return err
+	}
+
+	currentID, _ := utils.GetSessionIDFromContext(c)
+	for _, session := range sessions {
+		session.Current = session.ID == currentID
+	}
+
+	return c.JSON(fiber.Map{
+		"sessions": sessions,
+	})
+}
+
+func (h *SessionHandler) RevokeSession(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	sessionID, err := parseIDParam(c, "sessionId", "session")
+	if err != nil {
+		return err
+	}
+
+	if err := h.sessionUseCase.RevokeSession(userID, sessionID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+func (h *SessionHandler) RevokeAllSessions(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.sessionUseCase.RevokeAllSessions(userID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+func (h *SessionHandler) AdminListSessions(c *fiber.Ctx) error {
+	userID, err := parseIDParam(c, "id", "user")
+	if err != nil {
+		return err
+	}
+
+	sessions, err := h.sessionUseCase.ListSessions(userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"sessions": sessions,
+	})
+}
+
+func (h *SessionHandler) AdminRevokeSession(c *fiber.Ctx) error {
+	userID, err := parseIDParam(c, "id", "user")
+	if err != nil {
+		return err
+	}
+
+	sessionID, err := parseIDParam(c, "sessionId", "session")
+	if err != nil {
+		return err
+	}
+
+	if err := h.sessionUseCase.RevokeSession(userID, sessionID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+func (h *SessionHandler) AdminRevokeAllSessions(c *fiber.Ctx) error {
+	userID, err := parseIDParam(c, "id", "user")
+	if err != nil {
+		return err
+	}
+
+	if err := h.sessionUseCase.RevokeAllSessions(userID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
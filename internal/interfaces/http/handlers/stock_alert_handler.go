@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/pagination"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type StockAlertHandler struct {
+	stockAlertUseCase *usecases.StockAlertUseCase
+}
+
+func NewStockAlertHandler(stockAlertUseCase *usecases.StockAlertUseCase) *StockAlertHandler {
+	return &StockAlertHandler{
+		stockAlertUseCase: stockAlertUseCase,
+	}
+}
+
+func (h *StockAlertHandler) ListAlerts(c *fiber.Ctx) error {
+	params, err := parsePagination(c, pagination.DefaultLimit)
+	if err != nil {
+		return err
+	}
+
+	var query usecases.ListStockAlertsQuery
+	if err := c.QueryParser(&query); err != nil {
+		return apperrors.Validation(apperrors.CodeInvalidQuery, "Invalid query parameters")
+	}
+	if err := validateStruct(&query); err != nil {
+		return err
+	}
+
+	alerts, err := h.stockAlertUseCase.ListAlerts(entities.StockAlertStatus(query.Status), params)
+	if err != nil {
+		return err
+	}
+
+	return writePage(c, "alerts", params, alerts, nil)
+}
+
+func (h *StockAlertHandler) ResolveAlert(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "stock alert")
+	if err != nil {
+		return err
+	}
+
+	alert, err := h.stockAlertUseCase.ResolveAlert(id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(alert)
+}
+
+func (h *StockAlertHandler) Subscribe(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "product")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := h.stockAlertUseCase.Subscribe(id, userID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(subscription)
+}
+
+func (h *StockAlertHandler) Unsubscribe(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "product")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.stockAlertUseCase.Unsubscribe(id, userID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
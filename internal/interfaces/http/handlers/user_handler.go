@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"prototype-fiber/internal/usecases"
-	"prototype-fiber/pkg/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -18,44 +17,72 @@ func NewUserHandler(userUseCase *usecases.UserUseCase) *UserHandler {
 }
 
 func (h *UserHandler) GetProfile(c *fiber.Ctx) error {
-	userID, err := utils.GetUserIDFromContext(c)
+	userID, err := currentUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return err
 	}
 
 	user, err := h.userUseCase.GetProfile(userID)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "User not found",
-		})
+		return err
+	}
+
+	return c.JSON(user)
+}
+
+func (h *UserHandler) ReplaceProfile(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req usecases.ReplaceProfileRequest
+	if err := parseStrictBody(c, &req); err != nil {
+		return err
+	}
+
+	user, err := h.userUseCase.ReplaceProfile(userID, &req)
+	if err != nil {
+		return err
 	}
 
 	return c.JSON(user)
 }
 
 func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
-	userID, err := utils.GetUserIDFromContext(c)
+	userID, err := currentUserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
+		return err
 	}
 
-	var updates map[string]interface{}
-	if err := c.BodyParser(&updates); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	var req usecases.UpdateProfileRequest
+	nulls, err := parseMergePatch(c, &req)
+	if err != nil {
+		return err
 	}
 
-	user, err := h.userUseCase.UpdateProfile(userID, updates)
+	user, err := h.userUseCase.UpdateProfile(userID, &req, nulls)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(user)
-}
\ No newline at end of file
+}
+
+func (h *UserHandler) ChangePassword(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req usecases.ChangePasswordRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.userUseCase.ChangePassword(userID, &req); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"prototype-fiber/internal/usecases"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WarehouseHandler struct {
+	warehouseUseCase *usecases.WarehouseUseCase
+}
+
+func NewWarehouseHandler(warehouseUseCase *usecases.WarehouseUseCase) *WarehouseHandler {
+	return &WarehouseHandler{
+		warehouseUseCase: warehouseUseCase,
+	}
+}
+
+func (h *WarehouseHandler) ListWarehouses(c *fiber.Ctx) error {
+	warehouses, err := h.warehouseUseCase.ListWarehouses()
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"warehouses": warehouses,
+	})
+}
+
+func (h *WarehouseHandler) CreateWarehouse(c *fiber.Ctx) error {
+	var req usecases.CreateWarehouseRequest
+	if err := parseStrictBody(c, &req); err != nil {
+		return err
+	}
+
+	warehouse, err := h.warehouseUseCase.CreateWarehouse(&req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(warehouse)
+}
+
+func (h *WarehouseHandler) UpdateWarehouse(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "warehouse")
+	if err != nil {
+		return err
+	}
+
+	var req usecases.UpdateWarehouseRequest
+	nulls, err := parseMergePatch(c, &req)
+	if err != nil {
+		return err
+	}
+
+	warehouse, err := h.warehouseUseCase.UpdateWarehouse(id, &req, nulls)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(warehouse)
+}
+
+func (h *WarehouseHandler) DeleteWarehouse(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "warehouse")
+	if err != nil {
+		return err
+	}
+
+	if err := h.warehouseUseCase.DeleteWarehouse(id); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// GetAvailability lists the pickup locations holding a product.
+func (h *WarehouseHandler) GetAvailability(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "product")
+	if err != nil {
+		return err
+	}
+
+	availability, err := h.warehouseUseCase.GetAvailability(id, true)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(availability)
+}
+
+// GetStockLevels lists a product's stock at every warehouse.
+func (h *WarehouseHandler) GetStockLevels(c *fiber.Ctx) error {
+	id, err := parseIDParam(c, "id", "product")
+	if err != nil {
+		return err
+	}
+
+	availability, err := h.warehouseUseCase.GetAvailability(id, false)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(availability)
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"prototype-fiber/internal/usecases"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WishlistHandler struct {
+	wishlistUseCase *usecases.WishlistUseCase
+}
+
+func NewWishlistHandler(wishlistUseCase *usecases.WishlistUseCase) *WishlistHandler {
+	return &WishlistHandler{
+		wishlistUseCase: wishlistUseCase,
+	}
+}
+
+func (h *WishlistHandler) ListWishlists(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	wishlists, err := h.wishlistUseCase.ListWishlists(userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"wishlists": wishlists,
+	})
+}
+
+func (h *WishlistHandler) CreateWishlist(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req usecases.WishlistRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	wishlist, err := h.wishlistUseCase.CreateWishlist(userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(wishlist)
+}
+
+func (h *WishlistHandler) GetWishlist(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseIDParam(c, "id", "wishlist")
+	if err != nil {
+		return err
+	}
+
+	wishlist, err := h.wishlistUseCase.GetWishlist(id, userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(wishlist)
+}
+
+func (h *WishlistHandler) RenameWishlist(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseIDParam(c, "id", "wishlist")
+	if err != nil {
+		return err
+	}
+
+	// Name is the only member and is required, so there are no nulls to apply
+	var req usecases.WishlistRequest
+	if _, err := parseMergePatch(c, &req); err != nil {
+		return err
+	}
+
+	wishlist, err := h.wishlistUseCase.RenameWishlist(id, userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(wishlist)
+}
+
+func (h *WishlistHandler) DeleteWishlist(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseIDParam(c, "id", "wishlist")
+	if err != nil {
+		return err
+	}
+
+	if err := h.wishlistUseCase.DeleteWishlist(id, userID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+func (h *WishlistHandler) AddItem(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseIDParam(c, "id", "wishlist")
+	if err != nil {
+		return err
+	}
+
+	var req usecases.AddToWishlistRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	wishlist, err := h.wishlistUseCase.AddItem(id, userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(wishlist)
+}
+
+func (h *WishlistHandler) RemoveItem(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseIDParam(c, "id", "wishlist")
+	if err != nil {
+		return err
+	}
+
+	productID, err := parseIDParam(c, "productId", "product")
+	if err != nil {
+		return err
+	}
+
+	variantID, err := parseOptionalIDQuery(c, "variant_id", "variant")
+	if err != nil {
+		return err
+	}
+
+	wishlist, err := h.wishlistUseCase.RemoveItem(id, userID, productID, variantID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(wishlist)
+}
+
+func (h *WishlistHandler) MoveToCart(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseIDParam(c, "id", "wishlist")
+	if err != nil {
+		return err
+	}
+
+	var req usecases.MoveToCartRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	cart, err := h.wishlistUseCase.MoveToCart(id, userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(cart)
+}
+
+func (h *WishlistHandler) Share(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseIDParam(c, "id", "wishlist")
+	if err != nil {
+		return err
+	}
+
+	wishlist, err := h.wishlistUseCase.Share(id, userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(wishlist)
+}
+
+func (h *WishlistHandler) Unshare(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseIDParam(c, "id", "wishlist")
+	if err != nil {
+		return err
+	}
+
+	wishlist, err := h.wishlistUseCase.Unshare(id, userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(wishlist)
+}
+
+func (h *WishlistHandler) GetSharedWishlist(c *fiber.Ctx) error {
+	wishlist, err := h.wishlistUseCase.GetSharedWishlist(c.Params("token"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(wishlist)
+}
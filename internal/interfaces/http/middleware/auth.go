@@ -3,44 +3,55 @@ package middleware
 import (
 	"strings"
 
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/tokens"
+
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-func AuthMiddleware(secret string) fiber.Handler {
+func AuthMiddleware(keySet *tokens.KeySet, sessionUseCase *usecases.SessionUseCase) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Get Authorization header
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Missing authorization header",
-			})
+			return apperrors.Unauthorized(apperrors.CodeUnauthorized, "Missing authorization header")
 		}
 
 		// Check if it starts with "Bearer "
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString == authHeader {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Invalid authorization header format",
-			})
+			return apperrors.Unauthorized(apperrors.CodeUnauthorized, "Invalid authorization header format")
 		}
 
-		// Parse and validate token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte(secret), nil
-		})
+		// Parse and validate token (signature, kid/alg, iss, aud, exp)
+		claims, err := keySet.Parse(tokenString)
+		if err != nil {
+			return apperrors.Unauthorized(apperrors.CodeInvalidToken, "Invalid token")
+		}
 
-		if err != nil || !token.Valid {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Invalid token",
-			})
+		// Reject tokens whose session was revoked or signed out
+		userID, err := uuid.Parse(claims.UserID)
+		if err != nil {
+			return apperrors.Unauthorized(apperrors.CodeInvalidToken, "Invalid token")
+		}
+		sessionID, err := uuid.Parse(claims.SessionID)
+		if err != nil {
+			return apperrors.Unauthorized(apperrors.CodeInvalidToken, "Invalid token")
 		}
+		if err := sessionUseCase.Validate(sessionID, userID); err != nil {
+			return err
+		}
+		sessionUseCase.Touch(sessionID, claims.ExpiresAt.Time)
 
-		// Extract claims and set in context
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Locals("user_id", claims["user_id"])
-			c.Locals("email", claims["email"])
-			c.Locals("role", claims["role"])
+		// Set claims in context
+		c.Locals("user_id", claims.UserID)
+		c.Locals("email", claims.Email)
+		c.Locals("role", claims.Role)
+		c.Locals("session_id", claims.SessionID)
+		if claims.ImpersonatorID != "" {
+			c.Locals("impersonator_id", claims.ImpersonatorID)
 		}
 
 		return c.Next()
@@ -51,10 +62,8 @@ func AdminMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		role := c.Locals("role")
 		if role != "admin" {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "Admin access required",
-			})
+			return apperrors.Forbidden(apperrors.CodeAdminRequired, "Admin access required")
 		}
 		return c.Next()
 	}
-}
\ No newline at end of file
+}
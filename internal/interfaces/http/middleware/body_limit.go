@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+
+	"prototype-fiber/internal/domain/apperrors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimit refuses request bodies larger than limit bytes. The server
+// streams bodies above its own limit instead of reading them up front, so
+// an oversized body is refused here before anything reads it. A chunked
+// body has no length to check and is read up to the limit. The connection is
+// closed after a refusal because the rest of the body is still unread.
+func BodyLimit(limit int64) fiber.Handler {
+	tooLarge := apperrors.TooLarge(apperrors.CodeBodyTooLarge, fmt.Sprintf("the request body exceeds the limit of %d bytes", limit))
+
+	return func(c *fiber.Ctx) error {
+		length := int64(c.Request().Header.ContentLength())
+		if length > limit {
+			c.Context().SetConnectionClose()
+			return tooLarge
+		}
+		if length < 0 && c.Request().IsBodyStream() {
+			body, err := io.ReadAll(io.LimitReader(c.Context().RequestBodyStream(), limit+1))
+			if err != nil {
+				return err
+			}
+			if int64(len(body)) > limit {
+				c.Context().SetConnectionClose()
+				return tooLarge
+			}
+			c.Request().SetBody(body)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/interfaces/http/problem"
+	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/logger"
+	"prototype-fiber/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditImpersonation records every request made with an impersonation
+// token, including its final status code. Must run after AuthMiddleware.
+// A request that can change something is recorded before it runs and
+// refused if it cannot be, so no change made as the customer goes
+// unaudited; the status code is filled in once it has run.
+func AuditImpersonation(impersonationUseCase *usecases.ImpersonationUseCase, log *logger.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		impersonatorID, ok := utils.GetImpersonatorIDFromContext(c)
+		if !ok {
+			return c.Next()
+		}
+
+		userID, _ := utils.GetUserIDFromContext(c)
+		sessionID, _ := utils.GetSessionIDFromContext(c)
+
+		// Fiber reuses the request buffers once the handler returns, so the
+		// entry gets its own copies.
+		entry := &entities.ImpersonationLog{
+			ImpersonatorID: impersonatorID,
+			UserID:         userID,
+			SessionID:      sessionID,
+			Method:         strings.Clone(c.Method()),
+			Path:           strings.Clone(c.OriginalURL()),
+			IPAddress:      strings.Clone(c.IP()),
+		}
+
+		mutating := !isSafeMethod(entry.Method)
+		if mutating {
+			if err := impersonationUseCase.RecordRequest(entry); err != nil {
+				return apperrors.Internal(fmt.Errorf("recording impersonated request: %w", err))
+			}
+		}
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = problem.FromError(err).Status
+		}
+
+		var recordErr error
+		if mutating {
+			recordErr = impersonationUseCase.RecordStatus(entry, status)
+		} else {
+			entry.StatusCode = status
+			recordErr = impersonationUseCase.RecordRequest(entry)
+		}
+		if recordErr != nil {
+			log.Errorf("Recording impersonated request %s %s by %s: %v", entry.Method, entry.Path, impersonatorID, recordErr)
+		}
+
+		return err
+	}
+}
+
+func isSafeMethod(method string) bool {
+	return method == fiber.MethodGet || method == fiber.MethodHead || method == fiber.MethodOptions
+}
+
+// DenyImpersonation blocks destructive actions, such as changing the
+// password or paying, while a staff member is impersonating the customer.
+func DenyImpersonation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, ok := utils.GetImpersonatorIDFromContext(c); ok {
+			return apperrors.Forbidden(apperrors.CodeImpersonation, "Action not allowed while impersonating")
+		}
+		return c.Next()
+	}
+}
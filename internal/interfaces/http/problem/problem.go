@@ -0,0 +1,104 @@
+package problem
+
+import (
+	"errors"
+	"strings"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+const ContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object extended with a stable code
+// and optional structured details.
+type Problem struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Detail   string      `json:"detail,omitempty"`
+	Instance string      `json:"instance,omitempty"`
+	Code     string      `json:"code"`
+	Details  interface{} `json:"details,omitempty"`
+}
+
+var statusByKind = map[apperrors.Kind]int{
+	apperrors.KindValidation:        fiber.StatusBadRequest,
+	apperrors.KindUnauthorized:      fiber.StatusUnauthorized,
+	apperrors.KindForbidden:         fiber.StatusForbidden,
+	apperrors.KindNotFound:          fiber.StatusNotFound,
+	apperrors.KindConflict:          fiber.StatusConflict,
+	apperrors.KindInsufficientStock: fiber.StatusConflict,
+	apperrors.KindTooLarge:          fiber.StatusRequestEntityTooLarge,
+	apperrors.KindUnsupportedMedia:  fiber.StatusUnsupportedMediaType,
+	apperrors.KindInternal:          fiber.StatusInternalServerError,
+}
+
+// FromError maps any error to a problem. Domain errors keep their code and
+// message; Fiber errors keep their status; anything else is an opaque 500.
+func FromError(err error) *Problem {
+	var appErr *apperrors.Error
+	if errors.As(err, &appErr) {
+		status, ok := statusByKind[appErr.Kind]
+		if !ok {
+			status = fiber.StatusInternalServerError
+		}
+		code := appErr.Code
+		if code == "" {
+			code = string(appErr.Kind)
+		}
+		detail := appErr.Message
+		if status == fiber.StatusInternalServerError {
+			detail = "An unexpected error occurred"
+		}
+		return newProblem(status, code, detail, appErr.Details)
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return newProblem(fiberErr.Code, codeForStatus(fiberErr.Code), fiberErr.Message, nil)
+	}
+
+	return newProblem(fiber.StatusInternalServerError, apperrors.CodeInternal, "An unexpected error occurred", nil)
+}
+
+func newProblem(status int, code, detail string, details interface{}) *Problem {
+	return &Problem{
+		Type:    "/problems/" + strings.ReplaceAll(code, "_", "-"),
+		Title:   utils.StatusMessage(status),
+		Status:  status,
+		Detail:  detail,
+		Code:    code,
+		Details: details,
+	}
+}
+
+func codeForStatus(status int) string {
+	return strings.ReplaceAll(strings.ToLower(utils.StatusMessage(status)), " ", "_")
+}
+
+// Write sends the problem with the problem+json content type.
+func Write(c *fiber.Ctx, p *Problem) error {
+	p.Instance = c.OriginalURL()
+	c.Status(p.Status)
+	if err := c.JSON(p); err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, ContentType)
+	return nil
+}
+
+// ErrorHandler is the Fiber error handler: every error returned by a handler
+// or middleware ends up here and leaves as application/problem+json.
+func ErrorHandler(log *logger.Logger) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		p := FromError(err)
+		if p.Status >= fiber.StatusInternalServerError {
+			log.Errorf("%s %s: %v", c.Method(), c.OriginalURL(), err)
+		}
+		return Write(c, p)
+	}
+}
@@ -3,21 +3,59 @@ package routes
 import (
 	"prototype-fiber/internal/interfaces/http/handlers"
 	"prototype-fiber/internal/interfaces/http/middleware"
+	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/logger"
+	"prototype-fiber/pkg/tokens"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type Handlers struct {
-	Auth    *handlers.AuthHandler
-	User    *handlers.UserHandler
-	Product *handlers.ProductHandler
-	Cart    *handlers.CartHandler
-	Order   *handlers.OrderHandler
+	Auth          *handlers.AuthHandler
+	User          *handlers.UserHandler
+	Product       *handlers.ProductHandler
+	Category      *handlers.CategoryHandler
+	Media         *handlers.MediaHandler
+	ProductImport *handlers.ProductImportHandler
+	Inventory     *handlers.InventoryHandler
+	Warehouse     *handlers.WarehouseHandler
+	StockAlert    *handlers.StockAlertHandler
+	Review        *handlers.ReviewHandler
+	Wishlist      *handlers.WishlistHandler
+	Cart          *handlers.CartHandler
+	AbandonedCart *handlers.AbandonedCartHandler
+	Order         *handlers.OrderHandler
+	Session       *handlers.SessionHandler
+	Impersonation *handlers.ImpersonationHandler
+	JWKS          *handlers.JWKSHandler
 }
 
-func SetupRoutes(app *fiber.App, handlers *Handlers, jwtSecret string) {
+// BodyLimits caps request bodies in bytes. The upload routes have their
+// own limits; every other route has Default.
+type BodyLimits struct {
+	Default int64
+	Media   int64
+	Import  int64
+}
+
+func SetupRoutes(app *fiber.App, handlers *Handlers, limits BodyLimits, keySet *tokens.KeySet, sessionUseCase *usecases.SessionUseCase, impersonationUseCase *usecases.ImpersonationUseCase, logger *logger.Logger) {
+	// Public verification keys for other services
+	app.Get("/.well-known/jwks.json", handlers.JWKS.GetJWKS)
+
 	api := app.Group("/api/v1")
 
+	// Uploads are registered ahead of the default body limit, which they
+	// never reach, and check their own
+	uploadAuth := []fiber.Handler{
+		middleware.AuthMiddleware(keySet, sessionUseCase),
+		middleware.AuditImpersonation(impersonationUseCase, logger),
+		middleware.AdminMiddleware(),
+	}
+	api.Post("/admin/products/import", append(uploadAuth, middleware.BodyLimit(limits.Import), handlers.ProductImport.ImportProducts)...)
+	api.Post("/admin/products/:id/media", append(uploadAuth, middleware.BodyLimit(limits.Media), handlers.Media.UploadMedia)...)
+
+	api.Use(middleware.BodyLimit(limits.Default))
+
 	// Auth routes (public)
 	auth := api.Group("/auth")
 	auth.Post("/register", handlers.Auth.Register)
@@ -27,15 +65,66 @@ func SetupRoutes(app *fiber.App, handlers *Handlers, jwtSecret string) {
 	products := api.Group("/products")
 	products.Get("/", handlers.Product.ListProducts)
 	products.Get("/search", handlers.Product.SearchProducts)
+	products.Get("/suggest", handlers.Product.SuggestProducts)
 	products.Get("/:id", handlers.Product.GetProduct)
+	products.Get("/:id/availability", handlers.Warehouse.GetAvailability)
+	products.Get("/:id/reviews", handlers.Review.ListProductReviews)
+
+	api.Get("/categories", handlers.Category.GetTree)
+	api.Get("/wishlists/shared/:token", handlers.Wishlist.GetSharedWishlist)
+
+	// Guest cart routes (public, identified by the X-Cart-Token header)
+	guestCart := api.Group("/guest-cart")
+	guestCart.Post("/", handlers.Cart.CreateGuestCart)
+	guestCart.Get("/", handlers.Cart.GetGuestCart)
+	guestCart.Post("/items", handlers.Cart.AddToGuestCart)
+	guestCart.Put("/items/:productId", handlers.Cart.UpdateGuestCartItem)
+	guestCart.Delete("/items/:productId", handlers.Cart.RemoveFromGuestCart)
+	guestCart.Delete("/", handlers.Cart.ClearGuestCart)
+	api.Get("/cart/recover/:token", handlers.Cart.RecoverCart)
 
 	// Protected routes
-	protected := api.Use(middleware.AuthMiddleware(jwtSecret))
+	protected := api.Use(
+		middleware.AuthMiddleware(keySet, sessionUseCase),
+		middleware.AuditImpersonation(impersonationUseCase, logger),
+	)
 
 	// User routes
 	users := protected.Group("/users")
 	users.Get("/profile", handlers.User.GetProfile)
-	users.Put("/profile", handlers.User.UpdateProfile)
+	users.Put("/profile", middleware.DenyImpersonation(), handlers.User.ReplaceProfile)
+	users.Patch("/profile", middleware.DenyImpersonation(), handlers.User.UpdateProfile)
+	users.Put("/password", middleware.DenyImpersonation(), handlers.User.ChangePassword)
+	users.Get("/sessions", handlers.Session.ListSessions)
+	users.Delete("/sessions", middleware.DenyImpersonation(), handlers.Session.RevokeAllSessions)
+	users.Delete("/sessions/:sessionId", middleware.DenyImpersonation(), handlers.Session.RevokeSession)
+
+	// Back-in-stock notifications (support may subscribe on the customer's
+	// behalf; the alert only goes to the address already on the account)
+	protected.Post("/products/:id/back-in-stock", handlers.StockAlert.Subscribe)
+	protected.Delete("/products/:id/back-in-stock", handlers.StockAlert.Unsubscribe)
+
+	// Review routes (published under the customer's name, so never written
+	// by an impersonating staff member)
+	protected.Post("/products/:id/reviews", middleware.DenyImpersonation(), handlers.Review.CreateReview)
+	reviews := protected.Group("/reviews")
+	reviews.Patch("/:id", middleware.DenyImpersonation(), handlers.Review.UpdateReview)
+	reviews.Delete("/:id", middleware.DenyImpersonation(), handlers.Review.DeleteReview)
+	reviews.Post("/:id/helpful", middleware.DenyImpersonation(), handlers.Review.MarkHelpful)
+	reviews.Delete("/:id/helpful", middleware.DenyImpersonation(), handlers.Review.UnmarkHelpful)
+
+	// Wishlist routes (support may edit lists, but not make them public)
+	wishlists := protected.Group("/wishlists")
+	wishlists.Get("/", handlers.Wishlist.ListWishlists)
+	wishlists.Post("/", handlers.Wishlist.CreateWishlist)
+	wishlists.Get("/:id", handlers.Wishlist.GetWishlist)
+	wishlists.Patch("/:id", handlers.Wishlist.RenameWishlist)
+	wishlists.Delete("/:id", handlers.Wishlist.DeleteWishlist)
+	wishlists.Post("/:id/items", handlers.Wishlist.AddItem)
+	wishlists.Delete("/:id/items/:productId", handlers.Wishlist.RemoveItem)
+	wishlists.Post("/:id/move-to-cart", handlers.Wishlist.MoveToCart)
+	wishlists.Post("/:id/share", middleware.DenyImpersonation(), handlers.Wishlist.Share)
+	wishlists.Delete("/:id/share", middleware.DenyImpersonation(), handlers.Wishlist.Unshare)
 
 	// Cart routes
 	cart := protected.Group("/cart")
@@ -47,15 +136,61 @@ func SetupRoutes(app *fiber.App, handlers *Handlers, jwtSecret string) {
 
 	// Order routes
 	orders := protected.Group("/orders")
-	orders.Post("/", handlers.Order.CreateOrder)
+	orders.Post("/", middleware.DenyImpersonation(), handlers.Order.CreateOrder)
 	orders.Get("/", handlers.Order.GetUserOrders)
 	orders.Get("/:id", handlers.Order.GetOrder)
-	orders.Delete("/:id", handlers.Order.CancelOrder)
+	orders.Delete("/:id", middleware.DenyImpersonation(), handlers.Order.CancelOrder)
 
 	// Admin routes
 	admin := protected.Use(middleware.AdminMiddleware())
 	adminProducts := admin.Group("/admin/products")
 	adminProducts.Post("/", handlers.Product.CreateProduct)
-	adminProducts.Put("/:id", handlers.Product.UpdateProduct)
+	adminProducts.Get("/import/:jobId", handlers.ProductImport.GetImportJob)
+	adminProducts.Get("/export", handlers.ProductImport.ExportProducts)
+	adminProducts.Put("/:id", handlers.Product.ReplaceProduct)
+	adminProducts.Patch("/:id", handlers.Product.UpdateProduct)
 	adminProducts.Delete("/:id", handlers.Product.DeleteProduct)
+	adminProducts.Post("/:id/stock", handlers.Product.AdjustStock)
+	adminProducts.Get("/:id/stock-movements", handlers.Inventory.ListStockMovements)
+	adminProducts.Get("/:id/stock-levels", handlers.Warehouse.GetStockLevels)
+	adminProducts.Put("/:id/options", handlers.Product.SetProductOptions)
+	adminProducts.Post("/:id/variants", handlers.Product.CreateVariant)
+	adminProducts.Patch("/:id/variants/:variantId", handlers.Product.UpdateVariant)
+	adminProducts.Post("/:id/variants/:variantId/stock", handlers.Product.AdjustVariantStock)
+	adminProducts.Delete("/:id/variants/:variantId", handlers.Product.DeleteVariant)
+	adminProducts.Get("/:id/media", handlers.Media.ListMedia)
+	adminProducts.Put("/:id/media/order", handlers.Media.ReorderMedia)
+	adminProducts.Patch("/:id/media/:mediaId", handlers.Media.UpdateMedia)
+	adminProducts.Delete("/:id/media/:mediaId", handlers.Media.DeleteMedia)
+	admin.Post("/admin/search/reindex", handlers.Product.ReindexSearch)
+	admin.Get("/admin/inventory/reconciliation", handlers.Inventory.Reconcile)
+	admin.Get("/admin/inventory/alerts", handlers.StockAlert.ListAlerts)
+	admin.Post("/admin/inventory/alerts/:id/resolve", handlers.StockAlert.ResolveAlert)
+
+	adminCategories := admin.Group("/admin/categories")
+	adminCategories.Get("/", handlers.Category.AdminGetTree)
+	adminCategories.Post("/", handlers.Category.CreateCategory)
+	adminCategories.Patch("/:id", handlers.Category.UpdateCategory)
+	adminCategories.Delete("/:id", handlers.Category.DeleteCategory)
+
+	adminWarehouses := admin.Group("/admin/warehouses")
+	adminWarehouses.Get("/", handlers.Warehouse.ListWarehouses)
+	adminWarehouses.Post("/", handlers.Warehouse.CreateWarehouse)
+	adminWarehouses.Patch("/:id", handlers.Warehouse.UpdateWarehouse)
+	adminWarehouses.Delete("/:id", handlers.Warehouse.DeleteWarehouse)
+
+	adminReviews := admin.Group("/admin/reviews")
+	adminReviews.Get("/", handlers.Review.ListReviews)
+	adminReviews.Put("/:id/status", handlers.Review.ModerateReview)
+
+	adminCarts := admin.Group("/admin/carts")
+	adminCarts.Get("/abandoned", handlers.AbandonedCart.ListAbandonedCarts)
+	adminCarts.Get("/abandoned/report", handlers.AbandonedCart.Report)
+
+	adminUsers := admin.Group("/admin/users")
+	adminUsers.Get("/:id/sessions", handlers.Session.AdminListSessions)
+	adminUsers.Delete("/:id/sessions", handlers.Session.AdminRevokeAllSessions)
+	adminUsers.Delete("/:id/sessions/:sessionId", handlers.Session.AdminRevokeSession)
+	adminUsers.Post("/:id/impersonate", handlers.Impersonation.Impersonate)
+	adminUsers.Get("/:id/impersonation-log", handlers.Impersonation.ListLogs)
 }
\ No newline at end of file
@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"time"
+
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/pagination"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AbandonedCartRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewAbandonedCartRepository(db *gorm.DB) entities.AbandonedCartRepository {
+	return &AbandonedCartRepositoryImpl{db: db}
+}
+
+// RecordAbandoned relies on the unique index over cart and last activity to
+// record each quiet spell once. Guest carts have no user and are left out.
+func (r *AbandonedCartRepositoryImpl) RecordAbandoned(before time.Time) (int64, error) {
+	result := r.db.Exec(`INSERT INTO abandoned_carts (cart_id, user_id, email, item_count, total, cart_updated_at, created_at)
+		SELECT carts.id, carts.user_id, COALESCE(users.email, ''), SUM(cart_items.quantity),
+			SUM(cart_items.quantity * cart_items.price), carts.updated_at, NOW()
+		FROM carts
+		JOIN cart_items ON cart_items.cart_id = carts.id
+		JOIN users ON users.id = carts.user_id
+		WHERE carts.updated_at < ?
+		GROUP BY carts.id, carts.user_id, users.email, carts.updated_at
+		ON CONFLICT (cart_id, cart_updated_at) DO NOTHING`, before)
+	return result.RowsAffected, translateError(result.Error)
+}
+
+// ClaimPending sets notified_at in a single UPDATE, so a job on another
+// instance that races for the same rows waits on their locks and then skips
+// them.
+func (r *AbandonedCartRepositoryImpl) ClaimPending() ([]*entities.AbandonedCart, error) {
+	abandoned := []*entities.AbandonedCart{}
+	err := r.db.Raw(`UPDATE abandoned_carts SET notified_at = ?
+		FROM carts
+		WHERE carts.id = abandoned_carts.cart_id AND carts.updated_at = abandoned_carts.cart_updated_at
+		AND abandoned_carts.notified_at IS NULL AND abandoned_carts.recovered_at IS NULL AND abandoned_carts.email <> ''
+		RETURNING abandoned_carts.*`, time.Now()).
+		Scan(&abandoned).Error
+	return abandoned, translateError(err)
+}
+
+func (r *AbandonedCartRepositoryImpl) ReleaseClaim(id uuid.UUID) error {
+	return translateError(r.db.Model(&entities.AbandonedCart{}).Where("id = ?", id).
+		Update("notified_at", nil).Error)
+}
+
+// MarkRecovered counts an order placed after the cart's last activity,
+// unless it has been cancelled, as the cart's recovery.
+func (r *AbandonedCartRepositoryImpl) MarkRecovered() (int64, error) {
+	const firstOrder = `SELECT MIN(orders.created_at) FROM orders
+		WHERE orders.user_id = abandoned_carts.user_id
+		AND orders.created_at > abandoned_carts.cart_updated_at
+		AND orders.status <> ?`
+	result := r.db.Exec(`UPDATE abandoned_carts SET recovered_at = (`+firstOrder+`)
+		WHERE recovered_at IS NULL AND user_id IS NOT NULL AND (`+firstOrder+`) IS NOT NULL`,
+		entities.OrderStatusCancelled, entities.OrderStatusCancelled)
+	return result.RowsAffected, translateError(result.Error)
+}
+
+func (r *AbandonedCartRepositoryImpl) List(params pagination.Params) (*pagination.Page[*entities.AbandonedCart], error) {
+	return paginate(r.db.Model(&entities.AbandonedCart{}), params, func(a *entities.AbandonedCart) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: a.CreatedAt, ID: a.ID}
+	})
+}
+
+func (r *AbandonedCartRepositoryImpl) Stats(since time.Time) (*entities.AbandonedCartStats, error) {
+	var stats entities.AbandonedCartStats
+	err := r.db.Model(&entities.AbandonedCart{}).
+		Select(`COUNT(*) AS abandoned, COUNT(notified_at) AS notified, COUNT(recovered_at) AS recovered,
+			COALESCE(SUM(total), 0) AS abandoned_value,
+			COALESCE(SUM(total) FILTER (WHERE recovered_at IS NOT NULL), 0) AS recovered_value`).
+		Where("created_at >= ?", since).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &stats, nil
+}
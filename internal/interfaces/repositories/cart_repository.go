@@ -1,10 +1,13 @@
 package repositories
 
 import (
+	"time"
+
 	"prototype-fiber/internal/domain/entities"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CartRepositoryImpl struct {
@@ -16,33 +19,33 @@ func NewCartRepository(db *gorm.DB) entities.CartRepository {
 }
 
 func (r *CartRepositoryImpl) Create(cart *entities.Cart) error {
-	return r.db.Create(cart).Error
+	return translateError(r.db.Create(cart).Error)
 }
 
 func (r *CartRepositoryImpl) GetByUserID(userID uuid.UUID) (*entities.Cart, error) {
 	var cart entities.Cart
-	err := r.db.Preload("Items.Product").Where("user_id = ?", userID).First(&cart).Error
+	err := r.db.Scopes(withPreloads(cartPreloads...)).Where("user_id = ?", userID).First(&cart).Error
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return &cart, nil
 }
 
 func (r *CartRepositoryImpl) GetByID(id uuid.UUID) (*entities.Cart, error) {
 	var cart entities.Cart
-	err := r.db.Preload("Items.Product").Where("id = ?", id).First(&cart).Error
+	err := r.db.Scopes(withPreloads(cartPreloads...)).Where("id = ?", id).First(&cart).Error
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return &cart, nil
 }
 
 func (r *CartRepositoryImpl) Update(cart *entities.Cart) error {
-	return r.db.Save(cart).Error
+	return translateError(r.db.Save(cart).Error)
 }
 
 func (r *CartRepositoryImpl) Delete(id uuid.UUID) error {
-	return r.db.Delete(&entities.Cart{}, id).Error
+	return translateError(r.db.Delete(&entities.Cart{}, id).Error)
 }
 
 func (r *CartRepositoryImpl) AddItem(cartID uuid.UUID, item *entities.CartItem) error {
@@ -50,31 +53,124 @@ func (r *CartRepositoryImpl) AddItem(cartID uuid.UUID, item *entities.CartItem)
 	
 	// Check if item already exists
 	var existingItem entities.CartItem
-	err := r.db.Where("cart_id = ? AND product_id = ?", cartID, item.ProductID).First(&existingItem).Error
+	err := r.db.Scopes(cartLine(cartID, item.ProductID, item.VariantID)).First(&existingItem).Error
 	
 	if err == gorm.ErrRecordNotFound {
 		// Create new item
-		return r.db.Create(item).Error
-	} else if err != nil {
-		return err
-	} else {
+		err = r.db.Create(item).Error
+	} else if err == nil {
 		// Update existing item quantity
 		existingItem.Quantity += item.Quantity
-		return r.db.Save(&existingItem).Error
+		err = r.db.Save(&existingItem).Error
+	}
+	if err != nil {
+		return translateError(err)
 	}
+	return translateError(r.touch(r.db, cartID))
 }
 
-func (r *CartRepositoryImpl) UpdateItem(cartID uuid.UUID, productID uuid.UUID, quantity int) error {
-	return r.db.Model(&entities.CartItem{}).
-		Where("cart_id = ? AND product_id = ?", cartID, productID).
+func (r *CartRepositoryImpl) UpdateItem(cartID uuid.UUID, productID uuid.UUID, variantID *uuid.UUID, quantity int) error {
+	err := r.db.Model(&entities.CartItem{}).
+		Scopes(cartLine(cartID, productID, variantID)).
 		Update("quantity", quantity).Error
+	if err != nil {
+		return translateError(err)
+	}
+	return translateError(r.touch(r.db, cartID))
 }
 
-func (r *CartRepositoryImpl) RemoveItem(cartID uuid.UUID, productID uuid.UUID) error {
-	return r.db.Where("cart_id = ? AND product_id = ?", cartID, productID).
+func (r *CartRepositoryImpl) RemoveItem(cartID uuid.UUID, productID uuid.UUID, variantID *uuid.UUID) error {
+	err := r.db.Scopes(cartLine(cartID, productID, variantID)).
 		Delete(&entities.CartItem{}).Error
+	if err != nil {
+		return translateError(err)
+	}
+	return translateError(r.touch(r.db, cartID))
+}
+
+// touch records activity on the cart. Revalidation changes items without
+// touching the cart, as the shopper has done nothing.
+func (r *CartRepositoryImpl) touch(tx *gorm.DB, cartID uuid.UUID) error {
+	return tx.Model(&entities.Cart{}).Where("id = ?", cartID).
+		UpdateColumn("updated_at", time.Now()).Error
+}
+
+var cartPreloads = []string{"Items.Product", "Items.Variant.OptionValues"}
+
+// cartLine selects the item for a product and variant; a nil variantID
+// matches only the line without a variant.
+func cartLine(cartID, productID uuid.UUID, variantID *uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("cart_id = ? AND product_id = ?", cartID, productID)
+		if variantID == nil {
+			return db.Where("variant_id IS NULL")
+		}
+		return db.Where("variant_id = ?", *variantID)
+	}
+}
+
+func (r *CartRepositoryImpl) UpdateLines(cartID uuid.UUID, lines []entities.CartItem) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		for _, line := range lines {
+			if _, err := updateLine(tx, cartID, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// updateLine writes the quantity and price of line to the matching line of
+// the cart, reporting whether there was one.
+func updateLine(tx *gorm.DB, cartID uuid.UUID, line entities.CartItem) (bool, error) {
+	result := tx.Model(&entities.CartItem{}).
+		Scopes(cartLine(cartID, line.ProductID, line.VariantID)).
+		Updates(map[string]interface{}{"quantity": line.Quantity, "price": line.Price})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *CartRepositoryImpl) Merge(guestCartID, cartID uuid.UUID, lines []entities.CartItem) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		for _, line := range lines {
+			updated, err := updateLine(tx, cartID, line)
+			if err != nil {
+				return err
+			}
+			if !updated {
+				line.ID = uuid.Nil
+				line.CartID = cartID
+				if err := tx.Omit(clause.Associations).Create(&line).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if err := tx.Where("cart_id = ?", guestCartID).Delete(&entities.CartItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&entities.Cart{}, guestCartID).Error; err != nil {
+			return err
+		}
+		return r.touch(tx, cartID)
+	}))
 }
 
 func (r *CartRepositoryImpl) Clear(cartID uuid.UUID) error {
-	return r.db.Where("cart_id = ?", cartID).Delete(&entities.CartItem{}).Error
+	if err := r.db.Where("cart_id = ?", cartID).Delete(&entities.CartItem{}).Error; err != nil {
+		return translateError(err)
+	}
+	return translateError(r.touch(r.db, cartID))
+}
+
+func (r *CartRepositoryImpl) PurgeInactive(before time.Time) (int64, error) {
+	var purged int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		stale := tx.Model(&entities.Cart{}).Select("id").Where("updated_at < ?", before)
+		if err := tx.Where("cart_id IN (?)", stale).Delete(&entities.CartItem{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("updated_at < ?", before).Delete(&entities.Cart{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, translateError(err)
 }
\ No newline at end of file
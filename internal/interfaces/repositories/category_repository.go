@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"prototype-fiber/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CategoryRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewCategoryRepository(db *gorm.DB) entities.CategoryRepository {
+	return &CategoryRepositoryImpl{db: db}
+}
+
+func (r *CategoryRepositoryImpl) Create(category *entities.Category) error {
+	return translateError(r.db.Create(category).Error)
+}
+
+func (r *CategoryRepositoryImpl) GetByID(id uuid.UUID) (*entities.Category, error) {
+	var category entities.Category
+	err := r.db.Where("id = ?", id).First(&category).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &category, nil
+}
+
+func (r *CategoryRepositoryImpl) GetByIDs(ids []uuid.UUID) ([]*entities.Category, error) {
+	categories := []*entities.Category{}
+	err := r.db.Where("id IN ?", ids).Find(&categories).Error
+	return categories, translateError(err)
+}
+
+func (r *CategoryRepositoryImpl) GetBySlugs(slugs []string) ([]*entities.Category, error) {
+	categories := []*entities.Category{}
+	err := r.db.Where("slug IN ?", slugs).Find(&categories).Error
+	return categories, translateError(err)
+}
+
+// Update saves the category and, when it was renamed, refreshes the search
+// text of the products assigned to it.
+func (r *CategoryRepositoryImpl) Update(category *entities.Category) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Parent").Save(category).Error; err != nil {
+			return err
+		}
+		assigned := tx.Table("product_categories").Select("product_id").Where("category_id = ?", category.ID)
+		return refreshCategoryText(tx, assigned)
+	}))
+}
+
+// Delete removes a category and its product assignments. Categories with
+// children are protected by the parent_id foreign key.
+func (r *CategoryRepositoryImpl) Delete(id uuid.UUID) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		var productIDs []uuid.UUID
+		if err := tx.Table("product_categories").Where("category_id = ?", id).Pluck("product_id", &productIDs).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&entities.Category{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if len(productIDs) == 0 {
+			return nil
+		}
+		return refreshCategoryText(tx, productIDs)
+	}))
+}
+
+func (r *CategoryRepositoryImpl) List() ([]*entities.Category, error) {
+	categories := []*entities.Category{}
+	err := r.db.Order("position, name").Find(&categories).Error
+	return categories, translateError(err)
+}
+
+func (r *CategoryRepositoryImpl) DescendantIDs(ids []uuid.UUID) ([]uuid.UUID, error) {
+	var descendants []uuid.UUID
+	err := r.db.Raw(`WITH RECURSIVE tree AS (
+		SELECT id FROM categories WHERE id IN ?
+		UNION
+		SELECT categories.id FROM categories JOIN tree ON categories.parent_id = tree.id
+	) SELECT id FROM tree`, ids).Scan(&descendants).Error
+	return descendants, translateError(err)
+}
+
+func (r *CategoryRepositoryImpl) ProductIDs(id uuid.UUID) ([]uuid.UUID, error) {
+	productIDs := []uuid.UUID{}
+	err := r.db.Table("product_categories").Where("category_id = ?", id).Pluck("product_id", &productIDs).Error
+	return productIDs, translateError(err)
+}
+
+// refreshCategoryText recomputes products.category, the denormalised
+// category names the search vector is built from, for productIDs (a slice
+// or a subquery).
+func refreshCategoryText(tx *gorm.DB, productIDs interface{}) error {
+	return tx.Exec(`UPDATE products SET category = COALESCE((
+		SELECT string_agg(categories.name, ' ' ORDER BY categories.name)
+		FROM product_categories JOIN categories ON categories.id = product_categories.category_id
+		WHERE product_categories.product_id = products.id
+	), '') WHERE id IN (?)`, productIDs).Error
+}
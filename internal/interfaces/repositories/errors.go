@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"errors"
+
+	"prototype-fiber/internal/domain/apperrors"
+
+	"gorm.io/gorm"
+)
+
+// translateError converts GORM errors into domain errors so use cases can
+// tell a missing row or a duplicate key apart from an outage.
+func translateError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return apperrors.ErrNotFound.Wrap(err)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return apperrors.ErrConflict.Wrap(err)
+	}
+	return err
+}
@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/pagination"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ImpersonationLogRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewImpersonationLogRepository(db *gorm.DB) entities.ImpersonationLogRepository {
+	return &ImpersonationLogRepositoryImpl{db: db}
+}
+
+func (r *ImpersonationLogRepositoryImpl) Create(entry *entities.ImpersonationLog) error {
+	return translateError(r.db.Create(entry).Error)
+}
+
+func (r *ImpersonationLogRepositoryImpl) UpdateStatusCode(id uuid.UUID, status int) error {
+	return translateError(r.db.Model(&entities.ImpersonationLog{}).Where("id = ?", id).Update("status_code", status).Error)
+}
+
+func (r *ImpersonationLogRepositoryImpl) ListByUserID(userID uuid.UUID, params pagination.Params) (*pagination.Page[*entities.ImpersonationLog], error) {
+	query := r.db.Model(&entities.ImpersonationLog{}).Where("user_id = ?", userID)
+	return paginate(query, params, func(e *entities.ImpersonationLog) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: e.CreatedAt, ID: e.ID}
+	})
+}
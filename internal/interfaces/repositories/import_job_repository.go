@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"time"
+
+	"prototype-fiber/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ImportJobRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewImportJobRepository(db *gorm.DB) entities.ImportJobRepository {
+	return &ImportJobRepositoryImpl{db: db}
+}
+
+func (r *ImportJobRepositoryImpl) Create(job *entities.ImportJob) error {
+	return translateError(r.db.Omit(clause.Associations).Create(job).Error)
+}
+
+func (r *ImportJobRepositoryImpl) GetByID(id uuid.UUID) (*entities.ImportJob, error) {
+	var job entities.ImportJob
+	err := r.db.Preload("Errors", func(db *gorm.DB) *gorm.DB { return db.Order("row, field") }).
+		Where("id = ?", id).
+		First(&job).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &job, nil
+}
+
+func (r *ImportJobRepositoryImpl) Update(job *entities.ImportJob) error {
+	return translateError(r.db.Omit(clause.Associations).Save(job).Error)
+}
+
+func (r *ImportJobRepositoryImpl) AddErrors(jobID uuid.UUID, errors []entities.ImportRowError) error {
+	if len(errors) == 0 {
+		return nil
+	}
+	for i := range errors {
+		errors[i].JobID = jobID
+	}
+	return translateError(r.db.CreateInBatches(errors, 100).Error)
+}
+
+func (r *ImportJobRepositoryImpl) FailUnfinished(message string) (int64, error) {
+	result := r.db.Model(&entities.ImportJob{}).
+		Where("status IN ?", []entities.ImportStatus{entities.ImportStatusPending, entities.ImportStatusRunning}).
+		Updates(map[string]interface{}{
+			"status":      entities.ImportStatusFailed,
+			"error":       message,
+			"finished_at": time.Now(),
+		})
+	return result.RowsAffected, translateError(result.Error)
+}
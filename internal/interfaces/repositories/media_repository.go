@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"prototype-fiber/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProductMediaRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewProductMediaRepository(db *gorm.DB) entities.ProductMediaRepository {
+	return &ProductMediaRepositoryImpl{db: db}
+}
+
+func (r *ProductMediaRepositoryImpl) Create(media *entities.ProductMedia) error {
+	return translateError(r.db.Create(media).Error)
+}
+
+func (r *ProductMediaRepositoryImpl) GetByID(id uuid.UUID) (*entities.ProductMedia, error) {
+	var media entities.ProductMedia
+	err := r.db.Scopes(withThumbnails).Where("id = ?", id).First(&media).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &media, nil
+}
+
+func (r *ProductMediaRepositoryImpl) ListByProductID(productID uuid.UUID) ([]*entities.ProductMedia, error) {
+	media := []*entities.ProductMedia{}
+	err := r.db.Scopes(withThumbnails).
+		Where("product_id = ?", productID).
+		Order("position, created_at").
+		Find(&media).Error
+	return media, translateError(err)
+}
+
+// Update saves the media's own columns; thumbnails never change.
+func (r *ProductMediaRepositoryImpl) Update(media *entities.ProductMedia) error {
+	return translateError(r.db.Omit(clause.Associations).Save(media).Error)
+}
+
+func (r *ProductMediaRepositoryImpl) Delete(id uuid.UUID) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("media_id = ?", id).Delete(&entities.MediaThumbnail{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entities.ProductMedia{}, id).Error
+	}))
+}
+
+func (r *ProductMediaRepositoryImpl) SetPrimary(productID, mediaID uuid.UUID) error {
+	return translateError(r.db.Model(&entities.ProductMedia{}).
+		Where("product_id = ?", productID).
+		Update("is_primary", gorm.Expr("id = ?", mediaID)).Error)
+}
+
+func (r *ProductMediaRepositoryImpl) Reorder(productID uuid.UUID, ids []uuid.UUID) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		for position, id := range ids {
+			err := tx.Model(&entities.ProductMedia{}).
+				Where("id = ? AND product_id = ?", id, productID).
+				Update("position", position).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+func withThumbnails(db *gorm.DB) *gorm.DB {
+	return db.Preload("Thumbnails", func(db *gorm.DB) *gorm.DB { return db.Order("size") })
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/pagination"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -16,48 +17,52 @@ func NewOrderRepository(db *gorm.DB) entities.OrderRepository {
 }
 
 func (r *OrderRepositoryImpl) Create(order *entities.Order) error {
-	return r.db.Create(order).Error
+	return translateError(r.db.Create(order).Error)
 }
 
 func (r *OrderRepositoryImpl) GetByID(id uuid.UUID) (*entities.Order, error) {
 	var order entities.Order
-	err := r.db.Preload("Items.Product").Preload("Payment").Where("id = ?", id).First(&order).Error
+	err := r.db.Scopes(withPreloads(orderPreloads...)).Where("id = ?", id).First(&order).Error
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return &order, nil
 }
 
-func (r *OrderRepositoryImpl) GetByUserID(userID uuid.UUID, offset, limit int) ([]*entities.Order, error) {
-	var orders []*entities.Order
-	err := r.db.Preload("Items.Product").Preload("Payment").
-		Where("user_id = ?", userID).
-		Order("created_at DESC").
-		Offset(offset).Limit(limit).Find(&orders).Error
-	return orders, err
+func (r *OrderRepositoryImpl) GetByUserID(userID uuid.UUID, params pagination.Params) (*pagination.Page[*entities.Order], error) {
+	query := r.db.Model(&entities.Order{}).Where("user_id = ?", userID)
+	return paginate(query, params, orderCursor, withPreloads(orderPreloads...))
 }
 
 func (r *OrderRepositoryImpl) Update(order *entities.Order) error {
-	return r.db.Save(order).Error
+	return translateError(r.db.Save(order).Error)
 }
 
 func (r *OrderRepositoryImpl) UpdateStatus(id uuid.UUID, status entities.OrderStatus) error {
-	return r.db.Model(&entities.Order{}).Where("id = ?", id).Update("status", status).Error
+	return translateError(r.db.Model(&entities.Order{}).Where("id = ?", id).Update("status", status).Error)
 }
 
-func (r *OrderRepositoryImpl) List(offset, limit int) ([]*entities.Order, error) {
-	var orders []*entities.Order
-	err := r.db.Preload("Items.Product").Preload("Payment").
-		Order("created_at DESC").
-		Offset(offset).Limit(limit).Find(&orders).Error
-	return orders, err
+func (r *OrderRepositoryImpl) List(params pagination.Params) (*pagination.Page[*entities.Order], error) {
+	return paginate(r.db.Model(&entities.Order{}), params, orderCursor, withPreloads(orderPreloads...))
 }
 
-func (r *OrderRepositoryImpl) GetByStatus(status entities.OrderStatus, offset, limit int) ([]*entities.Order, error) {
-	var orders []*entities.Order
-	err := r.db.Preload("Items.Product").Preload("Payment").
-		Where("status = ?", status).
-		Order("created_at DESC").
-		Offset(offset).Limit(limit).Find(&orders).Error
-	return orders, err
+func (r *OrderRepositoryImpl) GetByStatus(status entities.OrderStatus, params pagination.Params) (*pagination.Page[*entities.Order], error) {
+	query := r.db.Model(&entities.Order{}).Where("status = ?", status)
+	return paginate(query, params, orderCursor, withPreloads(orderPreloads...))
+}
+
+func (r *OrderRepositoryImpl) HasDelivered(userID, productID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&entities.Order{}).
+		Where("user_id = ? AND status = ?", userID, entities.OrderStatusDelivered).
+		Where("EXISTS (SELECT 1 FROM order_items WHERE order_items.order_id = orders.id AND order_items.product_id = ?)", productID).
+		Count(&count).Error
+	return count > 0, translateError(err)
+}
+
+// Items are read from their snapshot, never from the live catalogue.
+var orderPreloads = []string{"Items.Allocations", "Payment"}
+
+func orderCursor(o *entities.Order) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: o.CreatedAt, ID: o.ID}
 }
\ No newline at end of file
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"fmt"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/pkg/pagination"
+
+	"gorm.io/gorm"
+)
+
+// keyset is the ordering a page is read in. Column must come from an
+// allowlist, never from the request; rows are tie-broken by id in the same
+// direction. Name is recorded in cursors so a cursor cannot be replayed
+// against a different sort.
+type keyset struct {
+	Name   string
+	Column string
+	Desc   bool
+}
+
+var newestFirst = keyset{Column: "created_at", Desc: true}
+
+// paginate fetches one page of query, newest first by (created_at, id). The
+// query must have its model set. One extra row is read to tell whether
+// another page follows; scopes (preloads, extra selects) are applied to the
+// page query only so the count stays a plain COUNT(*).
+func paginate[T any](query *gorm.DB, params pagination.Params, key func(T) pagination.Cursor, scopes ...func(*gorm.DB) *gorm.DB) (*pagination.Page[T], error) {
+	return paginateBy(query, params, newestFirst, key, scopes...)
+}
+
+// paginateBy is paginate with an explicit ordering. key must fill Value
+// with the row's value of order.Column when order is not newestFirst.
+func paginateBy[T any](query *gorm.DB, params pagination.Params, order keyset, key func(T) pagination.Cursor, scopes ...func(*gorm.DB) *gorm.DB) (*pagination.Page[T], error) {
+	page := &pagination.Page[T]{}
+
+	if params.WithTotal {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return nil, translateError(err)
+		}
+		page.Total = &total
+	}
+
+	direction, comparison := "ASC", ">"
+	if order.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	find := query.Session(&gorm.Session{}).
+		Scopes(scopes...).
+		Order(fmt.Sprintf("%s %s", order.Column, direction)).
+		Order("id " + direction)
+
+	if params.UsesCursor() {
+		after := params.After
+		if after.Sort != order.Name {
+			return nil, apperrors.Validation(apperrors.CodeInvalidQuery, "cursor does not match the requested sort")
+		}
+		var value interface{} = after.CreatedAt
+		if order.Name != "" {
+			value = after.Value
+		}
+		find = find.Where(fmt.Sprintf("(%s, id) %s (?, ?)", order.Column, comparison), value, after.ID)
+	} else {
+		find = find.Offset(params.Offset)
+	}
+
+	items := make([]T, 0, params.Limit+1)
+	if err := find.Limit(params.Limit + 1).Find(&items).Error; err != nil {
+		return nil, translateError(err)
+	}
+
+	if len(items) > params.Limit {
+		items = items[:params.Limit]
+		page.HasMore = true
+		cursor := key(items[len(items)-1])
+		cursor.Sort = order.Name
+		page.NextCursor = cursor.Encode()
+	}
+	page.Items = items
+	return page, nil
+}
+
+func withPreloads(associations ...string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, association := range associations {
+			db = db.Preload(association)
+		}
+		return db
+	}
+}
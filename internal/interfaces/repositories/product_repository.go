@@ -1,10 +1,17 @@
 package repositories
 
 import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"prototype-fiber/internal/domain/apperrors"
 	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/pagination"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductRepositoryImpl struct {
@@ -15,15 +22,22 @@ func NewProductRepository(db *gorm.DB) entities.ProductRepository {
 	return &ProductRepositoryImpl{db: db}
 }
 
+// Create places the product's initial stock at the default warehouse and
+// records it in the ledger as a receipt.
 func (r *ProductRepositoryImpl) Create(product *entities.Product) error {
-	return r.db.Create(product).Error
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		return openStock(tx, product.ID, nil, product.Stock)
+	}))
 }
 
 func (r *ProductRepositoryImpl) GetByID(id uuid.UUID) (*entities.Product, error) {
 	var product entities.Product
-	err := r.db.Where("id = ?", id).First(&product).Error
+	err := r.db.Scopes(withVariants, withPreloads("Categories")).Where("id = ?", id).First(&product).Error
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return &product, nil
 }
@@ -32,44 +46,343 @@ func (r *ProductRepositoryImpl) GetBySKU(sku string) (*entities.Product, error)
 	var product entities.Product
 	err := r.db.Where("sku = ?", sku).First(&product).Error
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return &product, nil
 }
 
+// Update saves the product's own columns except stock, which only moves
+// through the ledger, the sales count, which only orders move, and the
+// rating, which follows its reviews; options and variants are written
+// through the ProductVariantRepository.
 func (r *ProductRepositoryImpl) Update(product *entities.Product) error {
-	return r.db.Save(product).Error
+	return translateError(r.db.Omit(clause.Associations, "stock", "sales_count", "rating_average", "rating_count").Save(product).Error)
 }
 
-func (r *ProductRepositoryImpl) Delete(id uuid.UUID) error {
-	return r.db.Delete(&entities.Product{}, id).Error
+// UpdateImageURL writes the one column, so it cannot overwrite an edit
+// made since the product was read.
+func (r *ProductRepositoryImpl) UpdateImageURL(id uuid.UUID, url string) error {
+	return translateError(r.db.Model(&entities.Product{}).Where("id = ?", id).UpdateColumn("image_url", url).Error)
 }
 
-func (r *ProductRepositoryImpl) List(offset, limit int, category string) ([]*entities.Product, error) {
+// withVariants loads a product's options, variants and media in display
+// order.
+func (r *ProductRepositoryImpl) GetStockLevels(ids []uuid.UUID) (map[uuid.UUID]*entities.Product, error) {
 	var products []*entities.Product
-	query := r.db.Where("is_active = ?", true)
-	
-	if category != "" {
-		query = query.Where("category = ?", category)
+	err := r.db.Select("id", "name", "sku", "stock", "reorder_threshold", "is_active").Where("id IN ?", ids).Find(&products).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	levels := make(map[uuid.UUID]*entities.Product, len(products))
+	for _, product := range products {
+		levels[product.ID] = product
 	}
-	
-	err := query.Offset(offset).Limit(limit).Find(&products).Error
-	return products, err
+	return levels, nil
 }
 
-func (r *ProductRepositoryImpl) Search(queryStr string, offset, limit int) ([]*entities.Product, error) {
-	var products []*entities.Product
-	searchPattern := "%" + queryStr + "%"
-	
-	err := r.db.Where("is_active = ? AND (name ILIKE ? OR description ILIKE ?)", 
-		true, searchPattern, searchPattern).
-		Offset(offset).Limit(limit).Find(&products).Error
-	
-	return products, err
-}
-
-func (r *ProductRepositoryImpl) UpdateStock(id uuid.UUID, quantity int) error {
-	return r.db.Model(&entities.Product{}).
-		Where("id = ?", id).
-		Update("stock", gorm.Expr("stock + ?", quantity)).Error
-}
\ No newline at end of file
+func withVariants(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Options", func(db *gorm.DB) *gorm.DB { return db.Order("position, name") }).
+		Preload("Options.Values", func(db *gorm.DB) *gorm.DB { return db.Order("position, value") }).
+		Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("created_at, id") }).
+		Preload("Variants.OptionValues").
+		Preload("Media", func(db *gorm.DB) *gorm.DB { return db.Order("position, created_at") }).
+		Preload("Media.Thumbnails", func(db *gorm.DB) *gorm.DB { return db.Order("size") })
+}
+
+func (r *ProductRepositoryImpl) Delete(id uuid.UUID) error {
+	return translateError(r.db.Delete(&entities.Product{}, id).Error)
+}
+
+// productSortColumns is the allowlist of sortable columns.
+var productSortColumns = map[entities.ProductSort]string{
+	entities.ProductSortNewest:     "created_at",
+	entities.ProductSortPrice:      "price",
+	entities.ProductSortName:       "name",
+	entities.ProductSortPopularity: "sales_count",
+	entities.ProductSortRating:     "rating_average",
+}
+
+func (r *ProductRepositoryImpl) List(filter entities.ProductFilter, params pagination.Params) (*pagination.Page[*entities.Product], error) {
+	query := r.db.Model(&entities.Product{})
+	if !filter.IncludeInactive {
+		query = query.Where("is_active = ?", true)
+	}
+	query = applyProductFilter(query, filter)
+
+	order, err := productKeyset(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return paginateBy(query, params, order, func(p *entities.Product) pagination.Cursor {
+		cursor := productCursor(p)
+		switch filter.Sort {
+		case entities.ProductSortPrice:
+			cursor.Value = p.Price
+		case entities.ProductSortName:
+			cursor.Value = p.Name
+		case entities.ProductSortPopularity:
+			cursor.Value = p.SalesCount
+		case entities.ProductSortRating:
+			cursor.Value = p.RatingAverage
+		case entities.ProductSortNewest:
+			cursor.Value = p.CreatedAt
+		}
+		return cursor
+	}, withPreloads("Categories"))
+}
+
+// applyProductFilter adds the filter's conditions; sorting is left to the
+// caller. Columns are qualified so callers can join other tables.
+func applyProductFilter(query *gorm.DB, filter entities.ProductFilter) *gorm.DB {
+	if len(filter.CategoryIDs) > 0 {
+		query = query.Where("products.id IN (SELECT product_id FROM product_categories WHERE category_id IN ?)", filter.CategoryIDs)
+	}
+	if filter.MinPrice != nil {
+		query = query.Where("products.price >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		query = query.Where("products.price <= ?", *filter.MaxPrice)
+	}
+	if filter.InStockOnly {
+		query = query.Where("products.stock > 0")
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("products.created_at > ?", *filter.CreatedAfter)
+	}
+	return query
+}
+
+func productKeyset(filter entities.ProductFilter) (keyset, error) {
+	if filter.Sort == "" || (filter.Sort == entities.ProductSortNewest && filter.Descending) {
+		return newestFirst, nil
+	}
+	column, ok := productSortColumns[filter.Sort]
+	if !ok {
+		return keyset{}, apperrors.Validation(apperrors.CodeInvalidQuery, "unsupported sort: "+string(filter.Sort))
+	}
+	direction := "asc"
+	if filter.Descending {
+		direction = "desc"
+	}
+	return keyset{Name: string(filter.Sort) + ":" + direction, Column: column, Desc: filter.Descending}, nil
+}
+
+// productSearchSQL matches the weighted search_vector (see
+// database.migrateProductSearch) and falls back to trigram word similarity on
+// the name for misspellings. The query is also tried with hyphens removed so
+// "t-shirt" and "tshirt" find each other.
+const productSearchSQL = `
+SELECT products.*, q.query AS search_query,
+	ts_rank(products.search_vector, q.query) + word_similarity(@term, products.name) AS rank
+FROM products,
+	LATERAL (SELECT websearch_to_tsquery('english', @term) || websearch_to_tsquery('english', replace(@term, '-', ''))) AS q(query)
+WHERE products.is_active AND (products.search_vector @@ q.query OR @term <% products.name)`
+
+const productHighlightSelect = `products.*,
+	ts_headline('english', products.name, products.search_query, 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>') AS name_highlight,
+	ts_headline('english', products.description, products.search_query, 'StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10, MaxFragments=2') AS description_highlight`
+
+var productsByRelevance = keyset{Name: "relevance", Column: "rank", Desc: true}
+
+func (r *ProductRepositoryImpl) Search(queryStr string, params pagination.Params) (*pagination.Page[*entities.Product], error) {
+	return r.SearchFiltered(queryStr, entities.ProductFilter{}, params)
+}
+
+// SearchFiltered is Search narrowed by filter. Results are always ordered
+// by relevance; filter.Sort is ignored.
+func (r *ProductRepositoryImpl) SearchFiltered(queryStr string, filter entities.ProductFilter, params pagination.Params) (*pagination.Page[*entities.Product], error) {
+	matches := r.db.Raw(productSearchSQL, sql.Named("term", queryStr))
+	query := applyProductFilter(r.db.Model(&entities.Product{}).Table("(?) AS products", matches), filter)
+
+	// Highlights are computed for the returned page only, not every match.
+	highlight := func(db *gorm.DB) *gorm.DB {
+		return db.Select(productHighlightSelect)
+	}
+
+	return paginateBy(query, params, productsByRelevance, func(p *entities.Product) pagination.Cursor {
+		cursor := productCursor(p)
+		cursor.Value = p.SearchRank
+		return cursor
+	}, highlight, withPreloads("Categories"))
+}
+
+const maxCategoryFacets = 20
+
+func (r *ProductRepositoryImpl) SearchFacets(queryStr string, filter entities.ProductFilter) (*entities.SearchFacets, error) {
+	matches := r.db.Raw(productSearchSQL, sql.Named("term", queryStr))
+	facets := &entities.SearchFacets{
+		Categories: []entities.FacetCount{},
+	}
+
+	err := applyProductFilter(r.db.Table("(?) AS products", matches), filter).
+		Joins("JOIN product_categories ON product_categories.product_id = products.id").
+		Joins("JOIN categories ON categories.id = product_categories.category_id").
+		Select("categories.slug AS value, categories.name AS name, count(*) AS count").
+		Group("categories.slug, categories.name").
+		Order("count DESC, categories.slug").
+		Limit(maxCategoryFacets).
+		Scan(&facets.Categories).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	// One pass computes every bucket plus the stock split.
+	columns := make([]string, 0, len(entities.PriceBucketBounds)+3)
+	lower := 0.0
+	for _, upper := range entities.PriceBucketBounds {
+		columns = append(columns, fmt.Sprintf("count(*) FILTER (WHERE price >= %g AND price < %g)", lower, upper))
+		lower = upper
+	}
+	columns = append(columns,
+		fmt.Sprintf("count(*) FILTER (WHERE price >= %g)", lower),
+		"count(*) FILTER (WHERE stock > 0)",
+		"count(*) FILTER (WHERE stock <= 0)",
+	)
+
+	counts := make([]int64, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range counts {
+		dest[i] = &counts[i]
+	}
+	row := applyProductFilter(r.db.Table("(?) AS products", matches), filter).Select(strings.Join(columns, ", ")).Row()
+	if err := row.Scan(dest...); err != nil {
+		return nil, translateError(err)
+	}
+
+	lower = 0
+	for i, upper := range entities.PriceBucketBounds {
+		max := upper
+		facets.PriceBuckets = append(facets.PriceBuckets, entities.PriceBucket{Min: lower, Max: &max, Count: counts[i]})
+		lower = upper
+	}
+	buckets := len(entities.PriceBucketBounds)
+	facets.PriceBuckets = append(facets.PriceBuckets, entities.PriceBucket{Min: lower, Count: counts[buckets]})
+	facets.InStock = counts[buckets+1]
+	facets.OutOfStock = counts[buckets+2]
+
+	return facets, nil
+}
+
+// Suggest returns active products whose name starts with prefix, then close
+// trigram matches, most popular first within each group.
+func (r *ProductRepositoryImpl) Suggest(prefix string, limit int) ([]*entities.ProductSuggestion, error) {
+	pattern := escapeLike(strings.ToLower(prefix)) + "%"
+
+	suggestions := []*entities.ProductSuggestion{}
+	err := r.db.Model(&entities.Product{}).
+		Select("id, name, category, price").
+		Where("is_active AND (lower(name) LIKE @pattern OR @prefix <% name)", sql.Named("pattern", pattern), sql.Named("prefix", prefix)).
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "lower(name) LIKE ? DESC, word_similarity(?, name) DESC, sales_count DESC, name",
+			Vars: []interface{}{pattern, prefix},
+		}}).
+		Limit(limit).
+		Scan(&suggestions).Error
+	return suggestions, translateError(err)
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func productCursor(p *entities.Product) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: p.CreatedAt, ID: p.ID}
+}
+
+func (r *ProductRepositoryImpl) SetCategories(id uuid.UUID, categoryIDs []uuid.UUID) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM product_categories WHERE product_id = ?", id).Error; err != nil {
+			return err
+		}
+		for _, categoryID := range categoryIDs {
+			err := tx.Exec("INSERT INTO product_categories (product_id, category_id) VALUES (?, ?) ON CONFLICT DO NOTHING", id, categoryID).Error
+			if err != nil {
+				return err
+			}
+		}
+		return refreshCategoryText(tx, []uuid.UUID{id})
+	}))
+}
+
+func (r *ProductRepositoryImpl) UpdateStock(id uuid.UUID, quantity int, change entities.StockChange) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		return moveProductStock(tx, id, quantity, change, nil)
+	}))
+}
+
+func (r *ProductRepositoryImpl) RecordOrder(order *entities.Order) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+		for _, item := range order.Items {
+			for _, allocation := range item.Allocations {
+				sale := entities.StockChange{Reason: entities.StockReasonSale, WarehouseID: &allocation.WarehouseID, OrderID: &order.ID, UserID: &order.UserID}
+				if err := recordSale(tx, item.ProductID, item.VariantID, allocation.Quantity, sale); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}))
+}
+
+// recordSale takes quantity out of the product's or variant's stock and
+// adds it to the product's sales count; a negative quantity reverses a
+// sale.
+func recordSale(tx *gorm.DB, id uuid.UUID, variantID *uuid.UUID, quantity int, change entities.StockChange) error {
+	salesCount := gorm.Expr("GREATEST(sales_count + ?, 0)", quantity)
+	if variantID == nil {
+		return moveProductStock(tx, id, -quantity, change, map[string]interface{}{"sales_count": salesCount})
+	}
+
+	productID, err := moveVariantStock(tx, *variantID, -quantity, change)
+	if err != nil {
+		return err
+	}
+	if productID != id {
+		return gorm.ErrRecordNotFound
+	}
+	return tx.Model(&entities.Product{}).Where("id = ?", id).Update("sales_count", salesCount).Error
+}
+
+func (r *ProductRepositoryImpl) ReturnOrder(order *entities.Order, status entities.OrderStatus, returns []entities.StockReturn) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entities.Order{}).
+			Where("id = ? AND status = ?", order.ID, order.Status).
+			Update("status", status)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.ErrConflict
+		}
+
+		for _, stock := range returns {
+			var err error
+			if stock.Unreturned {
+				err = recordUnreturned(tx, stock)
+			} else {
+				err = recordSale(tx, stock.ProductID, stock.VariantID, -stock.Quantity, stock.Change)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// recordUnreturned writes only the ledger entry: there is no stock or
+// warehouse level left to move, so the entry is bookkeeping with a zero
+// balance.
+func recordUnreturned(tx *gorm.DB, stock entities.StockReturn) error {
+	change := stock.Change
+	change.WarehouseID = nil
+	if change.Note == "" {
+		change.Note = "not returned to stock: deleted from the catalogue"
+	}
+	return recordMovement(tx, stock.ProductID, stock.VariantID, stock.Quantity, 0, change)
+}
@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"time"
+
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/pagination"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReviewRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewReviewRepository(db *gorm.DB) entities.ReviewRepository {
+	return &ReviewRepositoryImpl{db: db}
+}
+
+func (r *ReviewRepositoryImpl) Create(review *entities.Review) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(review).Error; err != nil {
+			return err
+		}
+		return refreshRating(tx, review.ProductID)
+	}))
+}
+
+func (r *ReviewRepositoryImpl) GetByID(id uuid.UUID) (*entities.Review, error) {
+	var review entities.Review
+	err := r.db.Where("id = ?", id).First(&review).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &review, nil
+}
+
+func (r *ReviewRepositoryImpl) Update(review *entities.Review) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations, "helpful_count").Save(review).Error; err != nil {
+			return err
+		}
+		return refreshRating(tx, review.ProductID)
+	}))
+}
+
+func (r *ReviewRepositoryImpl) Delete(id uuid.UUID) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		var review entities.Review
+		if err := tx.Clauses(clause.Returning{}).Where("id = ?", id).Delete(&review).Error; err != nil {
+			return err
+		}
+		if review.ID == uuid.Nil {
+			return gorm.ErrRecordNotFound
+		}
+		return refreshRating(tx, review.ProductID)
+	}))
+}
+
+func (r *ReviewRepositoryImpl) ListByProduct(productID uuid.UUID, status entities.ReviewStatus, params pagination.Params) (*pagination.Page[*entities.Review], error) {
+	query := r.db.Model(&entities.Review{}).Where("product_id = ? AND status = ?", productID, status)
+	return paginate(query, params, reviewCursor)
+}
+
+func (r *ReviewRepositoryImpl) List(status entities.ReviewStatus, params pagination.Params) (*pagination.Page[*entities.Review], error) {
+	query := r.db.Model(&entities.Review{}).Where("status = ?", status)
+	return paginate(query, params, reviewCursor)
+}
+
+func (r *ReviewRepositoryImpl) SetStatus(id uuid.UUID, status entities.ReviewStatus, moderatorID uuid.UUID) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		var review entities.Review
+		result := tx.Model(&review).Clauses(clause.Returning{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"status":       status,
+			"moderated_by": moderatorID,
+			"moderated_at": time.Now(),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return refreshRating(tx, review.ProductID)
+	}))
+}
+
+func (r *ReviewRepositoryImpl) AddVote(reviewID, userID uuid.UUID) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		vote := &entities.ReviewVote{ReviewID: reviewID, UserID: userID}
+		result := tx.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(vote)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return adjustHelpfulCount(tx, reviewID, 1)
+	}))
+}
+
+func (r *ReviewRepositoryImpl) RemoveVote(reviewID, userID uuid.UUID) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("review_id = ? AND user_id = ?", reviewID, userID).Delete(&entities.ReviewVote{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return adjustHelpfulCount(tx, reviewID, -1)
+	}))
+}
+
+func adjustHelpfulCount(tx *gorm.DB, reviewID uuid.UUID, delta int) error {
+	return tx.Model(&entities.Review{}).Where("id = ?", reviewID).
+		UpdateColumn("helpful_count", gorm.Expr("helpful_count + ?", delta)).Error
+}
+
+// refreshRating recomputes a product's rating from its approved reviews.
+// UpdateColumns leaves updated_at alone: a new review is not an edit of the
+// product.
+func refreshRating(tx *gorm.DB, productID uuid.UUID) error {
+	approved := tx.Model(&entities.Review{}).Where("product_id = ? AND status = ?", productID, entities.ReviewApproved)
+	return tx.Model(&entities.Product{}).Where("id = ?", productID).UpdateColumns(map[string]interface{}{
+		"rating_average": gorm.Expr("COALESCE((?), 0)", approved.Session(&gorm.Session{}).Select("ROUND(AVG(rating), 2)")),
+		"rating_count":   gorm.Expr("(?)", approved.Session(&gorm.Session{}).Select("COUNT(*)")),
+	}).Error
+}
+
+func reviewCursor(r *entities.Review) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: r.CreatedAt, ID: r.ID}
+}
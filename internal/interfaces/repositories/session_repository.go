@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"time"
+
+	"prototype-fiber/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SessionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) entities.SessionRepository {
+	return &SessionRepositoryImpl{db: db}
+}
+
+func (r *SessionRepositoryImpl) Create(session *entities.Session) error {
+	return translateError(r.db.Create(session).Error)
+}
+
+func (r *SessionRepositoryImpl) GetByID(id uuid.UUID) (*entities.Session, error) {
+	var session entities.Session
+	err := r.db.Where("id = ?", id).First(&session).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &session, nil
+}
+
+func (r *SessionRepositoryImpl) ListActiveByUserID(userID uuid.UUID) ([]*entities.Session, error) {
+	var sessions []*entities.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, translateError(err)
+}
+
+func (r *SessionRepositoryImpl) UpdateLastSeen(id uuid.UUID, lastSeen time.Time) error {
+	return translateError(r.db.Model(&entities.Session{}).
+		Where("id = ? AND last_seen_at < ?", id, lastSeen).
+		Update("last_seen_at", lastSeen).Error)
+}
+
+func (r *SessionRepositoryImpl) Revoke(id uuid.UUID) error {
+	return translateError(r.db.Model(&entities.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error)
+}
+
+func (r *SessionRepositoryImpl) RevokeAllByUserID(userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entities.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Model(&entities.Session{}).
+			Where("id IN ?", ids).
+			Update("revoked_at", time.Now()).Error
+	})
+	return ids, translateError(err)
+}
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"time"
+
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/pagination"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StockAlertRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewStockAlertRepository(db *gorm.DB) entities.StockAlertRepository {
+	return &StockAlertRepositoryImpl{db: db}
+}
+
+// Open relies on the partial unique index over open alerts to skip a
+// product that already has one.
+func (r *StockAlertRepositoryImpl) Open(alert *entities.StockAlert) (bool, error) {
+	alert.Status = entities.StockAlertOpen
+	result := r.db.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(alert)
+	if result.Error != nil {
+		return false, translateError(result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *StockAlertRepositoryImpl) GetByID(id uuid.UUID) (*entities.StockAlert, error) {
+	var alert entities.StockAlert
+	err := r.db.Preload("Product").Where("id = ?", id).First(&alert).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &alert, nil
+}
+
+func (r *StockAlertRepositoryImpl) List(status entities.StockAlertStatus, params pagination.Params) (*pagination.Page[*entities.StockAlert], error) {
+	query := r.db.Model(&entities.StockAlert{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	return paginate(query, params, func(a *entities.StockAlert) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: a.CreatedAt, ID: a.ID}
+	}, withPreloads("Product"))
+}
+
+// Resolve leaves an already resolved alert as it is.
+func (r *StockAlertRepositoryImpl) Resolve(id uuid.UUID) error {
+	var alert entities.StockAlert
+	if err := r.db.Select("id").Where("id = ?", id).First(&alert).Error; err != nil {
+		return translateError(err)
+	}
+	return r.resolve(r.db.Where("id = ?", id))
+}
+
+func (r *StockAlertRepositoryImpl) ResolveOpen(productID uuid.UUID) error {
+	return r.resolve(r.db.Where("product_id = ?", productID))
+}
+
+func (r *StockAlertRepositoryImpl) resolve(query *gorm.DB) error {
+	err := query.Model(&entities.StockAlert{}).
+		Where("status = ?", entities.StockAlertOpen).
+		Updates(map[string]interface{}{"status": entities.StockAlertResolved, "resolved_at": time.Now()}).Error
+	return translateError(err)
+}
+
+type BackInStockRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewBackInStockRepository(db *gorm.DB) entities.BackInStockRepository {
+	return &BackInStockRepositoryImpl{db: db}
+}
+
+// Subscribe renews an existing subscription by clearing NotifiedAt, so a
+// customer told once can ask to be told again.
+func (r *BackInStockRepositoryImpl) Subscribe(subscription *entities.BackInStockSubscription) error {
+	err := r.db.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "product_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"notified_at": nil}),
+	}).Create(subscription).Error
+	return translateError(err)
+}
+
+func (r *BackInStockRepositoryImpl) Unsubscribe(productID, userID uuid.UUID) error {
+	err := r.db.Where("product_id = ? AND user_id = ?", productID, userID).
+		Delete(&entities.BackInStockSubscription{}).Error
+	return translateError(err)
+}
+
+// ClaimPending marks and returns the subscriptions in one statement, so
+// concurrent stock changes cannot both claim the same subscription.
+func (r *BackInStockRepositoryImpl) ClaimPending(productID uuid.UUID) ([]*entities.BackInStockSubscription, error) {
+	subscriptions := []*entities.BackInStockSubscription{}
+	err := r.db.Model(&subscriptions).Clauses(clause.Returning{}).
+		Where("product_id = ? AND notified_at IS NULL", productID).
+		Update("notified_at", time.Now()).Error
+	return subscriptions, translateError(err)
+}
@@ -0,0 +1,199 @@
+package repositories
+
+import (
+	"errors"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/pagination"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StockMovementRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewStockMovementRepository(db *gorm.DB) entities.StockMovementRepository {
+	return &StockMovementRepositoryImpl{db: db}
+}
+
+func (r *StockMovementRepositoryImpl) ListByProductID(productID uuid.UUID, params pagination.Params) (*pagination.Page[*entities.StockMovement], error) {
+	query := r.db.Model(&entities.StockMovement{}).Where("product_id = ?", productID)
+	return paginate(query, params, func(m *entities.StockMovement) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: m.CreatedAt, ID: m.ID}
+	})
+}
+
+// Reconcile checks products without variants against their own movements
+// and variants against theirs. A product's movements from before it had
+// variants are closed out when the first variant is created.
+func (r *StockMovementRepositoryImpl) Reconcile() ([]*entities.StockDiscrepancy, error) {
+	discrepancies := []*entities.StockDiscrepancy{}
+	err := r.db.Raw(`
+		SELECT products.id AS product_id, NULL::uuid AS variant_id, products.sku, products.stock,
+			COALESCE(SUM(stock_movements.delta), 0) AS ledger_balance
+		FROM products
+		LEFT JOIN stock_movements ON stock_movements.product_id = products.id AND stock_movements.variant_id IS NULL
+		WHERE NOT EXISTS (SELECT 1 FROM product_variants WHERE product_variants.product_id = products.id)
+		GROUP BY products.id
+		HAVING products.stock <> COALESCE(SUM(stock_movements.delta), 0)
+		UNION ALL
+		SELECT product_variants.product_id, product_variants.id, product_variants.sku, product_variants.stock,
+			COALESCE(SUM(stock_movements.delta), 0)
+		FROM product_variants
+		LEFT JOIN stock_movements ON stock_movements.variant_id = product_variants.id
+		GROUP BY product_variants.id
+		HAVING product_variants.stock <> COALESCE(SUM(stock_movements.delta), 0)
+		ORDER BY sku`).Scan(&discrepancies).Error
+	return discrepancies, translateError(err)
+}
+
+// moveProductStock adds delta to a product's own stock at the change's
+// warehouse, along with any other column updates, and records the movement
+// with the new total.
+func moveProductStock(tx *gorm.DB, productID uuid.UUID, delta int, change entities.StockChange, updates map[string]interface{}) error {
+	change, err := placeStock(tx, productID, nil, delta, change)
+	if err != nil {
+		return err
+	}
+
+	if updates == nil {
+		updates = make(map[string]interface{}, 1)
+	}
+	updates["stock"] = gorm.Expr("stock + ?", delta)
+
+	var product entities.Product
+	result := tx.Clauses(clause.Returning{Columns: []clause.Column{{Name: "stock"}}}).
+		Model(&product).
+		Where("id = ?", productID).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return recordMovement(tx, productID, nil, delta, product.Stock, change)
+}
+
+// moveVariantStock adds delta to a variant's stock at the change's
+// warehouse, records the movement and brings the product's total up to
+// date. It returns the variant's product ID.
+func moveVariantStock(tx *gorm.DB, variantID uuid.UUID, delta int, change entities.StockChange) (uuid.UUID, error) {
+	var variant entities.ProductVariant
+	if err := tx.Select("id", "product_id").Where("id = ?", variantID).First(&variant).Error; err != nil {
+		return uuid.Nil, err
+	}
+	change, err := placeStock(tx, variant.ProductID, &variantID, delta, change)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	result := tx.Clauses(clause.Returning{Columns: []clause.Column{{Name: "stock"}}}).
+		Model(&variant).
+		Update("stock", gorm.Expr("stock + ?", delta))
+	if result.Error != nil {
+		return uuid.Nil, result.Error
+	}
+	if err := recordMovement(tx, variant.ProductID, &variantID, delta, variant.Stock, change); err != nil {
+		return uuid.Nil, err
+	}
+	return variant.ProductID, syncVariantStock(tx, variant.ProductID)
+}
+
+// placeStock adds delta to the stock level at the change's warehouse, or
+// at the default warehouse when it names none, and returns the change with
+// the warehouse filled in. Levels never go below zero.
+func placeStock(tx *gorm.DB, productID uuid.UUID, variantID *uuid.UUID, delta int, change entities.StockChange) (entities.StockChange, error) {
+	var warehouse entities.Warehouse
+	query := tx.Select("id")
+	if change.WarehouseID != nil {
+		query = query.Where("id = ?", *change.WarehouseID)
+	} else {
+		query = query.Order("priority, created_at")
+	}
+	err := query.First(&warehouse).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return change, apperrors.NotFound(apperrors.CodeWarehouseNotFound, "warehouse not found")
+	}
+	if err != nil {
+		return change, err
+	}
+	change.WarehouseID = &warehouse.ID
+	if delta == 0 {
+		return change, nil
+	}
+
+	var level entities.StockLevel
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Scopes(stockLocation(warehouse.ID, productID, variantID)).
+		First(&level).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		level = entities.StockLevel{WarehouseID: warehouse.ID, ProductID: productID, VariantID: variantID}
+	case err != nil:
+		return change, err
+	}
+
+	if level.Quantity+delta < 0 {
+		if variantID != nil {
+			return change, apperrors.InsufficientVariantStock(productID, *variantID, "", -delta, level.Quantity)
+		}
+		return change, apperrors.InsufficientStock(productID, "", -delta, level.Quantity)
+	}
+	level.Quantity += delta
+	if level.ID == uuid.Nil {
+		return change, tx.Create(&level).Error
+	}
+	return change, tx.Model(&level).Update("quantity", level.Quantity).Error
+}
+
+// stockLocation narrows a query to the stock level of a product, or of one
+// of its variants, at a warehouse.
+func stockLocation(warehouseID, productID uuid.UUID, variantID *uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("warehouse_id = ? AND product_id = ?", warehouseID, productID)
+		if variantID == nil {
+			return db.Where("variant_id IS NULL")
+		}
+		return db.Where("variant_id = ?", *variantID)
+	}
+}
+
+// recordMovement appends to the ledger; zero deltas are not recorded.
+func recordMovement(tx *gorm.DB, productID uuid.UUID, variantID *uuid.UUID, delta, balance int, change entities.StockChange) error {
+	if delta == 0 {
+		return nil
+	}
+	return tx.Create(&entities.StockMovement{
+		ProductID:   productID,
+		VariantID:   variantID,
+		WarehouseID: change.WarehouseID,
+		Delta:       delta,
+		Reason:      change.Reason,
+		OrderID:     change.OrderID,
+		UserID:      change.UserID,
+		Note:        change.Note,
+		Balance:     balance,
+	}).Error
+}
+
+// initialStock is the ledger entry for stock a product or variant is
+// created with, which is received at the default warehouse.
+var initialStock = entities.StockChange{Reason: entities.StockReasonReceipt, Note: "initial stock"}
+
+// openStock places the stock a product or variant is created with and
+// records it in the ledger.
+func openStock(tx *gorm.DB, productID uuid.UUID, variantID *uuid.UUID, stock int) error {
+	if stock == 0 {
+		return nil
+	}
+	change, err := placeStock(tx, productID, variantID, stock, initialStock)
+	if err != nil {
+		return err
+	}
+	return recordMovement(tx, productID, variantID, stock, stock, change)
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/pagination"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -16,14 +17,14 @@ func NewUserRepository(db *gorm.DB) entities.UserRepository {
 }
 
 func (r *UserRepositoryImpl) Create(user *entities.User) error {
-	return r.db.Create(user).Error
+	return translateError(r.db.Create(user).Error)
 }
 
 func (r *UserRepositoryImpl) GetByID(id uuid.UUID) (*entities.User, error) {
 	var user entities.User
 	err := r.db.Where("id = ?", id).First(&user).Error
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return &user, nil
 }
@@ -32,21 +33,23 @@ func (r *UserRepositoryImpl) GetByEmail(email string) (*entities.User, error) {
 	var user entities.User
 	err := r.db.Where("email = ?", email).First(&user).Error
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return &user, nil
 }
 
 func (r *UserRepositoryImpl) Update(user *entities.User) error {
-	return r.db.Save(user).Error
+	return translateError(r.db.Save(user).Error)
 }
 
 func (r *UserRepositoryImpl) Delete(id uuid.UUID) error {
-	return r.db.Delete(&entities.User{}, id).Error
+	return translateError(r.db.Delete(&entities.User{}, id).Error)
 }
 
-func (r *UserRepositoryImpl) List(offset, limit int) ([]*entities.User, error) {
-	var users []*entities.User
-	err := r.db.Offset(offset).Limit(limit).Find(&users).Error
-	return users, err
+func (r *UserRepositoryImpl) List(params pagination.Params) (*pagination.Page[*entities.User], error) {
+	return paginate(r.db.Model(&entities.User{}), params, userCursor)
+}
+
+func userCursor(u *entities.User) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: u.CreatedAt, ID: u.ID}
 }
\ No newline at end of file
@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"prototype-fiber/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProductVariantRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewProductVariantRepository(db *gorm.DB) entities.ProductVariantRepository {
+	return &ProductVariantRepositoryImpl{db: db}
+}
+
+func (r *ProductVariantRepositoryImpl) SetOptions(productID uuid.UUID, options []entities.ProductOption) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		existing := tx.Model(&entities.ProductOption{}).Select("id").Where("product_id = ?", productID)
+		if err := tx.Where("option_id IN (?)", existing).Delete(&entities.ProductOptionValue{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", productID).Delete(&entities.ProductOption{}).Error; err != nil {
+			return err
+		}
+		if len(options) == 0 {
+			return nil
+		}
+		for i := range options {
+			options[i].ProductID = productID
+		}
+		return tx.Create(&options).Error
+	}))
+}
+
+// Create places the variant's initial stock at the default warehouse as a
+// receipt. The first variant of a product also closes out the product's own
+// stock, since from then on its stock is the total of its variants.
+func (r *ProductVariantRepositoryImpl) Create(variant *entities.ProductVariant) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		var siblings int64
+		if err := tx.Model(&entities.ProductVariant{}).Where("product_id = ?", variant.ProductID).Count(&siblings).Error; err != nil {
+			return err
+		}
+		if siblings == 0 {
+			var product entities.Product
+			if err := tx.Select("stock").Where("id = ?", variant.ProductID).First(&product).Error; err != nil {
+				return err
+			}
+			err := recordMovement(tx, variant.ProductID, nil, -product.Stock, 0, entities.StockChange{
+				Reason: entities.StockReasonAdjustment,
+				Note:   "stock moved to variants",
+			})
+			if err != nil {
+				return err
+			}
+			if err := tx.Where("product_id = ? AND variant_id IS NULL", variant.ProductID).Delete(&entities.StockLevel{}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(variant).Error; err != nil {
+			return err
+		}
+		if err := openStock(tx, variant.ProductID, &variant.ID, variant.Stock); err != nil {
+			return err
+		}
+		return syncVariantStock(tx, variant.ProductID)
+	}))
+}
+
+func (r *ProductVariantRepositoryImpl) GetByID(id uuid.UUID) (*entities.ProductVariant, error) {
+	var variant entities.ProductVariant
+	err := r.db.Preload("OptionValues").Where("id = ?", id).First(&variant).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &variant, nil
+}
+
+func (r *ProductVariantRepositoryImpl) GetBySKU(sku string) (*entities.ProductVariant, error) {
+	var variant entities.ProductVariant
+	err := r.db.Where("sku = ?", sku).First(&variant).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &variant, nil
+}
+
+// Update saves the variant's own columns except stock, which only moves
+// through the ledger; its option values are fixed when it is created.
+func (r *ProductVariantRepositoryImpl) Update(variant *entities.ProductVariant) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations, "stock").Save(variant).Error; err != nil {
+			return err
+		}
+		return syncVariantStock(tx, variant.ProductID)
+	}))
+}
+
+func (r *ProductVariantRepositoryImpl) Delete(id uuid.UUID) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		var variant entities.ProductVariant
+		if err := tx.Where("id = ?", id).First(&variant).Error; err != nil {
+			return err
+		}
+		// The variant's stock levels go with it, so the ledger writes its
+		// stock off first
+		err := recordMovement(tx, variant.ProductID, &variant.ID, -variant.Stock, 0, entities.StockChange{
+			Reason: entities.StockReasonAdjustment,
+			Note:   "variant deleted",
+		})
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&variant).Association("OptionValues").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Delete(&variant).Error; err != nil {
+			return err
+		}
+		return syncVariantStock(tx, variant.ProductID)
+	}))
+}
+
+func (r *ProductVariantRepositoryImpl) UpdateStock(id uuid.UUID, quantity int, change entities.StockChange) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		_, err := moveVariantStock(tx, id, quantity, change)
+		return err
+	}))
+}
+
+// syncVariantStock sets a product's stock to the total of its active
+// variants, so listings, filters and search see variant products as in or
+// out of stock.
+func syncVariantStock(tx *gorm.DB, productID uuid.UUID) error {
+	return tx.Exec(`UPDATE products SET stock = (
+		SELECT COALESCE(SUM(GREATEST(stock, 0)), 0) FROM product_variants WHERE product_id = @id AND is_active
+	) WHERE id = @id`, map[string]interface{}{"id": productID}).Error
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"prototype-fiber/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WarehouseRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewWarehouseRepository(db *gorm.DB) entities.WarehouseRepository {
+	return &WarehouseRepositoryImpl{db: db}
+}
+
+func (r *WarehouseRepositoryImpl) Create(warehouse *entities.Warehouse) error {
+	return translateError(r.db.Create(warehouse).Error)
+}
+
+func (r *WarehouseRepositoryImpl) GetByID(id uuid.UUID) (*entities.Warehouse, error) {
+	var warehouse entities.Warehouse
+	err := r.db.Where("id = ?", id).First(&warehouse).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &warehouse, nil
+}
+
+func (r *WarehouseRepositoryImpl) List() ([]*entities.Warehouse, error) {
+	warehouses := []*entities.Warehouse{}
+	err := r.db.Order("priority, created_at").Find(&warehouses).Error
+	return warehouses, translateError(err)
+}
+
+func (r *WarehouseRepositoryImpl) Update(warehouse *entities.Warehouse) error {
+	return translateError(r.db.Save(warehouse).Error)
+}
+
+// Delete clears the warehouse's empty stock levels, which would otherwise
+// hold it in place through their foreign key.
+func (r *WarehouseRepositoryImpl) Delete(id uuid.UUID) error {
+	return translateError(r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("warehouse_id = ? AND quantity = 0", id).Delete(&entities.StockLevel{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&entities.Warehouse{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	}))
+}
+
+func (r *WarehouseRepositoryImpl) HasStock(id uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&entities.StockLevel{}).Where("warehouse_id = ? AND quantity <> 0", id).Count(&count).Error
+	return count > 0, translateError(err)
+}
+
+func (r *WarehouseRepositoryImpl) ListStockLevels(productIDs []uuid.UUID) ([]*entities.StockLevel, error) {
+	levels := []*entities.StockLevel{}
+	if len(productIDs) == 0 {
+		return levels, nil
+	}
+	err := r.db.Where("product_id IN ? AND quantity <> 0", productIDs).Find(&levels).Error
+	return levels, translateError(err)
+}
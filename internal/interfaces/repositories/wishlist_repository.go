@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"prototype-fiber/internal/domain/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WishlistRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewWishlistRepository(db *gorm.DB) entities.WishlistRepository {
+	return &WishlistRepositoryImpl{db: db}
+}
+
+func (r *WishlistRepositoryImpl) Create(wishlist *entities.Wishlist) error {
+	return translateError(r.db.Omit(clause.Associations).Create(wishlist).Error)
+}
+
+func (r *WishlistRepositoryImpl) GetByID(id uuid.UUID) (*entities.Wishlist, error) {
+	return r.first(r.db.Where("id = ?", id))
+}
+
+func (r *WishlistRepositoryImpl) GetByShareToken(token string) (*entities.Wishlist, error) {
+	return r.first(r.db.Where("share_token = ?", token))
+}
+
+func (r *WishlistRepositoryImpl) first(query *gorm.DB) (*entities.Wishlist, error) {
+	var wishlist entities.Wishlist
+	err := query.Scopes(withWishlistItems).First(&wishlist).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &wishlist, nil
+}
+
+func (r *WishlistRepositoryImpl) ListByUserID(userID uuid.UUID) ([]*entities.Wishlist, error) {
+	wishlists := []*entities.Wishlist{}
+	err := r.db.Scopes(withWishlistItems).Where("user_id = ?", userID).Order("created_at, id").Find(&wishlists).Error
+	return wishlists, translateError(err)
+}
+
+func (r *WishlistRepositoryImpl) Update(wishlist *entities.Wishlist) error {
+	return translateError(r.db.Omit(clause.Associations).Save(wishlist).Error)
+}
+
+func (r *WishlistRepositoryImpl) Delete(id uuid.UUID) error {
+	return translateError(r.db.Delete(&entities.Wishlist{}, id).Error)
+}
+
+// AddItem relies on the unique index over wishlist, product and variant
+// (see database.migrateWishlists) to ignore items already saved.
+func (r *WishlistRepositoryImpl) AddItem(wishlistID uuid.UUID, item *entities.WishlistItem) error {
+	item.WishlistID = wishlistID
+	return translateError(r.db.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(item).Error)
+}
+
+func (r *WishlistRepositoryImpl) RemoveItem(wishlistID, productID uuid.UUID, variantID *uuid.UUID) error {
+	query := r.db.Where("wishlist_id = ? AND product_id = ?", wishlistID, productID)
+	if variantID == nil {
+		query = query.Where("variant_id IS NULL")
+	} else {
+		query = query.Where("variant_id = ?", *variantID)
+	}
+	result := query.Delete(&entities.WishlistItem{})
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return translateError(gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// withWishlistItems loads items newest first with what Wishlist.Refresh
+// needs.
+func withWishlistItems(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("created_at DESC, id") }).
+		Preload("Items.Product").
+		Preload("Items.Variant.OptionValues")
+}
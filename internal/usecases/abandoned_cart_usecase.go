@@ -0,0 +1,166 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/pagination"
+	"prototype-fiber/pkg/tokens"
+)
+
+// defaultReportPeriod is how far back the abandoned cart report looks when
+// no start is given.
+const defaultReportPeriod = 30 * 24 * time.Hour
+
+// AbandonedCartUseCase runs the abandoned cart job and reports on it.
+type AbandonedCartUseCase struct {
+	abandonedRepo entities.AbandonedCartRepository
+	cartRepo      entities.CartRepository
+	mailer        entities.Mailer
+	keySet        *tokens.KeySet
+	abandonAfter  time.Duration
+	retention     time.Duration
+	recoveryURL   string
+}
+
+// AbandonedCartRun counts what one run of the job did.
+type AbandonedCartRun struct {
+	Purged    int64
+	Recovered int64
+	Abandoned int64
+	Notified  int64
+}
+
+// AbandonedCartReportQuery takes since as RFC 3339 or YYYY-MM-DD.
+type AbandonedCartReportQuery struct {
+	Since string `json:"since" query:"since"`
+}
+
+// AbandonedCartReport is the recovery rate of carts abandoned since Since.
+type AbandonedCartReport struct {
+	Since time.Time `json:"since"`
+	entities.AbandonedCartStats
+}
+
+// NewAbandonedCartUseCase creates the use case. Carts count as abandoned
+// after abandonAfter without activity and are purged after retention; the
+// recovery email links to recoveryURL with a cart token valid until then.
+func NewAbandonedCartUseCase(abandonedRepo entities.AbandonedCartRepository, cartRepo entities.CartRepository, mailer entities.Mailer, keySet *tokens.KeySet, abandonAfter, retention time.Duration, recoveryURL string) *AbandonedCartUseCase {
+	return &AbandonedCartUseCase{
+		abandonedRepo: abandonedRepo,
+		cartRepo:      cartRepo,
+		mailer:        mailer,
+		keySet:        keySet,
+		abandonAfter:  abandonAfter,
+		retention:     retention,
+		recoveryURL:   recoveryURL,
+	}
+}
+
+// Run purges stale carts, marks abandoned carts whose shopper has since
+// ordered as recovered, records newly abandoned carts and emails their
+// owners. A failed email does not stop the run; it is retried next time.
+func (uc *AbandonedCartUseCase) Run(now time.Time) (*AbandonedCartRun, error) {
+	run := &AbandonedCartRun{}
+	var err error
+	if run.Purged, err = uc.cartRepo.PurgeInactive(now.Add(-uc.retention)); err != nil {
+		return nil, fmt.Errorf("purge carts: %w", err)
+	}
+	if run.Recovered, err = uc.abandonedRepo.MarkRecovered(); err != nil {
+		return nil, fmt.Errorf("mark recovered carts: %w", err)
+	}
+	if run.Abandoned, err = uc.abandonedRepo.RecordAbandoned(now.Add(-uc.abandonAfter)); err != nil {
+		return nil, fmt.Errorf("record abandoned carts: %w", err)
+	}
+
+	// Claim before sending, so that jobs on other instances skip these carts
+	pending, err := uc.abandonedRepo.ClaimPending()
+	if err != nil {
+		return nil, fmt.Errorf("claim abandoned carts: %w", err)
+	}
+	var failed []error
+	for _, abandoned := range pending {
+		if err := uc.notify(abandoned); err != nil {
+			failed = append(failed, fmt.Errorf("notify abandoned cart %s: %w", abandoned.CartID, err))
+			if err := uc.abandonedRepo.ReleaseClaim(abandoned.ID); err != nil {
+				failed = append(failed, fmt.Errorf("release abandoned cart %s: %w", abandoned.CartID, err))
+			}
+			continue
+		}
+		run.Notified++
+	}
+	return run, errors.Join(failed...)
+}
+
+func (uc *AbandonedCartUseCase) ListAbandonedCarts(params pagination.Params) (*pagination.Page[*entities.AbandonedCart], error) {
+	return uc.abandonedRepo.List(params)
+}
+
+// Report sums up the carts abandoned since the query's start, the last 30
+// days by default. The recovery rate is the share of them recovered.
+func (uc *AbandonedCartUseCase) Report(query *AbandonedCartReportQuery, now time.Time) (*AbandonedCartReport, error) {
+	since := now.Add(-defaultReportPeriod)
+	if query.Since != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, query.Since)
+		if err != nil {
+			since, err = time.Parse(time.DateOnly, query.Since)
+		}
+		if err != nil {
+			return nil, apperrors.Validation(apperrors.CodeInvalidQuery, "since must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+	}
+
+	stats, err := uc.abandonedRepo.Stats(since)
+	if err != nil {
+		return nil, err
+	}
+	if stats.Abandoned > 0 {
+		stats.RecoveryRate = float64(stats.Recovered) / float64(stats.Abandoned)
+	}
+	return &AbandonedCartReport{Since: since, AbandonedCartStats: *stats}, nil
+}
+
+// notify emails the shopper a link back to the cart, which stays valid for
+// as long as the cart is kept.
+func (uc *AbandonedCartUseCase) notify(abandoned *entities.AbandonedCart) error {
+	cart, err := uc.cartRepo.GetByID(abandoned.CartID)
+	if err != nil {
+		return err
+	}
+	token, err := uc.keySet.IssueCart(cart.ID, uc.retention)
+	if err != nil {
+		return err
+	}
+
+	var body strings.Builder
+	body.WriteString("Hello,\n\nYou left these items in your cart:\n\n")
+	for _, item := range cart.Items {
+		fmt.Fprintf(&body, "- %d x %s\n", item.Quantity, cartItemName(&item))
+	}
+	fmt.Fprintf(&body, "\nPick up where you left off: %s?cart_token=%s\n", uc.recoveryURL, url.QueryEscape(token))
+
+	return uc.mailer.Send(&entities.MailMessage{
+		To:      abandoned.Email,
+		Subject: "You left something in your cart",
+		Body:    body.String(),
+	})
+}
+
+// cartItemName names the item's product and, when it has one, its variant
+// by option values.
+func cartItemName(item *entities.CartItem) string {
+	if item.Variant == nil || len(item.Variant.OptionValues) == 0 {
+		return item.Product.Name
+	}
+	values := make([]string, len(item.Variant.OptionValues))
+	for i, value := range item.Variant.OptionValues {
+		values[i] = value.Value
+	}
+	return item.Product.Name + " (" + strings.Join(values, " / ") + ")"
+}
@@ -0,0 +1,146 @@
+package usecases
+
+import (
+	"fmt"
+	"sort"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AllocationPriority = "priority"
+	AllocationNearest  = "nearest"
+)
+
+// AllocationPolicy decides which warehouses an order ships from.
+// Warehouses are ranked by priority; the nearest strategy ranks those in
+// the shipping country first. Without AllowSplit, an order that no single
+// warehouse can fulfil is refused.
+type AllocationPolicy struct {
+	Strategy   string
+	AllowSplit bool
+}
+
+func NewAllocationPolicy(strategy string, allowSplit bool) (AllocationPolicy, error) {
+	switch strategy {
+	case AllocationPriority, AllocationNearest:
+		return AllocationPolicy{Strategy: strategy, AllowSplit: allowSplit}, nil
+	default:
+		return AllocationPolicy{}, fmt.Errorf("unknown allocation strategy %q", strategy)
+	}
+}
+
+// allocationLine is one order item to allocate; name is the product's
+// display name for errors.
+type allocationLine struct {
+	productID uuid.UUID
+	variantID *uuid.UUID
+	quantity  int
+	name      string
+}
+
+// stockKey identifies a stock level; variantID is uuid.Nil for a product
+// without variants.
+type stockKey struct {
+	warehouseID uuid.UUID
+	productID   uuid.UUID
+	variantID   uuid.UUID
+}
+
+func newStockKey(warehouseID, productID uuid.UUID, variantID *uuid.UUID) stockKey {
+	key := stockKey{warehouseID: warehouseID, productID: productID}
+	if variantID != nil {
+		key.variantID = *variantID
+	}
+	return key
+}
+
+// allocate assigns each line to warehouses, returning the allocations in
+// line order. An order one warehouse can fulfil ships from the best-ranked
+// such warehouse. Otherwise, when splitting is allowed, each line is taken
+// whole from the best-ranked warehouse that has it, or failing that from as
+// many warehouses as it takes, in rank order.
+func (p AllocationPolicy) allocate(lines []allocationLine, warehouses []*entities.Warehouse, levels []*entities.StockLevel, country string) ([][]entities.OrderItemAllocation, error) {
+	available := make(map[stockKey]int, len(levels))
+	for _, level := range levels {
+		available[newStockKey(level.WarehouseID, level.ProductID, level.VariantID)] += level.Quantity
+	}
+	ranked := p.rank(warehouses, country)
+	allocations := make([][]entities.OrderItemAllocation, len(lines))
+
+	for _, warehouse := range ranked {
+		if fulfils(available, warehouse.ID, lines) {
+			for i, line := range lines {
+				allocations[i] = []entities.OrderItemAllocation{{WarehouseID: warehouse.ID, Quantity: line.quantity}}
+			}
+			return allocations, nil
+		}
+	}
+	if !p.AllowSplit {
+		return nil, apperrors.Conflict(apperrors.CodeAllocationFailed, "no single warehouse can fulfil the order")
+	}
+
+	for i, line := range lines {
+		for _, warehouse := range ranked {
+			key := newStockKey(warehouse.ID, line.productID, line.variantID)
+			if available[key] >= line.quantity {
+				available[key] -= line.quantity
+				allocations[i] = []entities.OrderItemAllocation{{WarehouseID: warehouse.ID, Quantity: line.quantity}}
+				break
+			}
+		}
+		if allocations[i] != nil {
+			continue
+		}
+
+		remaining := line.quantity
+		for _, warehouse := range ranked {
+			key := newStockKey(warehouse.ID, line.productID, line.variantID)
+			take := min(available[key], remaining)
+			if take <= 0 {
+				continue
+			}
+			available[key] -= take
+			remaining -= take
+			allocations[i] = append(allocations[i], entities.OrderItemAllocation{WarehouseID: warehouse.ID, Quantity: take})
+			if remaining == 0 {
+				break
+			}
+		}
+		if remaining > 0 {
+			if line.variantID != nil {
+				return nil, apperrors.InsufficientVariantStock(line.productID, *line.variantID, line.name, line.quantity, line.quantity-remaining)
+			}
+			return nil, apperrors.InsufficientStock(line.productID, line.name, line.quantity, line.quantity-remaining)
+		}
+	}
+	return allocations, nil
+}
+
+// rank orders warehouses by preference, leaving the input untouched.
+func (p AllocationPolicy) rank(warehouses []*entities.Warehouse, country string) []*entities.Warehouse {
+	ranked := append([]*entities.Warehouse(nil), warehouses...)
+	local := func(w *entities.Warehouse) bool {
+		return p.Strategy == AllocationNearest && country != "" && w.Country == country
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if local(ranked[i]) != local(ranked[j]) {
+			return local(ranked[i])
+		}
+		return ranked[i].Priority < ranked[j].Priority
+	})
+	return ranked
+}
+
+// fulfils reports whether a warehouse holds enough of every line.
+func fulfils(available map[stockKey]int, warehouseID uuid.UUID, lines []allocationLine) bool {
+	for _, line := range lines {
+		if available[newStockKey(warehouseID, line.productID, line.variantID)] < line.quantity {
+			return false
+		}
+	}
+	return true
+}
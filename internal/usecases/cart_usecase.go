@@ -2,8 +2,12 @@ package usecases
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
+	"prototype-fiber/internal/domain/apperrors"
 	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/tokens"
 
 	"github.com/google/uuid"
 )
@@ -11,30 +15,47 @@ import (
 type CartUseCase struct {
 	cartRepo    entities.CartRepository
 	productRepo entities.ProductRepository
+	keySet      *tokens.KeySet
+	guestTTL    time.Duration
 }
 
+// AddToCartRequest adds a product to the cart. VariantID is required for
+// products sold in variants and must be omitted otherwise.
 type AddToCartRequest struct {
-	ProductID uuid.UUID `json:"product_id" validate:"required"`
-	Quantity  int       `json:"quantity" validate:"required,gt=0"`
+	ProductID uuid.UUID  `json:"product_id" validate:"required"`
+	VariantID *uuid.UUID `json:"variant_id"`
+	Quantity  int        `json:"quantity" validate:"required,gt=0,lte=1000"`
 }
 
-func NewCartUseCase(cartRepo entities.CartRepository, productRepo entities.ProductRepository) *CartUseCase {
+// GuestCart is a new guest cart and the token that identifies it.
+type GuestCart struct {
+	Token string         `json:"cart_token"`
+	Cart  *entities.Cart `json:"cart"`
+}
+
+// NewCartUseCase creates the cart use case. Guest cart tokens are signed
+// with keySet and expire after guestTTL.
+func NewCartUseCase(cartRepo entities.CartRepository, productRepo entities.ProductRepository, keySet *tokens.KeySet, guestTTL time.Duration) *CartUseCase {
 	return &CartUseCase{
 		cartRepo:    cartRepo,
 		productRepo: productRepo,
+		keySet:      keySet,
+		guestTTL:    guestTTL,
 	}
 }
 
 func (uc *CartUseCase) GetOrCreateCart(userID uuid.UUID) (*entities.Cart, error) {
 	cart, err := uc.cartRepo.GetByUserID(userID)
-	if err != nil {
+	if errors.Is(err, apperrors.ErrNotFound) {
 		// Create new cart if doesn't exist
 		cart = &entities.Cart{
-			UserID: userID,
+			UserID: &userID,
 		}
 		if err := uc.cartRepo.Create(cart); err != nil {
 			return nil, err
 		}
+	} else if err != nil {
+		return nil, err
 	}
 	return cart, nil
 }
@@ -44,79 +65,358 @@ func (uc *CartUseCase) AddToCart(userID uuid.UUID, req *AddToCartRequest) (*enti
 	if err != nil {
 		return nil, err
 	}
+	return uc.addItem(cart, req)
+}
 
-	product, err := uc.productRepo.GetByID(req.ProductID)
+// UpdateCartItem sets the quantity of the line for productID and variantID,
+// removing it at zero. variantID is nil for products without variants.
+func (uc *CartUseCase) UpdateCartItem(userID, productID uuid.UUID, variantID *uuid.UUID, quantity int) (*entities.Cart, error) {
+	cart, err := uc.userCart(userID)
 	if err != nil {
-		return nil, errors.New("product not found")
+		return nil, err
 	}
+	return uc.updateItem(cart, productID, variantID, quantity)
+}
 
-	if !product.CanFulfillQuantity(req.Quantity) {
-		return nil, errors.New("insufficient stock")
+func (uc *CartUseCase) RemoveFromCart(userID, productID uuid.UUID, variantID *uuid.UUID) (*entities.Cart, error) {
+	cart, err := uc.userCart(userID)
+	if err != nil {
+		return nil, err
 	}
+	return uc.removeItem(cart, productID, variantID)
+}
 
-	cartItem := &entities.CartItem{
-		ProductID: req.ProductID,
-		Quantity:  req.Quantity,
-		Price:     product.Price,
+func (uc *CartUseCase) ClearCart(userID uuid.UUID) error {
+	cart, err := uc.userCart(userID)
+	if err != nil {
+		return err
 	}
 
-	if err := uc.cartRepo.AddItem(cart.ID, cartItem); err != nil {
+	return uc.cartRepo.Clear(cart.ID)
+}
+
+func (uc *CartUseCase) userCart(userID uuid.UUID) (*entities.Cart, error) {
+	cart, err := uc.cartRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeCartNotFound, "cart not found")
+	}
+	return cart, nil
+}
+
+// GetCart returns the user's cart revalidated against the catalogue, with
+// the changes the shopper should see before checking out.
+func (uc *CartUseCase) GetCart(userID uuid.UUID) (*entities.Cart, error) {
+	cart, err := uc.userCart(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := revalidateCart(uc.cartRepo, uc.productRepo, cart); err != nil {
 		return nil, err
 	}
+	return cart, nil
+}
 
-	return uc.cartRepo.GetByID(cart.ID)
+// CreateGuestCart starts an empty cart for a shopper who has not signed in.
+// The returned token is the only way to reach it.
+func (uc *CartUseCase) CreateGuestCart() (*GuestCart, error) {
+	cart := &entities.Cart{Items: []entities.CartItem{}}
+	if err := uc.cartRepo.Create(cart); err != nil {
+		return nil, err
+	}
+	token, err := uc.keySet.IssueCart(cart.ID, uc.guestTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &GuestCart{Token: token, Cart: cart}, nil
 }
 
-func (uc *CartUseCase) UpdateCartItem(userID, productID uuid.UUID, quantity int) (*entities.Cart, error) {
-	cart, err := uc.cartRepo.GetByUserID(userID)
+// GetGuestCart returns the guest cart revalidated, as GetCart does.
+func (uc *CartUseCase) GetGuestCart(token string) (*entities.Cart, error) {
+	cart, err := uc.guestCart(token)
 	if err != nil {
 		return nil, err
 	}
+	if err := revalidateCart(uc.cartRepo, uc.productRepo, cart); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
 
-	if quantity <= 0 {
-		if err := uc.cartRepo.RemoveItem(cart.ID, productID); err != nil {
+func (uc *CartUseCase) AddToGuestCart(token string, req *AddToCartRequest) (*entities.Cart, error) {
+	cart, err := uc.guestCart(token)
+	if err != nil {
+		return nil, err
+	}
+	return uc.addItem(cart, req)
+}
+
+func (uc *CartUseCase) UpdateGuestCartItem(token string, productID uuid.UUID, variantID *uuid.UUID, quantity int) (*entities.Cart, error) {
+	cart, err := uc.guestCart(token)
+	if err != nil {
+		return nil, err
+	}
+	return uc.updateItem(cart, productID, variantID, quantity)
+}
+
+func (uc *CartUseCase) RemoveFromGuestCart(token string, productID uuid.UUID, variantID *uuid.UUID) (*entities.Cart, error) {
+	cart, err := uc.guestCart(token)
+	if err != nil {
+		return nil, err
+	}
+	return uc.removeItem(cart, productID, variantID)
+}
+
+func (uc *CartUseCase) ClearGuestCart(token string) error {
+	cart, err := uc.guestCart(token)
+	if err != nil {
+		return err
+	}
+	return uc.cartRepo.Clear(cart.ID)
+}
+
+// RecoverCart returns the cart named by the token in a recovery email,
+// revalidated. Only signed-in shoppers get those emails, so the cart is a
+// user's, and theirs to change again once they sign in.
+func (uc *CartUseCase) RecoverCart(token string) (*entities.Cart, error) {
+	cartID, err := uc.keySet.ParseCart(token)
+	if err != nil {
+		return nil, apperrors.Unauthorized(apperrors.CodeInvalidCartToken, "invalid cart token")
+	}
+	cart, err := uc.cartRepo.GetByID(cartID)
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeCartNotFound, "cart not found")
+	}
+	if err := revalidateCart(uc.cartRepo, uc.productRepo, cart); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+// MergeGuestCart moves the guest cart's items into the user's cart and
+// deletes the guest cart. A product already in the user's cart gets the two
+// quantities added up. Every merged line is repriced and cut down to the
+// stock available now, and lines that can no longer be bought are dropped.
+// An empty token merges nothing and returns a nil cart.
+func (uc *CartUseCase) MergeGuestCart(userID uuid.UUID, token string) (*entities.Cart, error) {
+	if token == "" {
+		return nil, nil
+	}
+	guest, err := uc.guestCart(token)
+	if err != nil {
+		return nil, err
+	}
+	cart, err := uc.GetOrCreateCart(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]entities.CartItem, 0, len(guest.Items))
+	for _, item := range guest.Items {
+		quantity := item.Quantity
+		if existing := cart.Item(item.ProductID, item.VariantID); existing != nil {
+			quantity += existing.Quantity
+		}
+		line, ok, err := uc.mergedLine(item, quantity)
+		if err != nil {
 			return nil, err
 		}
-	} else {
-		product, err := uc.productRepo.GetByID(productID)
+		if ok {
+			lines = append(lines, line)
+		}
+	}
+
+	if err := uc.cartRepo.Merge(guest.ID, cart.ID, lines); err != nil {
+		return nil, err
+	}
+	return uc.cartRepo.GetByID(cart.ID)
+}
+
+// mergedLine prices a merged line at today's price and caps its quantity
+// at the stock available; ok is false when none is.
+func (uc *CartUseCase) mergedLine(item entities.CartItem, quantity int) (entities.CartItem, bool, error) {
+	line := entities.CartItem{ProductID: item.ProductID, VariantID: item.VariantID}
+
+	offer, err := currentOffer(uc.productRepo, item.ProductID, item.VariantID)
+	if err != nil || !offer.onSale {
+		return line, false, err
+	}
+
+	line.Quantity = min(quantity, offer.available)
+	line.Price = offer.price
+	return line, line.Quantity > 0, nil
+}
+
+// offer is what a cart line can be bought for right now. A product or
+// variant that is gone or inactive is not on offer.
+type offer struct {
+	name      string
+	price     float64
+	available int
+	onSale    bool
+}
+
+func currentOffer(productRepo entities.ProductRepository, productID uuid.UUID, variantID *uuid.UUID) (offer, error) {
+	product, err := productRepo.GetByID(productID)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return offer{}, nil
+	}
+	if err != nil {
+		return offer{}, err
+	}
+	variant, err := resolveVariant(product, variantID)
+	if err != nil {
+		// The variant is gone, or the product has since been split into
+		// variants and the line never named one.
+		return offer{name: product.Name}, nil
+	}
+
+	if variant == nil {
+		return offer{name: product.Name, price: product.Price, available: product.Stock, onSale: product.IsActive}, nil
+	}
+	return offer{
+		name:      product.Name + " (" + product.VariantLabel(variant) + ")",
+		price:     variant.EffectivePrice(product),
+		available: variant.Stock,
+		onSale:    product.IsActive && variant.IsActive,
+	}, nil
+}
+
+// revalidateCart brings the cart up to date with the catalogue. Lines are
+// repriced and cut down to the stock available, and both changes are saved;
+// lines that cannot be bought at all are flagged but kept. Every change is
+// listed in cart.Changes. Price and quantity changes are reported once,
+// when they are made; unavailable lines are reported until removed.
+func revalidateCart(cartRepo entities.CartRepository, productRepo entities.ProductRepository, cart *entities.Cart) error {
+	cart.Changes = nil
+	var updated []entities.CartItem
+	for i := range cart.Items {
+		item := &cart.Items[i]
+		offer, err := currentOffer(productRepo, item.ProductID, item.VariantID)
 		if err != nil {
-			return nil, errors.New("product not found")
+			return err
 		}
+		change := entities.CartChange{ProductID: item.ProductID, VariantID: item.VariantID, Name: offer.name}
 
-		if !product.CanFulfillQuantity(quantity) {
-			return nil, errors.New("insufficient stock")
+		item.Unavailable = !offer.onSale || offer.available <= 0
+		if item.Unavailable {
+			change.Kind, change.Message = entities.CartChangeUnavailable, "no longer available"
+			if offer.onSale {
+				change.Kind, change.Message = entities.CartChangeOutOfStock, "out of stock"
+			}
+			cart.Changes = append(cart.Changes, change)
+			continue
 		}
 
-		if err := uc.cartRepo.UpdateItem(cart.ID, productID, quantity); err != nil {
-			return nil, err
+		changed := false
+		if offer.price != item.Price {
+			change := change
+			change.Kind, change.Message = entities.CartChangePriceDecreased, "price decreased"
+			if offer.price > item.Price {
+				change.Kind, change.Message = entities.CartChangePriceIncreased, "price increased"
+			}
+			change.Message += fmt.Sprintf(" from %.2f to %.2f", item.Price, offer.price)
+			change.OldPrice, change.NewPrice = item.Price, offer.price
+			cart.Changes = append(cart.Changes, change)
+			item.Price = offer.price
+			changed = true
+		}
+		if item.Quantity > offer.available {
+			change := change
+			change.Kind = entities.CartChangeQuantityReduced
+			change.Message = fmt.Sprintf("quantity reduced from %d to %d, the stock available", item.Quantity, offer.available)
+			change.OldQuantity, change.NewQuantity = item.Quantity, offer.available
+			cart.Changes = append(cart.Changes, change)
+			item.Quantity = offer.available
+			changed = true
+		}
+		if changed {
+			updated = append(updated, *item)
 		}
 	}
 
-	return uc.cartRepo.GetByID(cart.ID)
+	if len(updated) == 0 {
+		return nil
+	}
+	return cartRepo.UpdateLines(cart.ID, updated)
 }
 
-func (uc *CartUseCase) RemoveFromCart(userID, productID uuid.UUID) (*entities.Cart, error) {
-	cart, err := uc.cartRepo.GetByUserID(userID)
+// guestCart resolves a cart token to its cart. Tokens of carts that have
+// been merged no longer resolve.
+func (uc *CartUseCase) guestCart(token string) (*entities.Cart, error) {
+	cartID, err := uc.keySet.ParseCart(token)
+	if err != nil {
+		return nil, apperrors.Unauthorized(apperrors.CodeInvalidCartToken, "invalid cart token")
+	}
+	cart, err := uc.cartRepo.GetByID(cartID)
+	if err == nil && cart.UserID != nil {
+		err = apperrors.ErrNotFound
+	}
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeCartNotFound, "cart not found")
+	}
+	return cart, nil
+}
+
+func (uc *CartUseCase) addItem(cart *entities.Cart, req *AddToCartRequest) (*entities.Cart, error) {
+	product, err := uc.productRepo.GetByID(req.ProductID)
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeProductNotFound, "product not found")
+	}
+
+	variant, err := resolveVariant(product, req.VariantID)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkStock(product, variant, req.Quantity); err != nil {
+		return nil, err
+	}
+
+	cartItem := &entities.CartItem{
+		ProductID: req.ProductID,
+		VariantID: req.VariantID,
+		Quantity:  req.Quantity,
+		Price:     product.Price,
+	}
+	if variant != nil {
+		cartItem.Price = variant.EffectivePrice(product)
+	}
 
-	if err := uc.cartRepo.RemoveItem(cart.ID, productID); err != nil {
+	if err := uc.cartRepo.AddItem(cart.ID, cartItem); err != nil {
 		return nil, err
 	}
 
 	return uc.cartRepo.GetByID(cart.ID)
 }
 
-func (uc *CartUseCase) ClearCart(userID uuid.UUID) error {
-	cart, err := uc.cartRepo.GetByUserID(userID)
+func (uc *CartUseCase) updateItem(cart *entities.Cart, productID uuid.UUID, variantID *uuid.UUID, quantity int) (*entities.Cart, error) {
+	if quantity <= 0 {
+		return uc.removeItem(cart, productID, variantID)
+	}
+
+	product, err := uc.productRepo.GetByID(productID)
 	if err != nil {
-		return err
+		return nil, apperrors.MapNotFound(err, apperrors.CodeProductNotFound, "product not found")
 	}
 
-	return uc.cartRepo.Clear(cart.ID)
+	variant, err := resolveVariant(product, variantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStock(product, variant, quantity); err != nil {
+		return nil, err
+	}
+
+	if err := uc.cartRepo.UpdateItem(cart.ID, productID, variantID, quantity); err != nil {
+		return nil, err
+	}
+
+	return uc.cartRepo.GetByID(cart.ID)
 }
 
-func (uc *CartUseCase) GetCart(userID uuid.UUID) (*entities.Cart, error) {
-	return uc.cartRepo.GetByUserID(userID)
-}
\ No newline at end of file
+func (uc *CartUseCase) removeItem(cart *entities.Cart, productID uuid.UUID, variantID *uuid.UUID) (*entities.Cart, error) {
+	if err := uc.cartRepo.RemoveItem(cart.ID, productID, variantID); err != nil {
+		return nil, err
+	}
+
+	return uc.cartRepo.GetByID(cart.ID)
+}
@@ -0,0 +1,170 @@
+package usecases
+
+import (
+	"errors"
+	"strings"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/patch"
+	"prototype-fiber/pkg/slug"
+
+	"github.com/google/uuid"
+)
+
+type CategoryUseCase struct {
+	categoryRepo entities.CategoryRepository
+}
+
+// CreateCategoryRequest creates a category. The slug is derived from the
+// name when omitted.
+type CreateCategoryRequest struct {
+	Name     string     `json:"name" validate:"required,max=100"`
+	Slug     string     `json:"slug" validate:"omitempty,max=100"`
+	ParentID *uuid.UUID `json:"parent_id"`
+	Position int        `json:"position" validate:"gte=0"`
+	IsActive *bool      `json:"is_active"`
+}
+
+// UpdateCategoryRequest is the body of PATCH, applied as a JSON Merge Patch.
+// A null parent_id moves the category to the top level.
+type UpdateCategoryRequest struct {
+	Name     *string    `json:"name" validate:"omitnil,min=1,max=100"`
+	Slug     *string    `json:"slug" validate:"omitnil,min=1,max=100"`
+	ParentID *uuid.UUID `json:"parent_id" patch:"nullable"`
+	Position *int       `json:"position" validate:"omitnil,gte=0"`
+	IsActive *bool      `json:"is_active"`
+}
+
+func NewCategoryUseCase(categoryRepo entities.CategoryRepository) *CategoryUseCase {
+	return &CategoryUseCase{categoryRepo: categoryRepo}
+}
+
+// GetTree returns the category tree. Inactive categories, and everything
+// below them, are left out unless includeInactive is set.
+func (uc *CategoryUseCase) GetTree(includeInactive bool) ([]*entities.Category, error) {
+	categories, err := uc.categoryRepo.List()
+	if err != nil {
+		return nil, err
+	}
+	return entities.BuildCategoryTree(categories, includeInactive), nil
+}
+
+func (uc *CategoryUseCase) CreateCategory(req *CreateCategoryRequest) (*entities.Category, error) {
+	category := &entities.Category{
+		Name:     strings.TrimSpace(req.Name),
+		ParentID: req.ParentID,
+		Position: req.Position,
+		IsActive: req.IsActive == nil || *req.IsActive,
+	}
+
+	var err error
+	if category.Slug, err = categorySlug(req.Slug, category.Name); err != nil {
+		return nil, err
+	}
+	if req.ParentID != nil {
+		if _, err := uc.GetCategory(*req.ParentID); err != nil {
+			return nil, apperrors.Validation(apperrors.CodeInvalidCategoryParent, "parent category not found")
+		}
+	}
+
+	if err := uc.categoryRepo.Create(category); err != nil {
+		return nil, categoryConflict(err)
+	}
+	return category, nil
+}
+
+func (uc *CategoryUseCase) GetCategory(id uuid.UUID) (*entities.Category, error) {
+	category, err := uc.categoryRepo.GetByID(id)
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeCategoryNotFound, "category not found")
+	}
+	return category, nil
+}
+
+func (uc *CategoryUseCase) UpdateCategory(id uuid.UUID, req *UpdateCategoryRequest, nulls patch.Nulls) (*entities.Category, error) {
+	category, err := uc.GetCategory(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		category.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Slug != nil {
+		if category.Slug, err = categorySlug(*req.Slug, category.Name); err != nil {
+			return nil, err
+		}
+	}
+	if req.ParentID != nil {
+		if err := uc.checkParent(category.ID, *req.ParentID); err != nil {
+			return nil, err
+		}
+		category.ParentID = req.ParentID
+	} else if nulls.Has("parent_id") {
+		category.ParentID = nil
+	}
+	if req.Position != nil {
+		category.Position = *req.Position
+	}
+	if req.IsActive != nil {
+		category.IsActive = *req.IsActive
+	}
+
+	if err := uc.categoryRepo.Update(category); err != nil {
+		return nil, categoryConflict(err)
+	}
+	return category, nil
+}
+
+// DeleteCategory removes a category and its product assignments. Its
+// children must be moved or deleted first.
+func (uc *CategoryUseCase) DeleteCategory(id uuid.UUID) error {
+	categories, err := uc.categoryRepo.List()
+	if err != nil {
+		return err
+	}
+	for _, category := range categories {
+		if category.ParentID != nil && *category.ParentID == id {
+			return apperrors.Conflict(apperrors.CodeCategoryHasChildren, "category has subcategories")
+		}
+	}
+
+	err = uc.categoryRepo.Delete(id)
+	return apperrors.MapNotFound(err, apperrors.CodeCategoryNotFound, "category not found")
+}
+
+// checkParent rejects moving a category under itself or its descendants.
+func (uc *CategoryUseCase) checkParent(id, parentID uuid.UUID) error {
+	if _, err := uc.GetCategory(parentID); err != nil {
+		return apperrors.Validation(apperrors.CodeInvalidCategoryParent, "parent category not found")
+	}
+	subtree, err := uc.categoryRepo.DescendantIDs([]uuid.UUID{id})
+	if err != nil {
+		return err
+	}
+	for _, descendant := range subtree {
+		if descendant == parentID {
+			return apperrors.Validation(apperrors.CodeInvalidCategoryParent, "a category cannot be moved under itself or its subcategories")
+		}
+	}
+	return nil
+}
+
+func categorySlug(requested, name string) (string, error) {
+	if requested == "" {
+		requested = name
+	}
+	s := slug.Make(requested)
+	if s == "" {
+		return "", apperrors.Validation(apperrors.CodeValidation, "slug must contain letters or digits")
+	}
+	return s, nil
+}
+
+func categoryConflict(err error) error {
+	if errors.Is(err, apperrors.ErrConflict) {
+		return apperrors.Conflict(apperrors.CodeCategoryExists, "a category with this slug already exists")
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+package usecases
+
+import (
+	"time"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/pagination"
+	"prototype-fiber/pkg/tokens"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type ImpersonationUseCase struct {
+	userRepo       entities.UserRepository
+	logRepo        entities.ImpersonationLogRepository
+	sessionUseCase *SessionUseCase
+	keySet         *tokens.KeySet
+	ttl            time.Duration
+}
+
+type ImpersonateRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+type ImpersonationResponse struct {
+	Token     string         `json:"token"`
+	ExpiresAt time.Time      `json:"expires_at"`
+	User      *entities.User `json:"user"`
+}
+
+func NewImpersonationUseCase(userRepo entities.UserRepository, logRepo entities.ImpersonationLogRepository, sessionUseCase *SessionUseCase, keySet *tokens.KeySet, ttl time.Duration) *ImpersonationUseCase {
+	return &ImpersonationUseCase{
+		userRepo:       userRepo,
+		logRepo:        logRepo,
+		sessionUseCase: sessionUseCase,
+		keySet:         keySet,
+		ttl:            ttl,
+	}
+}
+
+// Impersonate mints a short-lived token that acts as the customer and
+// carries the admin's ID in the impersonator_id claim.
+func (uc *ImpersonationUseCase) Impersonate(adminID, userID uuid.UUID, req *ImpersonateRequest, client ClientInfo) (*ImpersonationResponse, error) {
+	if req.Reason == "" {
+		return nil, apperrors.Validation(apperrors.CodeValidation, "a reason is required to impersonate a user")
+	}
+	if adminID == userID {
+		return nil, apperrors.Forbidden(apperrors.CodeForbidden, "cannot impersonate yourself")
+	}
+
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeUserNotFound, "user not found")
+	}
+	if user.Role != entities.RoleCustomer {
+		return nil, apperrors.Forbidden(apperrors.CodeForbidden, "only customers can be impersonated")
+	}
+	if !user.IsActive {
+		return nil, apperrors.Conflict(apperrors.CodeAccountDisabled, "account is deactivated")
+	}
+
+	expiresAt := time.Now().Add(uc.ttl)
+	client.DeviceName = "Support impersonation"
+	session, err := uc.sessionUseCase.StartImpersonation(user.ID, adminID, client, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &tokens.Claims{
+		UserID:         user.ID.String(),
+		Email:          user.Email,
+		Role:           string(user.Role),
+		SessionID:      session.ID.String(),
+		ImpersonatorID: adminID.String(),
+	}
+	claims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+
+	token, err := uc.keySet.Issue(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.logRepo.Create(&entities.ImpersonationLog{
+		ImpersonatorID: adminID,
+		UserID:         user.ID,
+		SessionID:      session.ID,
+		Action:         entities.ImpersonationActionStart,
+		Reason:         req.Reason,
+		IPAddress:      client.IPAddress,
+	}); err != nil {
+		// No audit entry, no impersonation.
+		_ = uc.sessionUseCase.RevokeSession(user.ID, session.ID)
+		return nil, err
+	}
+
+	return &ImpersonationResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		User:      user,
+	}, nil
+}
+
+func (uc *ImpersonationUseCase) RecordRequest(entry *entities.ImpersonationLog) error {
+	entry.Action = entities.ImpersonationActionRequest
+	return uc.logRepo.Create(entry)
+}
+
+// RecordStatus fills in the status code of a request recorded before it ran.
+func (uc *ImpersonationUseCase) RecordStatus(entry *entities.ImpersonationLog, status int) error {
+	entry.StatusCode = status
+	return uc.logRepo.UpdateStatusCode(entry.ID, status)
+}
+
+func (uc *ImpersonationUseCase) ListLogs(userID uuid.UUID, params pagination.Params) (*pagination.Page[*entities.ImpersonationLog], error) {
+	return uc.logRepo.ListByUserID(userID, params)
+}
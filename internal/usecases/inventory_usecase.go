@@ -0,0 +1,50 @@
+package usecases
+
+import (
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+type InventoryUseCase struct {
+	movementRepo entities.StockMovementRepository
+	productRepo  entities.ProductRepository
+}
+
+// StockReconciliation is the result of checking stock against the ledger.
+type StockReconciliation struct {
+	Consistent    bool                         `json:"consistent"`
+	Discrepancies []*entities.StockDiscrepancy `json:"discrepancies"`
+}
+
+func NewInventoryUseCase(movementRepo entities.StockMovementRepository, productRepo entities.ProductRepository) *InventoryUseCase {
+	return &InventoryUseCase{
+		movementRepo: movementRepo,
+		productRepo:  productRepo,
+	}
+}
+
+// ListStockMovements returns the ledger of a product and its variants,
+// newest first.
+func (uc *InventoryUseCase) ListStockMovements(productID uuid.UUID, params pagination.Params) (*pagination.Page[*entities.StockMovement], error) {
+	if _, err := uc.productRepo.GetByID(productID); err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeProductNotFound, "product not found")
+	}
+	return uc.movementRepo.ListByProductID(productID, params)
+}
+
+// Reconcile lists every product and variant whose stock has drifted from
+// the sum of its movements, which means stock was written around the
+// ledger.
+func (uc *InventoryUseCase) Reconcile() (*StockReconciliation, error) {
+	discrepancies, err := uc.movementRepo.Reconcile()
+	if err != nil {
+		return nil, err
+	}
+	return &StockReconciliation{
+		Consistent:    len(discrepancies) == 0,
+		Discrepancies: discrepancies,
+	}, nil
+}
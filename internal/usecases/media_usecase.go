@@ -0,0 +1,363 @@
+package usecases
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"strings"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/imaging"
+	"prototype-fiber/pkg/logger"
+	"prototype-fiber/pkg/patch"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxMediaPerProduct = 20
+	// maxMediaPixels bounds decoding memory: a small file can declare huge
+	// dimensions.
+	maxMediaPixels = 40_000_000
+)
+
+// mediaTypes maps the accepted content types, as sniffed from the file
+// itself, to the extension originals are stored under.
+var mediaTypes = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+type MediaUseCase struct {
+	mediaRepo      entities.ProductMediaRepository
+	productRepo    entities.ProductRepository
+	storage        entities.BlobStorage
+	maxSize        int64
+	thumbnailSizes []int
+	logger         *logger.Logger
+}
+
+// UploadMediaInput is one uploaded file. Size is the size the client
+// declared; the body is still read with a limit.
+type UploadMediaInput struct {
+	Body    io.Reader
+	Size    int64
+	AltText string
+}
+
+// UpdateMediaRequest is the body of PATCH on a media item, applied as a JSON
+// Merge Patch. Only true is accepted for is_primary; to change the primary
+// image, promote another one.
+type UpdateMediaRequest struct {
+	AltText   *string `json:"alt_text" validate:"omitnil,max=255" patch:"nullable"`
+	IsPrimary *bool   `json:"is_primary"`
+}
+
+// ReorderMediaRequest lists every media ID of the product in the new order.
+type ReorderMediaRequest struct {
+	MediaIDs []uuid.UUID `json:"media_ids" validate:"required,min=1"`
+}
+
+// NewMediaUseCase creates the media use case. Blob deletes and image URL
+// updates that fail after the media itself was saved are logged to logger
+// rather than failing the request.
+func NewMediaUseCase(mediaRepo entities.ProductMediaRepository, productRepo entities.ProductRepository, storage entities.BlobStorage, maxSize int64, thumbnailSizes []int, logger *logger.Logger) *MediaUseCase {
+	return &MediaUseCase{
+		mediaRepo:      mediaRepo,
+		productRepo:    productRepo,
+		storage:        storage,
+		maxSize:        maxSize,
+		thumbnailSizes: thumbnailSizes,
+		logger:         logger,
+	}
+}
+
+func (uc *MediaUseCase) ListMedia(productID uuid.UUID) ([]*entities.ProductMedia, error) {
+	if _, err := uc.getProduct(productID); err != nil {
+		return nil, err
+	}
+	return uc.mediaRepo.ListByProductID(productID)
+}
+
+// UploadMedia validates an image, stores it with its thumbnails and adds it
+// to the end of the product's gallery. The first image becomes primary.
+func (uc *MediaUseCase) UploadMedia(productID uuid.UUID, input *UploadMediaInput) (*entities.ProductMedia, error) {
+	product, err := uc.getProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := uc.mediaRepo.ListByProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxMediaPerProduct {
+		return nil, apperrors.Conflict(apperrors.CodeMediaLimitReached, fmt.Sprintf("a product can have at most %d media", maxMediaPerProduct))
+	}
+
+	if input.Size > uc.maxSize {
+		return nil, uc.tooLarge()
+	}
+	data, err := io.ReadAll(io.LimitReader(input.Body, uc.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > uc.maxSize {
+		return nil, uc.tooLarge()
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := mediaTypes[contentType]
+	if !ok {
+		return nil, apperrors.UnsupportedMedia(apperrors.CodeUnsupportedMedia, "only JPEG, PNG and GIF images are accepted")
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, apperrors.UnsupportedMedia(apperrors.CodeUnsupportedMedia, "the file is not a readable image")
+	}
+	if config.Width*config.Height > maxMediaPixels {
+		return nil, apperrors.TooLarge(apperrors.CodeMediaTooLarge, "image dimensions are too large")
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, apperrors.UnsupportedMedia(apperrors.CodeUnsupportedMedia, "the file is not a readable image")
+	}
+
+	media := &entities.ProductMedia{
+		ID:          uuid.New(),
+		ProductID:   productID,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Width:       config.Width,
+		Height:      config.Height,
+		AltText:     strings.TrimSpace(input.AltText),
+		Position:    nextMediaPosition(existing),
+		IsPrimary:   len(existing) == 0,
+	}
+
+	var stored []string
+	cleanup := func() {
+		for _, key := range stored {
+			uc.deleteBlob(key)
+		}
+	}
+
+	media.Key = mediaKey(productID, media.ID, "original."+ext)
+	if err := uc.storage.Put(media.Key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return nil, err
+	}
+	stored = append(stored, media.Key)
+	media.URL = uc.storage.URL(media.Key)
+
+	thumbnails, keys, err := uc.storeThumbnails(media, img, contentType)
+	stored = append(stored, keys...)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	media.Thumbnails = thumbnails
+
+	if err := uc.mediaRepo.Create(media); err != nil {
+		cleanup()
+		return nil, err
+	}
+	if media.IsPrimary {
+		uc.syncProductImage(product, media.URL)
+	}
+	return media, nil
+}
+
+func (uc *MediaUseCase) UpdateMedia(productID, mediaID uuid.UUID, req *UpdateMediaRequest, nulls patch.Nulls) (*entities.ProductMedia, error) {
+	media, err := uc.getMedia(productID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if req.IsPrimary != nil && !*req.IsPrimary {
+		return nil, apperrors.Validation(apperrors.CodeValidation, "is_primary can only be set to true; promote another image instead")
+	}
+
+	if req.AltText != nil {
+		media.AltText = strings.TrimSpace(*req.AltText)
+	} else if nulls.Has("alt_text") {
+		media.AltText = ""
+	}
+	if err := uc.mediaRepo.Update(media); err != nil {
+		return nil, err
+	}
+
+	if req.IsPrimary != nil && !media.IsPrimary {
+		if err := uc.mediaRepo.SetPrimary(productID, media.ID); err != nil {
+			return nil, err
+		}
+		media.IsPrimary = true
+		if product, err := uc.getProduct(productID); err == nil {
+			uc.syncProductImage(product, media.URL)
+		}
+	}
+	return media, nil
+}
+
+// ReorderMedia puts the product's media in the given order. Every media ID
+// must be listed exactly once.
+func (uc *MediaUseCase) ReorderMedia(productID uuid.UUID, req *ReorderMediaRequest) ([]*entities.ProductMedia, error) {
+	existing, err := uc.ListMedia(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := make(map[uuid.UUID]bool, len(existing))
+	for _, media := range existing {
+		remaining[media.ID] = true
+	}
+	for _, id := range req.MediaIDs {
+		if !remaining[id] {
+			return nil, apperrors.Validation(apperrors.CodeInvalidMediaOrder, "unknown or repeated media ID: "+id.String())
+		}
+		delete(remaining, id)
+	}
+	if len(remaining) > 0 {
+		return nil, apperrors.Validation(apperrors.CodeInvalidMediaOrder, "every media of the product must be listed")
+	}
+
+	if err := uc.mediaRepo.Reorder(productID, req.MediaIDs); err != nil {
+		return nil, err
+	}
+	return uc.mediaRepo.ListByProductID(productID)
+}
+
+// DeleteMedia removes the media and its blobs. When it was primary, the
+// next image in the gallery takes its place.
+func (uc *MediaUseCase) DeleteMedia(productID, mediaID uuid.UUID) error {
+	media, err := uc.getMedia(productID, mediaID)
+	if err != nil {
+		return err
+	}
+	if err := uc.mediaRepo.Delete(media.ID); err != nil {
+		return err
+	}
+
+	for _, thumbnail := range media.Thumbnails {
+		uc.deleteBlob(thumbnail.Key)
+	}
+	uc.deleteBlob(media.Key)
+
+	if !media.IsPrimary {
+		return nil
+	}
+	product, err := uc.getProduct(productID)
+	if err != nil {
+		return err
+	}
+	remaining, err := uc.mediaRepo.ListByProductID(productID)
+	if err != nil {
+		return err
+	}
+	if len(remaining) == 0 {
+		uc.syncProductImage(product, "")
+		return nil
+	}
+	if err := uc.mediaRepo.SetPrimary(productID, remaining[0].ID); err != nil {
+		return err
+	}
+	uc.syncProductImage(product, remaining[0].URL)
+	return nil
+}
+
+// storeThumbnails writes one thumbnail per configured size, returning the
+// keys written so far even on failure. PNG and GIF sources keep their
+// transparency as PNG; everything else becomes JPEG.
+func (uc *MediaUseCase) storeThumbnails(media *entities.ProductMedia, img image.Image, contentType string) ([]entities.MediaThumbnail, []string, error) {
+	format, thumbType := "jpeg", "image/jpeg"
+	ext := "jpg"
+	if contentType != "image/jpeg" {
+		format, thumbType, ext = "png", "image/png", "png"
+	}
+
+	var thumbnails []entities.MediaThumbnail
+	var keys []string
+	for _, size := range uc.thumbnailSizes {
+		thumb := imaging.Fit(img, size)
+		var buf bytes.Buffer
+		if err := imaging.Encode(&buf, thumb, format); err != nil {
+			return nil, keys, err
+		}
+
+		key := mediaKey(media.ProductID, media.ID, fmt.Sprintf("%d.%s", size, ext))
+		if err := uc.storage.Put(key, bytes.NewReader(buf.Bytes()), int64(buf.Len()), thumbType); err != nil {
+			return nil, keys, err
+		}
+		keys = append(keys, key)
+
+		bounds := thumb.Bounds()
+		thumbnails = append(thumbnails, entities.MediaThumbnail{
+			MediaID: media.ID,
+			Size:    size,
+			Key:     key,
+			URL:     uc.storage.URL(key),
+			Width:   bounds.Dx(),
+			Height:  bounds.Dy(),
+		})
+	}
+	return thumbnails, keys, nil
+}
+
+// syncProductImage mirrors the primary image into Product.ImageURL. A
+// failure leaves the old URL, which the next primary change corrects.
+func (uc *MediaUseCase) syncProductImage(product *entities.Product, url string) {
+	if product.ImageURL == url {
+		return
+	}
+	if err := uc.productRepo.UpdateImageURL(product.ID, url); err != nil {
+		uc.logger.Errorf("Updating the image URL of product %s: %v", product.ID, err)
+		return
+	}
+	product.ImageURL = url
+}
+
+// deleteBlob removes a stored blob. One left behind by a failed delete is
+// unreachable but harmless, so the failure is only logged.
+func (uc *MediaUseCase) deleteBlob(key string) {
+	if err := uc.storage.Delete(key); err != nil {
+		uc.logger.Errorf("Deleting media blob %s: %v", key, err)
+	}
+}
+
+func (uc *MediaUseCase) getProduct(productID uuid.UUID) (*entities.Product, error) {
+	product, err := uc.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeProductNotFound, "product not found")
+	}
+	return product, nil
+}
+
+func (uc *MediaUseCase) getMedia(productID, mediaID uuid.UUID) (*entities.ProductMedia, error) {
+	media, err := uc.mediaRepo.GetByID(mediaID)
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeMediaNotFound, "media not found")
+	}
+	if media.ProductID != productID {
+		return nil, apperrors.NotFound(apperrors.CodeMediaNotFound, "media not found")
+	}
+	return media, nil
+}
+
+func (uc *MediaUseCase) tooLarge() error {
+	return apperrors.TooLarge(apperrors.CodeMediaTooLarge, fmt.Sprintf("the file exceeds the upload limit of %d bytes", uc.maxSize))
+}
+
+func nextMediaPosition(existing []*entities.ProductMedia) int {
+	position := 0
+	for _, media := range existing {
+		if media.Position >= position {
+			position = media.Position + 1
+		}
+	}
+	return position
+}
+
+func mediaKey(productID, mediaID uuid.UUID, name string) string {
+	return "products/" + productID.String() + "/" + mediaID.String() + "/" + name
+}
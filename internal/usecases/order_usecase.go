@@ -2,69 +2,106 @@ package usecases
 
 import (
 	"errors"
+	"fmt"
 
+	"prototype-fiber/internal/domain/apperrors"
 	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/pagination"
 
 	"github.com/google/uuid"
 )
 
 type OrderUseCase struct {
-	orderRepo   entities.OrderRepository
-	cartRepo    entities.CartRepository
-	productRepo entities.ProductRepository
+	orderRepo     entities.OrderRepository
+	cartRepo      entities.CartRepository
+	productRepo   entities.ProductRepository
+	warehouseRepo entities.WarehouseRepository
+	allocation    AllocationPolicy
 }
 
 type CreateOrderRequest struct {
-	ShippingAddress string `json:"shipping_address" validate:"required"`
-	BillingAddress  string `json:"billing_address" validate:"required"`
+	ShippingAddress string `json:"shipping_address" validate:"required,max=500"`
+	ShippingCountry string `json:"shipping_country" validate:"omitempty,country"`
+	BillingAddress  string `json:"billing_address" validate:"required,max=500"`
 }
 
-func NewOrderUseCase(orderRepo entities.OrderRepository, cartRepo entities.CartRepository, productRepo entities.ProductRepository) *OrderUseCase {
+func NewOrderUseCase(orderRepo entities.OrderRepository, cartRepo entities.CartRepository, productRepo entities.ProductRepository, warehouseRepo entities.WarehouseRepository, allocation AllocationPolicy) *OrderUseCase {
 	return &OrderUseCase{
-		orderRepo:   orderRepo,
-		cartRepo:    cartRepo,
-		productRepo: productRepo,
+		orderRepo:     orderRepo,
+		cartRepo:      cartRepo,
+		productRepo:   productRepo,
+		warehouseRepo: warehouseRepo,
+		allocation:    allocation,
 	}
 }
 
 func (uc *OrderUseCase) CreateOrder(userID uuid.UUID, req *CreateOrderRequest) (*entities.Order, error) {
 	cart, err := uc.cartRepo.GetByUserID(userID)
 	if err != nil {
-		return nil, errors.New("cart not found")
+		return nil, apperrors.MapNotFound(err, apperrors.CodeCartNotFound, "cart not found")
 	}
 
 	if len(cart.Items) == 0 {
-		return nil, errors.New("cart is empty")
+		return nil, apperrors.Validation(apperrors.CodeCartEmpty, "cart is empty")
+	}
+
+	// Orders are charged at today's prices. When revalidation changes the
+	// cart the order is refused with the changes, which are saved, so the
+	// shopper can review them and place it again.
+	if err := revalidateCart(uc.cartRepo, uc.productRepo, cart); err != nil {
+		return nil, err
+	}
+	if len(cart.Changes) > 0 {
+		return nil, apperrors.Conflict(apperrors.CodeCartChanged, "cart has changed, review it before placing the order").
+			WithDetails(cart.Changes)
 	}
 
 	// Check stock availability for all items
-	for _, item := range cart.Items {
+	lines := make([]allocationLine, len(cart.Items))
+	snapshots := make([]entities.OrderItem, len(cart.Items))
+	for i, item := range cart.Items {
 		product, err := uc.productRepo.GetByID(item.ProductID)
 		if err != nil {
-			return nil, errors.New("product not found")
+			return nil, apperrors.MapNotFound(err, apperrors.CodeProductNotFound, "product not found")
+		}
+		variant, err := resolveVariant(product, item.VariantID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkStock(product, variant, item.Quantity); err != nil {
+			return nil, err
 		}
-		if !product.CanFulfillQuantity(item.Quantity) {
-			return nil, errors.New("insufficient stock for product: " + product.Name)
+		lines[i] = allocationLine{productID: product.ID, variantID: item.VariantID, quantity: item.Quantity, name: product.Name}
+		if variant != nil {
+			lines[i].name += " (" + product.VariantLabel(variant) + ")"
 		}
+		snapshots[i] = snapshotItem(product, variant)
+	}
+
+	allocations, err := uc.allocate(lines, req.ShippingCountry)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create order
 	order := &entities.Order{
-		UserID:       userID,
-		Status:       entities.OrderStatusPending,
-		ShippingAddr: req.ShippingAddress,
-		BillingAddr:  req.BillingAddress,
+		UserID:          userID,
+		Status:          entities.OrderStatusPending,
+		ShippingAddr:    req.ShippingAddress,
+		ShippingCountry: req.ShippingCountry,
+		BillingAddr:     req.BillingAddress,
 	}
 
 	// Convert cart items to order items
 	var orderItems []entities.OrderItem
 	total := 0.0
-	for _, cartItem := range cart.Items {
-		orderItem := entities.OrderItem{
-			ProductID: cartItem.ProductID,
-			Quantity:  cartItem.Quantity,
-			Price:     cartItem.Price,
-		}
+	for i, cartItem := range cart.Items {
+		orderItem := snapshots[i]
+		orderItem.ProductID = cartItem.ProductID
+		orderItem.VariantID = cartItem.VariantID
+		orderItem.Quantity = cartItem.Quantity
+		orderItem.Price = cartItem.Price
+		orderItem.Allocations = allocations[i]
 		orderItems = append(orderItems, orderItem)
 		total += cartItem.GetSubtotal()
 	}
@@ -72,41 +109,51 @@ func (uc *OrderUseCase) CreateOrder(userID uuid.UUID, req *CreateOrderRequest) (
 	order.Items = orderItems
 	order.Total = total
 
-	if err := uc.orderRepo.Create(order); err != nil {
+	// The order and the sales at its allocated warehouses are written
+	// together; stock taken by another order since the allocation refuses
+	// the whole order.
+	if err := uc.productRepo.RecordOrder(order); err != nil {
 		return nil, err
 	}
 
-	// Update product stock
-	for _, item := range cart.Items {
-		if err := uc.productRepo.UpdateStock(item.ProductID, -item.Quantity); err != nil {
-			// Rollback order creation if stock update fails
-			return nil, errors.New("failed to update stock")
-		}
+	if err := uc.cartRepo.Clear(cart.ID); err != nil {
+		return nil, fmt.Errorf("order %s was placed but its cart could not be cleared: %w", order.ID, err)
 	}
 
-	// Clear cart after successful order
-	uc.cartRepo.Clear(cart.ID)
-
 	return order, nil
 }
 
 func (uc *OrderUseCase) GetOrder(id uuid.UUID) (*entities.Order, error) {
-	return uc.orderRepo.GetByID(id)
+	order, err := uc.orderRepo.GetByID(id)
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeOrderNotFound, "order not found")
+	}
+	return order, nil
 }
 
-func (uc *OrderUseCase) GetUserOrders(userID uuid.UUID, offset, limit int) ([]*entities.Order, error) {
-	return uc.orderRepo.GetByUserID(userID, offset, limit)
+func (uc *OrderUseCase) GetUserOrders(userID uuid.UUID, params pagination.Params) (*pagination.Page[*entities.Order], error) {
+	return uc.orderRepo.GetByUserID(userID, params)
 }
 
-func (uc *OrderUseCase) UpdateOrderStatus(id uuid.UUID, status entities.OrderStatus) error {
+// UpdateOrderStatus moves an order on as actorID, returning its items to
+// stock when it is cancelled or refunded.
+func (uc *OrderUseCase) UpdateOrderStatus(id uuid.UUID, status entities.OrderStatus, actorID uuid.UUID) error {
 	order, err := uc.orderRepo.GetByID(id)
 	if err != nil {
-		return err
+		return apperrors.MapNotFound(err, apperrors.CodeOrderNotFound, "order not found")
 	}
 
 	// Validate status transition
 	if !uc.isValidStatusTransition(order.Status, status) {
-		return errors.New("invalid status transition")
+		return apperrors.Conflict(apperrors.CodeInvalidStatusTransition,
+			fmt.Sprintf("cannot change order status from %s to %s", order.Status, status))
+	}
+
+	switch status {
+	case entities.OrderStatusCancelled:
+		return uc.restock(order, status, entities.StockReasonCancel, actorID)
+	case entities.OrderStatusRefunded:
+		return uc.restock(order, status, entities.StockReasonRefund, actorID)
 	}
 
 	return uc.orderRepo.UpdateStatus(id, status)
@@ -115,29 +162,127 @@ func (uc *OrderUseCase) UpdateOrderStatus(id uuid.UUID, status entities.OrderSta
 func (uc *OrderUseCase) CancelOrder(userID, orderID uuid.UUID) error {
 	order, err := uc.orderRepo.GetByID(orderID)
 	if err != nil {
-		return err
+		return apperrors.MapNotFound(err, apperrors.CodeOrderNotFound, "order not found")
 	}
 
 	if order.UserID != userID {
-		return errors.New("unauthorized")
+		return apperrors.Forbidden(apperrors.CodeForbidden, "order belongs to another user")
 	}
 
 	if !order.CanBeCancelled() {
-		return errors.New("order cannot be cancelled")
+		return apperrors.Conflict(apperrors.CodeOrderNotCancellable, "order cannot be cancelled")
 	}
 
-	// Restore stock
+	return uc.restock(order, entities.OrderStatusCancelled, entities.StockReasonCancel, userID)
+}
+
+// snapshotItem copies onto an order item what it must keep of the product
+// and variant being bought: names, SKU, the variant's options in option
+// order, and its image, falling back to the product's.
+func snapshotItem(product *entities.Product, variant *entities.ProductVariant) entities.OrderItem {
+	item := entities.OrderItem{
+		ProductName: product.Name,
+		SKU:         product.SKU,
+		ImageURL:    product.ImageURL,
+	}
+	if variant == nil {
+		return item
+	}
+
+	item.SKU = variant.SKU
+	if variant.ImageURL != "" {
+		item.ImageURL = variant.ImageURL
+	}
+	for _, option := range product.Options {
+		for _, value := range variant.OptionValues {
+			if value.OptionID == option.ID {
+				item.VariantOptions = append(item.VariantOptions, entities.OrderItemOption{Name: option.Name, Value: value.Value})
+			}
+		}
+	}
+	return item
+}
+
+// allocate loads the stock of the ordered products at every warehouse and
+// applies the allocation policy.
+func (uc *OrderUseCase) allocate(lines []allocationLine, country string) ([][]entities.OrderItemAllocation, error) {
+	warehouses, err := uc.warehouseRepo.List()
+	if err != nil {
+		return nil, err
+	}
+	productIDs := make([]uuid.UUID, len(lines))
+	for i, line := range lines {
+		productIDs[i] = line.productID
+	}
+	levels, err := uc.warehouseRepo.ListStockLevels(productIDs)
+	if err != nil {
+		return nil, err
+	}
+	return uc.allocation.allocate(lines, warehouses, levels, country)
+}
+
+// restock moves the order to status and reverses the sale of its items,
+// returning stock to the warehouses it was allocated from. Items without
+// allocations go back to the default warehouse, and items deleted from the
+// catalogue since are only recorded in the ledger.
+func (uc *OrderUseCase) restock(order *entities.Order, status entities.OrderStatus, reason entities.StockMovementReason, actorID uuid.UUID) error {
+	var returns []entities.StockReturn
 	for _, item := range order.Items {
-		if err := uc.productRepo.UpdateStock(item.ProductID, item.Quantity); err != nil {
-			return errors.New("failed to restore stock")
+		stocked, err := uc.stillStocked(item)
+		if err != nil {
+			return fmt.Errorf("failed to restore stock: %w", err)
+		}
+		if !stocked {
+			returns = append(returns, entities.StockReturn{
+				ProductID:  item.ProductID,
+				VariantID:  item.VariantID,
+				Quantity:   item.Quantity,
+				Change:     entities.StockChange{Reason: reason, OrderID: &order.ID, UserID: &actorID},
+				Unreturned: true,
+			})
+			continue
+		}
+
+		allocations := item.Allocations
+		if len(allocations) == 0 {
+			allocations = []entities.OrderItemAllocation{{Quantity: item.Quantity}}
+		}
+		for _, allocation := range allocations {
+			change := entities.StockChange{Reason: reason, OrderID: &order.ID, UserID: &actorID}
+			if allocation.WarehouseID != uuid.Nil {
+				change.WarehouseID = &allocation.WarehouseID
+			}
+			returns = append(returns, entities.StockReturn{
+				ProductID: item.ProductID,
+				VariantID: item.VariantID,
+				Quantity:  allocation.Quantity,
+				Change:    change,
+			})
 		}
 	}
 
-	return uc.orderRepo.UpdateStatus(orderID, entities.OrderStatusCancelled)
+	err := uc.productRepo.ReturnOrder(order, status, returns)
+	if errors.Is(err, apperrors.ErrConflict) {
+		return apperrors.Conflict(apperrors.CodeInvalidStatusTransition, "order status has changed, reload the order and try again")
+	}
+	return err
+}
+
+// stillStocked reports whether the item's product, and its variant if it
+// has one, are still in the catalogue to take stock back.
+func (uc *OrderUseCase) stillStocked(item entities.OrderItem) (bool, error) {
+	product, err := uc.productRepo.GetByID(item.ProductID)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return item.VariantID == nil || product.Variant(*item.VariantID) != nil, nil
 }
 
-func (uc *OrderUseCase) ListOrders(offset, limit int) ([]*entities.Order, error) {
-	return uc.orderRepo.List(offset, limit)
+func (uc *OrderUseCase) ListOrders(params pagination.Params) (*pagination.Page[*entities.Order], error) {
+	return uc.orderRepo.List(params)
 }
 
 func (uc *OrderUseCase) isValidStatusTransition(current, new entities.OrderStatus) bool {
@@ -162,4 +307,4 @@ func (uc *OrderUseCase) isValidStatusTransition(current, new entities.OrderStatu
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
@@ -0,0 +1,350 @@
+package usecases
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+)
+
+const (
+	ImportFormatCSV  = "csv"
+	ImportFormatJSON = "json"
+)
+
+// categorySeparator joins category slugs in a CSV cell.
+const categorySeparator = "|"
+
+// productColumns are the CSV columns, in export order.
+var productColumns = []string{"sku", "name", "description", "price", "stock", "categories", "image_url", "is_active"}
+
+// ProductImportRow is one product in an import or export file. Only sku is
+// required: nil fields leave an existing product unchanged, so a file may
+// carry just the columns it means to update. New products need a name and a
+// price.
+type ProductImportRow struct {
+	SKU         string    `json:"sku" validate:"required,sku"`
+	Name        *string   `json:"name" validate:"omitnil,min=1,max=255"`
+	Description *string   `json:"description" validate:"omitnil,max=5000"`
+	Price       *float64  `json:"price" validate:"omitnil,gt=0"`
+	Stock       *int      `json:"stock" validate:"omitnil,gte=0"`
+	Categories  *[]string `json:"categories" validate:"omitnil,max=20,dive,max=100"`
+	ImageURL    *string   `json:"image_url" validate:"omitnil,url"`
+	IsActive    *bool     `json:"is_active"`
+}
+
+// ParseImportFormat accepts "csv" or "json", falling back to the extension
+// of filename when format is empty.
+func ParseImportFormat(format, filename string) (string, error) {
+	if format == "" {
+		if i := strings.LastIndex(filename, "."); i >= 0 {
+			format = filename[i+1:]
+		}
+	}
+	switch strings.ToLower(format) {
+	case ImportFormatCSV:
+		return ImportFormatCSV, nil
+	case ImportFormatJSON:
+		return ImportFormatJSON, nil
+	default:
+		return "", apperrors.Validation(apperrors.CodeInvalidImportFile, "format must be csv or json")
+	}
+}
+
+// rowError is a problem confined to one row; reading carries on with the
+// next one. Any other error from Next ends the import.
+type rowError struct {
+	field   string
+	message string
+}
+
+func (e *rowError) Error() string {
+	return e.message
+}
+
+// productRowReader reads import rows one at a time, returning io.EOF after
+// the last.
+type productRowReader interface {
+	Next() (*ProductImportRow, error)
+}
+
+// newProductRowReader checks the start of the file (the CSV header or the
+// opening bracket of the JSON array) before any row is read.
+func newProductRowReader(format string, r io.Reader) (productRowReader, error) {
+	if format == ImportFormatJSON {
+		return newJSONRowReader(r)
+	}
+	return newCSVRowReader(r)
+}
+
+type csvRowReader struct {
+	reader  *csv.Reader
+	columns []string
+}
+
+func newCSVRowReader(r io.Reader) (*csvRowReader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, readFailure(err, "the file must start with a header row")
+	}
+
+	known := make(map[string]bool, len(productColumns))
+	for _, column := range productColumns {
+		known[column] = true
+	}
+	seen := make(map[string]bool, len(header))
+	columns := make([]string, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !known[name] {
+			return nil, invalidImportFile("unknown column: " + name)
+		}
+		if seen[name] {
+			return nil, invalidImportFile("duplicate column: " + name)
+		}
+		seen[name] = true
+		columns[i] = name
+	}
+	if !seen["sku"] {
+		return nil, invalidImportFile("the sku column is required")
+	}
+
+	// Rows are checked against the header length by hand so a short row is a
+	// row error rather than the end of the import.
+	reader.FieldsPerRecord = -1
+	return &csvRowReader{reader: reader, columns: columns}, nil
+}
+
+// Next maps a record onto a row. Text cells are taken as they are, so an
+// empty description clears it; an empty price, stock or is_active cell
+// leaves the value unchanged.
+func (r *csvRowReader) Next() (*ProductImportRow, error) {
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return nil, err
+	}
+	if err != nil {
+		return nil, readFailure(err, "malformed CSV")
+	}
+
+	row := &ProductImportRow{}
+	if len(record) != len(r.columns) {
+		return row, &rowError{message: fmt.Sprintf("expected %d cells, found %d", len(r.columns), len(record))}
+	}
+
+	for i, column := range r.columns {
+		value := strings.TrimSpace(record[i])
+		switch column {
+		case "sku":
+			row.SKU = value
+		case "name":
+			row.Name = &value
+		case "description":
+			row.Description = &value
+		case "image_url":
+			row.ImageURL = &value
+		case "categories":
+			slugs := []string{}
+			for _, slug := range strings.Split(value, categorySeparator) {
+				if slug = strings.ToLower(strings.TrimSpace(slug)); slug != "" {
+					slugs = append(slugs, slug)
+				}
+			}
+			row.Categories = &slugs
+		case "price":
+			if value == "" {
+				continue
+			}
+			price, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return row, &rowError{field: column, message: "price must be a number"}
+			}
+			row.Price = &price
+		case "stock":
+			if value == "" {
+				continue
+			}
+			stock, err := strconv.Atoi(value)
+			if err != nil {
+				return row, &rowError{field: column, message: "stock must be a whole number"}
+			}
+			row.Stock = &stock
+		case "is_active":
+			if value == "" {
+				continue
+			}
+			active, err := strconv.ParseBool(value)
+			if err != nil {
+				return row, &rowError{field: column, message: "is_active must be true or false"}
+			}
+			row.IsActive = &active
+		}
+	}
+	return row, nil
+}
+
+// jsonRowReader streams the elements of a JSON array of row objects.
+type jsonRowReader struct {
+	decoder *json.Decoder
+}
+
+func newJSONRowReader(r io.Reader) (*jsonRowReader, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, readFailure(err, "the file must contain a JSON array of products")
+	}
+	if token != json.Delim('[') {
+		return nil, invalidImportFile("the file must contain a JSON array of products")
+	}
+	return &jsonRowReader{decoder: decoder}, nil
+}
+
+func (r *jsonRowReader) Next() (*ProductImportRow, error) {
+	if !r.decoder.More() {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return nil, readFailure(err, "the JSON array is not terminated")
+		}
+		if token != json.Delim(']') {
+			return nil, invalidImportFile("the JSON array is not terminated")
+		}
+		return nil, io.EOF
+	}
+
+	row := &ProductImportRow{}
+	err := r.decoder.Decode(row)
+	if err == nil {
+		if row.Categories != nil {
+			for i, slug := range *row.Categories {
+				(*row.Categories)[i] = strings.ToLower(strings.TrimSpace(slug))
+			}
+		}
+		return row, nil
+	}
+
+	// The decoder has consumed the whole element for type and unknown-field
+	// errors, so the next row can still be read.
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return row, &rowError{field: typeErr.Field, message: typeErr.Field + " has the wrong type"}
+	}
+	if strings.HasPrefix(err.Error(), "json: unknown field ") {
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return row, &rowError{field: field, message: "unknown field: " + field}
+	}
+	return nil, readFailure(err, "malformed JSON")
+}
+
+func invalidImportFile(message string) error {
+	return apperrors.Validation(apperrors.CodeInvalidImportFile, message)
+}
+
+// readFailure reports a failed read as a problem with the file, unless the
+// reader itself gave up with an application error such as a size limit.
+func readFailure(err error, message string) error {
+	var appErr *apperrors.Error
+	if errors.As(err, &appErr) {
+		return err
+	}
+	return invalidImportFile(message + ": " + err.Error())
+}
+
+// exportRow is the file form of a product, with every column set.
+func exportRow(product *entities.Product) *ProductImportRow {
+	slugs := make([]string, len(product.Categories))
+	for i, category := range product.Categories {
+		slugs[i] = category.Slug
+	}
+	return &ProductImportRow{
+		SKU:         product.SKU,
+		Name:        &product.Name,
+		Description: &product.Description,
+		Price:       &product.Price,
+		Stock:       &product.Stock,
+		Categories:  &slugs,
+		ImageURL:    &product.ImageURL,
+		IsActive:    &product.IsActive,
+	}
+}
+
+// productRowWriter writes export rows; Close finishes the file.
+type productRowWriter interface {
+	Write(row *ProductImportRow) error
+	Close() error
+}
+
+func newProductRowWriter(format string, w io.Writer) (productRowWriter, error) {
+	if format == ImportFormatJSON {
+		if _, err := io.WriteString(w, "["); err != nil {
+			return nil, err
+		}
+		return &jsonRowWriter{w: w}, nil
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(productColumns); err != nil {
+		return nil, err
+	}
+	return &csvRowWriter{writer: writer}, nil
+}
+
+type csvRowWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvRowWriter) Write(row *ProductImportRow) error {
+	return w.writer.Write([]string{
+		row.SKU,
+		*row.Name,
+		*row.Description,
+		strconv.FormatFloat(*row.Price, 'f', -1, 64),
+		strconv.Itoa(*row.Stock),
+		strings.Join(*row.Categories, categorySeparator),
+		*row.ImageURL,
+		strconv.FormatBool(*row.IsActive),
+	})
+}
+
+func (w *csvRowWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type jsonRowWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (w *jsonRowWriter) Write(row *ProductImportRow) error {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	separator := "\n"
+	if w.written {
+		separator = ",\n"
+	}
+	w.written = true
+	if _, err := io.WriteString(w.w, separator); err != nil {
+		return err
+	}
+	_, err = w.w.Write(data)
+	return err
+}
+
+func (w *jsonRowWriter) Close() error {
+	_, err := io.WriteString(w.w, "\n]\n")
+	return err
+}
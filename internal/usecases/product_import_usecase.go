@@ -0,0 +1,543 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/logger"
+	"prototype-fiber/pkg/pagination"
+	"prototype-fiber/pkg/validation"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// importBatchSize is how many rows a background import processes between
+	// progress updates.
+	importBatchSize = 100
+	// maxReportedErrors bounds the row errors kept per import; rows past it
+	// are still counted as failed.
+	maxReportedErrors = 1000
+
+	exportBatchSize = 500
+)
+
+type importAction int
+
+const (
+	importCreated importAction = iota
+	importUpdated
+	importSkipped
+	importFailed
+)
+
+// ProductImportUseCase upserts products from CSV or JSON files by SKU and
+// exports the catalogue in the same formats. Writes go through
+// ProductUseCase, so imported rows obey the same rules as the admin API.
+type ProductImportUseCase struct {
+	products *ProductUseCase
+	jobRepo  entities.ImportJobRepository
+	validate *validation.Validator
+	maxSize  int64
+	logger   *logger.Logger
+}
+
+// NewProductImportUseCase creates the import use case. Files larger than
+// maxSize bytes are rejected; zero allows any size. Import jobs run in the
+// background with nobody to return an error to, so they log to logger.
+func NewProductImportUseCase(products *ProductUseCase, jobRepo entities.ImportJobRepository, maxSize int64, logger *logger.Logger) *ProductImportUseCase {
+	return &ProductImportUseCase{
+		products: products,
+		jobRepo:  jobRepo,
+		validate: validation.New(),
+		maxSize:  maxSize,
+		logger:   logger,
+	}
+}
+
+// RunImport imports the file synchronously and returns the report. A dry
+// run validates every row and reports what would be created, updated or
+// skipped without writing anything.
+func (uc *ProductImportUseCase) RunImport(format string, r io.Reader, dryRun bool) (*entities.ImportReport, error) {
+	rows, err := newProductRowReader(format, uc.limit(r))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &entities.ImportReport{Errors: []entities.ImportRowError{}}
+	err = uc.process(rows, dryRun, uuid.Nil, report, func(rowErrors []entities.ImportRowError) error {
+		report.Errors = append(report.Errors, rowErrors...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// StartImport copies the file aside and imports it in the background,
+// returning the pending job to poll. A file whose header is unusable is
+// rejected before a job is created.
+func (uc *ProductImportUseCase) StartImport(format string, r io.Reader, userID *uuid.UUID) (*entities.ImportJob, error) {
+	file, err := os.CreateTemp("", "product-import-*")
+	if err != nil {
+		return nil, err
+	}
+	cleanup := func() {
+		file.Close()
+		os.Remove(file.Name())
+	}
+
+	if _, err := io.Copy(file, uc.limit(r)); err != nil {
+		cleanup()
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, err
+	}
+	rows, err := newProductRowReader(format, file)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	job := &entities.ImportJob{
+		Format:    format,
+		Status:    entities.ImportStatusPending,
+		CreatedBy: userID,
+	}
+	if err := uc.jobRepo.Create(job); err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	go func() {
+		defer cleanup()
+		defer uc.recoverJob(job)
+		uc.runJob(job, rows)
+	}()
+
+	return job, nil
+}
+
+// limit fails reads past the size limit, so an oversized file is rejected
+// however it arrives.
+func (uc *ProductImportUseCase) limit(r io.Reader) io.Reader {
+	if uc.maxSize <= 0 {
+		return r
+	}
+	return &sizeLimitedReader{r: r, remaining: uc.maxSize}
+}
+
+type sizeLimitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *sizeLimitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, apperrors.TooLarge(apperrors.CodeImportTooLarge, "the import file is too large")
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return 0, apperrors.TooLarge(apperrors.CodeImportTooLarge, "the import file is too large")
+	}
+	return n, err
+}
+
+// recoverJob marks the job failed if the import panics, rather than taking
+// the server down and leaving the job running.
+func (uc *ProductImportUseCase) recoverJob(job *entities.ImportJob) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	uc.finishJob(job, fmt.Errorf("import panicked: %v", r))
+}
+
+// FailInterruptedJobs marks jobs left unfinished by a previous run of the
+// server as failed. Imports run in-process, so none of them can still be
+// making progress when the server starts.
+func (uc *ProductImportUseCase) FailInterruptedJobs() (int64, error) {
+	return uc.jobRepo.FailUnfinished("the import was interrupted by a restart")
+}
+
+func (uc *ProductImportUseCase) GetImportJob(id uuid.UUID) (*entities.ImportJob, error) {
+	job, err := uc.jobRepo.GetByID(id)
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeImportJobNotFound, "import job not found")
+	}
+	return job, nil
+}
+
+// runJob works through the rows, saving progress after every batch. The
+// job is marked failed if the file turns out to be malformed part-way, with
+// the rows before that point already imported.
+func (uc *ProductImportUseCase) runJob(job *entities.ImportJob, rows productRowReader) {
+	started := time.Now()
+	job.Status = entities.ImportStatusRunning
+	job.StartedAt = &started
+	uc.saveJob(job)
+
+	actorID := uuid.Nil
+	if job.CreatedBy != nil {
+		actorID = *job.CreatedBy
+	}
+	err := uc.process(rows, false, actorID, &job.ImportReport, func(rowErrors []entities.ImportRowError) error {
+		if err := uc.jobRepo.AddErrors(job.ID, rowErrors); err != nil {
+			return err
+		}
+		return uc.jobRepo.Update(job)
+	})
+
+	uc.finishJob(job, err)
+}
+
+// finishJob marks the job completed, or failed with err. The cause of an
+// internal failure is logged, since the job only tells the admin that one
+// happened.
+func (uc *ProductImportUseCase) finishJob(job *entities.ImportJob, err error) {
+	finished := time.Now()
+	job.FinishedAt = &finished
+	job.Status = entities.ImportStatusCompleted
+	if err != nil {
+		job.Status = entities.ImportStatusFailed
+		job.Error = importFailure(err)
+		if !isImportProblem(err) {
+			uc.logger.Errorf("Import job %s failed: %v", job.ID, err)
+		}
+	}
+	uc.saveJob(job)
+}
+
+// saveJob stores the job's progress. A job that cannot be saved carries on;
+// the next save or the restart sweep catches it up.
+func (uc *ProductImportUseCase) saveJob(job *entities.ImportJob) {
+	if err := uc.jobRepo.Update(job); err != nil {
+		uc.logger.Errorf("Saving import job %s: %v", job.ID, err)
+	}
+}
+
+// importFailure is the message stored on a failed job. Problems with the
+// file are shown as they are; anything else is an internal error.
+func importFailure(err error) string {
+	var appErr *apperrors.Error
+	if errors.As(err, &appErr) && appErr.Kind != apperrors.KindInternal {
+		return appErr.Message
+	}
+	return "the import stopped because of an internal error"
+}
+
+// isImportProblem reports whether err is a problem with the file rather
+// than with the server.
+func isImportProblem(err error) bool {
+	var appErr *apperrors.Error
+	return errors.As(err, &appErr) && appErr.Kind != apperrors.KindInternal
+}
+
+// process reads rows until the end of the file, counting each into report.
+// Stock changes are recorded as made by actorID. flush receives the row
+// errors found since its last call, every importBatchSize rows and once at
+// the end.
+func (uc *ProductImportUseCase) process(rows productRowReader, dryRun bool, actorID uuid.UUID, report *entities.ImportReport, flush func([]entities.ImportRowError) error) error {
+	state := &importState{seen: make(map[string]bool), categories: make(map[string]uuid.UUID), actorID: actorID}
+	var pending []entities.ImportRowError
+	reported := 0
+
+	for {
+		row, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+
+		var action importAction
+		var rowErrors []entities.ImportRowError
+		var fieldErr *rowError
+		switch {
+		case errors.As(err, &fieldErr):
+			action = importFailed
+			rowErrors = []entities.ImportRowError{{SKU: row.SKU, Field: fieldErr.field, Message: fieldErr.message}}
+		case err != nil:
+			return err
+		default:
+			action, rowErrors, err = uc.importRow(row, dryRun, state)
+			if err != nil {
+				return err
+			}
+		}
+
+		report.Rows++
+		switch action {
+		case importCreated:
+			report.Created++
+		case importUpdated:
+			report.Updated++
+		case importSkipped:
+			report.Skipped++
+		case importFailed:
+			report.Failed++
+		}
+		for _, rowErr := range rowErrors {
+			if reported < maxReportedErrors {
+				rowErr.Row = report.Rows
+				pending = append(pending, rowErr)
+				reported++
+			}
+		}
+
+		if report.Rows%importBatchSize == 0 {
+			if err := flush(pending); err != nil {
+				return err
+			}
+			pending = nil
+		}
+	}
+
+	return flush(pending)
+}
+
+// importState is what an import remembers between rows.
+type importState struct {
+	seen       map[string]bool
+	categories map[string]uuid.UUID
+	actorID    uuid.UUID
+}
+
+// importRow creates or updates the product for one row. Problems with the
+// row are returned as row errors; the error result is kept for failures
+// that should stop the import, such as a lost database connection.
+func (uc *ProductImportUseCase) importRow(row *ProductImportRow, dryRun bool, state *importState) (importAction, []entities.ImportRowError, error) {
+	fail := func(field, message string) (importAction, []entities.ImportRowError, error) {
+		return importFailed, []entities.ImportRowError{{SKU: row.SKU, Field: field, Message: message}}, nil
+	}
+
+	// An empty image_url clears the image; only other values must be URLs.
+	checked := *row
+	if checked.ImageURL != nil && *checked.ImageURL == "" {
+		checked.ImageURL = nil
+	}
+	if err := uc.validate.Struct(&checked); err != nil {
+		var fieldErrors validation.Errors
+		if !errors.As(err, &fieldErrors) {
+			return importFailed, nil, err
+		}
+		rowErrors := make([]entities.ImportRowError, len(fieldErrors))
+		for i, fieldErr := range fieldErrors {
+			rowErrors[i] = entities.ImportRowError{SKU: row.SKU, Field: fieldErr.Field, Message: fieldErr.Message}
+		}
+		return importFailed, rowErrors, nil
+	}
+
+	if state.seen[row.SKU] {
+		return fail("sku", "the SKU appears more than once in the file")
+	}
+	state.seen[row.SKU] = true
+
+	var categoryIDs *[]uuid.UUID
+	if row.Categories != nil {
+		ids, unknown, err := uc.resolveCategorySlugs(*row.Categories, state)
+		if err != nil {
+			return importFailed, nil, err
+		}
+		if unknown != "" {
+			return fail("categories", "unknown category: "+unknown)
+		}
+		categoryIDs = &ids
+	}
+
+	existing, err := uc.products.productRepo.GetBySKU(row.SKU)
+	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+		return importFailed, nil, err
+	}
+	if existing == nil {
+		return uc.createFromRow(row, categoryIDs, dryRun, fail)
+	}
+
+	product, err := uc.products.GetProduct(existing.ID)
+	if err != nil {
+		return importFailed, nil, err
+	}
+	req, changed := rowChanges(product, row, categoryIDs)
+	if !changed {
+		return importSkipped, nil, nil
+	}
+	if req.Stock != nil && product.HasVariants() {
+		return fail("stock", errStockManagedByVariants.Message)
+	}
+	if dryRun {
+		return importUpdated, nil, nil
+	}
+	if _, err := uc.products.UpdateProduct(product.ID, req, nil, state.actorID); err != nil {
+		return rowFailure(row, err)
+	}
+	return importUpdated, nil, nil
+}
+
+func (uc *ProductImportUseCase) createFromRow(row *ProductImportRow, categoryIDs *[]uuid.UUID, dryRun bool, fail func(field, message string) (importAction, []entities.ImportRowError, error)) (importAction, []entities.ImportRowError, error) {
+	if row.Name == nil {
+		return fail("name", "name is required for a new product")
+	}
+	if row.Price == nil {
+		return fail("price", "price is required for a new product")
+	}
+	if variant, _ := uc.products.variantRepo.GetBySKU(row.SKU); variant != nil {
+		return fail("sku", "the SKU belongs to a product variant")
+	}
+	if dryRun {
+		return importCreated, nil, nil
+	}
+
+	req := &CreateProductRequest{
+		Name:     *row.Name,
+		Price:    *row.Price,
+		SKU:      row.SKU,
+		IsActive: row.IsActive,
+	}
+	if row.Description != nil {
+		req.Description = *row.Description
+	}
+	if row.Stock != nil {
+		req.Stock = *row.Stock
+	}
+	if row.ImageURL != nil {
+		req.ImageURL = *row.ImageURL
+	}
+	if categoryIDs != nil {
+		req.CategoryIDs = *categoryIDs
+	}
+
+	if _, err := uc.products.CreateProduct(req); err != nil {
+		return rowFailure(row, err)
+	}
+	return importCreated, nil, nil
+}
+
+// rowFailure turns a rejected write into a row error. Internal errors stop
+// the import instead.
+func rowFailure(row *ProductImportRow, err error) (importAction, []entities.ImportRowError, error) {
+	var appErr *apperrors.Error
+	if errors.As(err, &appErr) && appErr.Kind != apperrors.KindInternal {
+		return importFailed, []entities.ImportRowError{{SKU: row.SKU, Message: appErr.Message}}, nil
+	}
+	return importFailed, nil, err
+}
+
+// resolveCategorySlugs maps slugs to category ids, looking up only those
+// not seen earlier in the file. It returns the first unknown slug, if any.
+func (uc *ProductImportUseCase) resolveCategorySlugs(slugs []string, state *importState) ([]uuid.UUID, string, error) {
+	var missing []string
+	for _, slug := range slugs {
+		if _, ok := state.categories[slug]; !ok {
+			missing = append(missing, slug)
+		}
+	}
+	if len(missing) > 0 {
+		categories, err := uc.products.categoryRepo.GetBySlugs(missing)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, category := range categories {
+			state.categories[category.Slug] = category.ID
+		}
+	}
+
+	ids := make([]uuid.UUID, 0, len(slugs))
+	for _, slug := range slugs {
+		id, ok := state.categories[slug]
+		if !ok {
+			return nil, slug, nil
+		}
+		ids = append(ids, id)
+	}
+	return ids, "", nil
+}
+
+// rowChanges builds the update for the fields where row differs from
+// product, reporting whether there are any.
+func rowChanges(product *entities.Product, row *ProductImportRow, categoryIDs *[]uuid.UUID) (*UpdateProductRequest, bool) {
+	req := &UpdateProductRequest{}
+	changed := false
+	if row.Name != nil && *row.Name != product.Name {
+		req.Name, changed = row.Name, true
+	}
+	if row.Description != nil && *row.Description != product.Description {
+		req.Description, changed = row.Description, true
+	}
+	if row.Price != nil && *row.Price != product.Price {
+		req.Price, changed = row.Price, true
+	}
+	if row.Stock != nil && *row.Stock != product.Stock {
+		req.Stock, changed = row.Stock, true
+	}
+	if row.ImageURL != nil && *row.ImageURL != product.ImageURL {
+		req.ImageURL, changed = row.ImageURL, true
+	}
+	if row.IsActive != nil && *row.IsActive != product.IsActive {
+		req.IsActive, changed = row.IsActive, true
+	}
+	if categoryIDs != nil && !sameCategories(product.Categories, *categoryIDs) {
+		req.CategoryIDs, changed = categoryIDs, true
+	}
+	return req, changed
+}
+
+func sameCategories(categories []entities.Category, ids []uuid.UUID) bool {
+	current := make([]string, len(categories))
+	for i, category := range categories {
+		current[i] = category.ID.String()
+	}
+	wanted := make([]string, 0, len(ids))
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			wanted = append(wanted, id.String())
+		}
+	}
+	sort.Strings(current)
+	sort.Strings(wanted)
+	return strings.Join(current, ",") == strings.Join(wanted, ",")
+}
+
+// ExportProducts writes every product, inactive ones included, in a form
+// the import reads back unchanged. Products are read a batch at a time, so the
+// catalogue is never held in memory.
+func (uc *ProductImportUseCase) ExportProducts(format string, w io.Writer) error {
+	writer, err := newProductRowWriter(format, w)
+	if err != nil {
+		return err
+	}
+
+	filter := entities.ProductFilter{Sort: entities.ProductSortName, IncludeInactive: true}
+	params := pagination.Params{Limit: exportBatchSize}
+	for {
+		page, err := uc.products.productRepo.List(filter, params)
+		if err != nil {
+			return err
+		}
+		for _, product := range page.Items {
+			if err := writer.Write(exportRow(product)); err != nil {
+				return err
+			}
+		}
+
+		if !page.HasMore {
+			return writer.Close()
+		}
+		if params.After, err = pagination.DecodeCursor(page.NextCursor); err != nil {
+			return err
+		}
+	}
+}
@@ -1,37 +1,195 @@
 package usecases
 
 import (
+	"strings"
+	"time"
+
+	"prototype-fiber/internal/domain/apperrors"
 	"prototype-fiber/internal/domain/entities"
-	"errors"
+	"prototype-fiber/pkg/pagination"
+	"prototype-fiber/pkg/patch"
 
 	"github.com/google/uuid"
 )
 
+// suggestionTTL bounds how stale a cached autocomplete list can be after a
+// product is added or renamed.
+const suggestionTTL = time.Minute
+
+const (
+	minSuggestPrefix   = 2
+	defaultSuggestions = 8
+	maxSuggestions     = 20
+
+	reindexBatchSize = 500
+)
+
 type ProductUseCase struct {
-	productRepo entities.ProductRepository
+	productRepo     entities.ProductRepository
+	variantRepo     entities.ProductVariantRepository
+	categoryRepo    entities.CategoryRepository
+	searchIndex     entities.SearchIndex
+	suggestionCache entities.SuggestionCache
+	storage         entities.BlobStorage
 }
 
 type CreateProductRequest struct {
-	Name        string  `json:"name" validate:"required"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" validate:"required,gt=0"`
-	SKU         string  `json:"sku" validate:"required"`
-	Stock       int     `json:"stock" validate:"gte=0"`
-	Category    string  `json:"category"`
-	ImageURL    string  `json:"image_url"`
+	Name        string      `json:"name" validate:"required,max=255"`
+	Description string      `json:"description" validate:"max=5000"`
+	Price       float64     `json:"price" validate:"required,gt=0"`
+	SKU         string      `json:"sku" validate:"required,sku"`
+	Stock       int         `json:"stock" validate:"gte=0"`
+	CategoryIDs []uuid.UUID `json:"category_ids" validate:"max=20"`
+	ImageURL    string      `json:"image_url" validate:"omitempty,url"`
+	IsActive    *bool       `json:"is_active"`
+	// ReorderThreshold raises a low-stock alert when stock falls to it.
+	ReorderThreshold int `json:"reorder_threshold" validate:"gte=0"`
+}
+
+// ReplaceProductRequest is the body of PUT: every field is replaced.
+type ReplaceProductRequest struct {
+	Name        string      `json:"name" validate:"required,max=255"`
+	Description string      `json:"description" validate:"max=5000"`
+	Price       float64     `json:"price" validate:"required,gt=0"`
+	SKU         string      `json:"sku" validate:"required,sku"`
+	Stock       *int        `json:"stock" validate:"required,gte=0"`
+	CategoryIDs []uuid.UUID `json:"category_ids" validate:"max=20"`
+	ImageURL    string      `json:"image_url" validate:"omitempty,url"`
+	IsActive    *bool       `json:"is_active" validate:"required"`
+
+	ReorderThreshold int `json:"reorder_threshold" validate:"gte=0"`
+}
+
+// UpdateProductRequest is the body of PATCH, applied as a JSON Merge Patch:
+// absent fields are left unchanged and nullable fields are cleared by null.
+type UpdateProductRequest struct {
+	Name        *string      `json:"name" validate:"omitnil,min=1,max=255"`
+	Description *string      `json:"description" validate:"omitnil,max=5000" patch:"nullable"`
+	Price       *float64     `json:"price" validate:"omitnil,gt=0"`
+	SKU         *string      `json:"sku" validate:"omitnil,sku"`
+	Stock       *int         `json:"stock" validate:"omitnil,gte=0"`
+	CategoryIDs *[]uuid.UUID `json:"category_ids" validate:"omitnil,max=20" patch:"nullable"`
+	ImageURL    *string      `json:"image_url" validate:"omitnil,url" patch:"nullable"`
+	IsActive    *bool        `json:"is_active"`
+
+	ReorderThreshold *int `json:"reorder_threshold" validate:"omitnil,gte=0"`
+}
+
+// AdjustStockRequest adds Quantity, which may be negative, to the stock at
+// a warehouse, the default one when WarehouseID is unset. Reason is
+// adjustment, the default, or receipt for goods coming in.
+type AdjustStockRequest struct {
+	Quantity    *int                         `json:"quantity" validate:"required,ne=0"`
+	WarehouseID *uuid.UUID                   `json:"warehouse_id"`
+	Reason      entities.StockMovementReason `json:"reason" validate:"omitempty,oneof=adjustment receipt"`
+	Note        string                       `json:"note" validate:"max=500"`
+}
+
+func (r *AdjustStockRequest) change(actorID uuid.UUID) entities.StockChange {
+	change := stockAdjustment(actorID)
+	if r.Reason != "" {
+		change.Reason = r.Reason
+	}
+	change.WarehouseID = r.WarehouseID
+	change.Note = r.Note
+	return change
+}
+
+// stockAdjustment is a manual stock change at the default warehouse;
+// actorID is uuid.Nil when there is no user behind it, as for a
+// command-line import.
+func stockAdjustment(actorID uuid.UUID) entities.StockChange {
+	change := entities.StockChange{Reason: entities.StockReasonAdjustment}
+	if actorID != uuid.Nil {
+		change.UserID = &actorID
+	}
+	return change
+}
+
+// ListProductsQuery is the query string of GET /products. category takes
+// category slugs, repeated or comma-separated, and matches their
+// subcategories too; created_after takes RFC 3339 or YYYY-MM-DD.
+type ListProductsQuery struct {
+	Category     []string `json:"category" query:"category" validate:"max=20,dive,max=100"`
+	MinPrice     *float64 `json:"min_price" query:"min_price" validate:"omitnil,gte=0"`
+	MaxPrice     *float64 `json:"max_price" query:"max_price" validate:"omitnil,gte=0"`
+	InStock      bool     `json:"in_stock" query:"in_stock"`
+	CreatedAfter string   `json:"created_after" query:"created_after"`
+	Sort         string   `json:"sort" query:"sort" validate:"omitempty,oneof=newest price name popularity rating"`
+	Order        string   `json:"order" query:"order" validate:"omitempty,oneof=asc desc"`
+}
+
+// Filter converts the query into a repository filter. Without an explicit
+// order, newest, popularity and rating sort descending and price and name
+// ascending.
+func (q *ListProductsQuery) Filter() (entities.ProductFilter, error) {
+	filter := entities.ProductFilter{
+		MinPrice:    q.MinPrice,
+		MaxPrice:    q.MaxPrice,
+		InStockOnly: q.InStock,
+		Sort:        entities.ProductSort(q.Sort),
+	}
+
+	for _, value := range q.Category {
+		for _, category := range strings.Split(value, ",") {
+			if category = strings.ToLower(strings.TrimSpace(category)); category != "" {
+				filter.CategorySlugs = append(filter.CategorySlugs, category)
+			}
+		}
+	}
+
+	if q.MinPrice != nil && q.MaxPrice != nil && *q.MinPrice > *q.MaxPrice {
+		return filter, apperrors.Validation(apperrors.CodeInvalidQuery, "min_price cannot be greater than max_price")
+	}
+
+	if q.CreatedAfter != "" {
+		createdAfter, err := time.Parse(time.RFC3339, q.CreatedAfter)
+		if err != nil {
+			createdAfter, err = time.Parse(time.DateOnly, q.CreatedAfter)
+		}
+		if err != nil {
+			return filter, apperrors.Validation(apperrors.CodeInvalidQuery, "created_after must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+		filter.CreatedAfter = &createdAfter
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = entities.ProductSortNewest
+	}
+	switch q.Order {
+	case "asc":
+		filter.Descending = false
+	case "desc":
+		filter.Descending = true
+	default:
+		filter.Descending = filter.Sort == entities.ProductSortNewest || filter.Sort == entities.ProductSortPopularity ||
+			filter.Sort == entities.ProductSortRating
+	}
+
+	return filter, nil
 }
 
-func NewProductUseCase(productRepo entities.ProductRepository) *ProductUseCase {
+// NewProductUseCase creates the product use case. suggestionCache may be
+// nil, in which case every suggestion is read from the database. storage
+// holds the media blobs removed with a deleted product and may be nil where
+// products are never deleted.
+func NewProductUseCase(productRepo entities.ProductRepository, variantRepo entities.ProductVariantRepository, categoryRepo entities.CategoryRepository, searchIndex entities.SearchIndex, suggestionCache entities.SuggestionCache, storage entities.BlobStorage) *ProductUseCase {
 	return &ProductUseCase{
-		productRepo: productRepo,
+		productRepo:     productRepo,
+		variantRepo:     variantRepo,
+		categoryRepo:    categoryRepo,
+		searchIndex:     searchIndex,
+		suggestionCache: suggestionCache,
+		storage:         storage,
 	}
 }
 
 func (uc *ProductUseCase) CreateProduct(req *CreateProductRequest) (*entities.Product, error) {
-	// Check if SKU already exists
-	existing, _ := uc.productRepo.GetBySKU(req.SKU)
-	if existing != nil {
-		return nil, errors.New("product with this SKU already exists")
+	if err := uc.ensureSKUAvailable(req.SKU, uuid.Nil, uuid.Nil); err != nil {
+		return nil, err
+	}
+	if err := uc.checkCategories(req.CategoryIDs); err != nil {
+		return nil, err
 	}
 
 	product := &entities.Product{
@@ -40,70 +198,315 @@ func (uc *ProductUseCase) CreateProduct(req *CreateProductRequest) (*entities.Pr
 		Price:       req.Price,
 		SKU:         req.SKU,
 		Stock:       req.Stock,
-		Category:    req.Category,
 		ImageURL:    req.ImageURL,
-		IsActive:    true,
+		IsActive:    req.IsActive == nil || *req.IsActive,
+
+		ReorderThreshold: req.ReorderThreshold,
 	}
 
 	if err := uc.productRepo.Create(product); err != nil {
 		return nil, err
 	}
+	if len(req.CategoryIDs) > 0 {
+		if err := uc.productRepo.SetCategories(product.ID, req.CategoryIDs); err != nil {
+			return nil, err
+		}
+	}
 
-	return product, nil
+	return uc.GetProduct(product.ID)
 }
 
 func (uc *ProductUseCase) GetProduct(id uuid.UUID) (*entities.Product, error) {
-	return uc.productRepo.GetByID(id)
+	product, err := uc.productRepo.GetByID(id)
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeProductNotFound, "product not found")
+	}
+	return product, nil
+}
+
+func (uc *ProductUseCase) ListProducts(filter entities.ProductFilter, params pagination.Params) (*pagination.Page[*entities.Product], error) {
+	if err := uc.resolveCategories(&filter); err != nil {
+		return nil, err
+	}
+	return uc.productRepo.List(filter, params)
 }
 
-func (uc *ProductUseCase) ListProducts(offset, limit int, category string) ([]*entities.Product, error) {
-	return uc.productRepo.List(offset, limit, category)
+func (uc *ProductUseCase) SearchProducts(query string, filter entities.ProductFilter, params pagination.Params) (*entities.SearchResult, error) {
+	if err := uc.resolveCategories(&filter); err != nil {
+		return nil, err
+	}
+	return uc.searchIndex.Search(entities.SearchQuery{Text: query, Filter: filter, Page: params})
 }
 
-func (uc *ProductUseCase) SearchProducts(query string, offset, limit int) ([]*entities.Product, error) {
-	return uc.productRepo.Search(query, offset, limit)
+// resolveCategories expands the filter's category slugs into the ids of
+// those categories and all their descendants. Unknown slugs are rejected
+// rather than silently matching nothing.
+func (uc *ProductUseCase) resolveCategories(filter *entities.ProductFilter) error {
+	if len(filter.CategorySlugs) == 0 {
+		return nil
+	}
+
+	categories, err := uc.categoryRepo.GetBySlugs(filter.CategorySlugs)
+	if err != nil {
+		return err
+	}
+	found := make(map[string]bool, len(categories))
+	ids := make([]uuid.UUID, 0, len(categories))
+	for _, category := range categories {
+		found[category.Slug] = true
+		ids = append(ids, category.ID)
+	}
+	for _, slug := range filter.CategorySlugs {
+		if !found[slug] {
+			return apperrors.Validation(apperrors.CodeInvalidQuery, "unknown category: "+slug)
+		}
+	}
+
+	filter.CategoryIDs, err = uc.categoryRepo.DescendantIDs(ids)
+	return err
 }
 
-func (uc *ProductUseCase) UpdateProduct(id uuid.UUID, updates map[string]interface{}) (*entities.Product, error) {
-	product, err := uc.productRepo.GetByID(id)
+// checkCategories fails unless every id names an existing category.
+func (uc *ProductUseCase) checkCategories(ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	categories, err := uc.categoryRepo.GetByIDs(ids)
+	if err != nil {
+		return err
+	}
+	found := make(map[uuid.UUID]bool, len(categories))
+	for _, category := range categories {
+		found[category.ID] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return apperrors.Validation(apperrors.CodeCategoryNotFound, "category not found: "+id.String())
+		}
+	}
+	return nil
+}
+
+// ReindexSearch empties the search index and rebuilds it from the catalogue,
+// returning the number of products indexed.
+func (uc *ProductUseCase) ReindexSearch() (int, error) {
+	if err := uc.searchIndex.Reset(); err != nil {
+		return 0, err
+	}
+
+	indexed := 0
+	params := pagination.Params{Limit: reindexBatchSize}
+	for {
+		page, err := uc.productRepo.List(entities.ProductFilter{}, params)
+		if err != nil {
+			return indexed, err
+		}
+		if err := uc.searchIndex.Index(page.Items...); err != nil {
+			return indexed, err
+		}
+		indexed += len(page.Items)
+
+		if !page.HasMore {
+			return indexed, nil
+		}
+		if params.After, err = pagination.DecodeCursor(page.NextCursor); err != nil {
+			return indexed, err
+		}
+	}
+}
+
+// SuggestProducts returns autocomplete entries for prefix. Prefixes shorter
+// than two characters return nothing rather than most of the catalogue.
+func (uc *ProductUseCase) SuggestProducts(prefix string, limit int) ([]*entities.ProductSuggestion, error) {
+	prefix = strings.Join(strings.Fields(strings.ToLower(prefix)), " ")
+	if len([]rune(prefix)) < minSuggestPrefix {
+		return []*entities.ProductSuggestion{}, nil
+	}
+	if limit <= 0 {
+		limit = defaultSuggestions
+	}
+	if limit > maxSuggestions {
+		limit = maxSuggestions
+	}
+
+	if uc.suggestionCache != nil {
+		if suggestions, found, err := uc.suggestionCache.GetSuggestions(prefix, limit); err == nil && found {
+			return suggestions, nil
+		}
+	}
+
+	suggestions, err := uc.productRepo.Suggest(prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if uc.suggestionCache != nil {
+		_ = uc.suggestionCache.SetSuggestions(prefix, limit, suggestions, suggestionTTL)
+	}
+	return suggestions, nil
+}
+
+// ReplaceProduct replaces the product as actorID; a stock change is
+// recorded as an adjustment.
+func (uc *ProductUseCase) ReplaceProduct(id uuid.UUID, req *ReplaceProductRequest, actorID uuid.UUID) (*entities.Product, error) {
+	product, err := uc.GetProduct(id)
 	if err != nil {
 		return nil, err
 	}
 
-	// Update fields if provided
-	if name, ok := updates["name"].(string); ok {
-		product.Name = name
+	if req.SKU != product.SKU {
+		if err := uc.ensureSKUAvailable(req.SKU, product.ID, uuid.Nil); err != nil {
+			return nil, err
+		}
 	}
-	if description, ok := updates["description"].(string); ok {
-		product.Description = description
+	if product.HasVariants() && *req.Stock != product.Stock {
+		return nil, errStockManagedByVariants
 	}
-	if price, ok := updates["price"].(float64); ok {
-		product.Price = price
+	if err := uc.checkCategories(req.CategoryIDs); err != nil {
+		return nil, err
 	}
-	if stock, ok := updates["stock"].(int); ok {
-		product.Stock = stock
+
+	product.Name = req.Name
+	product.Description = req.Description
+	product.Price = req.Price
+	product.SKU = req.SKU
+	product.ImageURL = req.ImageURL
+	product.IsActive = *req.IsActive
+	product.ReorderThreshold = req.ReorderThreshold
+
+	if err := uc.productRepo.Update(product); err != nil {
+		return nil, err
 	}
-	if category, ok := updates["category"].(string); ok {
-		product.Category = category
+	if err := uc.setStock(product, *req.Stock, actorID); err != nil {
+		return nil, err
 	}
-	if imageURL, ok := updates["image_url"].(string); ok {
-		product.ImageURL = imageURL
+	if err := uc.productRepo.SetCategories(product.ID, req.CategoryIDs); err != nil {
+		return nil, err
 	}
-	if isActive, ok := updates["is_active"].(bool); ok {
-		product.IsActive = isActive
+
+	return uc.GetProduct(product.ID)
+}
+
+// UpdateProduct applies a merge patch as actorID; a stock change is recorded
+// as an adjustment.
+func (uc *ProductUseCase) UpdateProduct(id uuid.UUID, req *UpdateProductRequest, nulls patch.Nulls, actorID uuid.UUID) (*entities.Product, error) {
+	product, err := uc.GetProduct(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		product.Name = *req.Name
+	}
+	if req.Description != nil {
+		product.Description = *req.Description
+	} else if nulls.Has("description") {
+		product.Description = ""
+	}
+	if req.Price != nil {
+		product.Price = *req.Price
+	}
+	if req.SKU != nil && *req.SKU != product.SKU {
+		if err := uc.ensureSKUAvailable(*req.SKU, product.ID, uuid.Nil); err != nil {
+			return nil, err
+		}
+		product.SKU = *req.SKU
+	}
+	if req.Stock != nil && *req.Stock != product.Stock && product.HasVariants() {
+		return nil, errStockManagedByVariants
+	}
+	if req.ImageURL != nil {
+		product.ImageURL = *req.ImageURL
+	} else if nulls.Has("image_url") {
+		product.ImageURL = ""
+	}
+	if req.IsActive != nil {
+		product.IsActive = *req.IsActive
+	}
+	if req.ReorderThreshold != nil {
+		product.ReorderThreshold = *req.ReorderThreshold
+	}
+	var categoryIDs []uuid.UUID
+	if req.CategoryIDs != nil {
+		categoryIDs = *req.CategoryIDs
+		if err := uc.checkCategories(categoryIDs); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := uc.productRepo.Update(product); err != nil {
 		return nil, err
 	}
+	if req.Stock != nil {
+		if err := uc.setStock(product, *req.Stock, actorID); err != nil {
+			return nil, err
+		}
+	}
+	if req.CategoryIDs != nil || nulls.Has("category_ids") {
+		if err := uc.productRepo.SetCategories(product.ID, categoryIDs); err != nil {
+			return nil, err
+		}
+	}
 
-	return product, nil
+	return uc.GetProduct(product.ID)
 }
 
+// ensureSKUAvailable fails if sku belongs to a product other than productID
+// or a variant other than variantID: SKUs are unique across both.
+func (uc *ProductUseCase) ensureSKUAvailable(sku string, productID, variantID uuid.UUID) error {
+	if existing, _ := uc.productRepo.GetBySKU(sku); existing != nil && existing.ID != productID {
+		return apperrors.Conflict(apperrors.CodeSKUExists, "product with this SKU already exists")
+	}
+	if existing, _ := uc.variantRepo.GetBySKU(sku); existing != nil && existing.ID != variantID {
+		return apperrors.Conflict(apperrors.CodeSKUExists, "variant with this SKU already exists")
+	}
+	return nil
+}
+
+var errStockManagedByVariants = apperrors.Conflict(apperrors.CodeProductHasVariants, "stock of a product with variants is set per variant")
+
+// DeleteProduct deletes the product, its media with it, and the media's
+// blobs. The primary image's original is kept, as orders snapshot its URL.
 func (uc *ProductUseCase) DeleteProduct(id uuid.UUID) error {
-	return uc.productRepo.Delete(id)
+	product, err := uc.GetProduct(id)
+	if err != nil {
+		return err
+	}
+	if err := uc.productRepo.Delete(id); err != nil {
+		return err
+	}
+
+	// Blobs left behind by a failed delete are unreachable but harmless.
+	if uc.storage == nil {
+		return nil
+	}
+	for _, media := range product.Media {
+		for _, thumbnail := range media.Thumbnails {
+			_ = uc.storage.Delete(thumbnail.Key)
+		}
+		if !media.IsPrimary {
+			_ = uc.storage.Delete(media.Key)
+		}
+	}
+	return nil
 }
 
-func (uc *ProductUseCase) UpdateStock(id uuid.UUID, quantity int) error {
-	return uc.productRepo.UpdateStock(id, quantity)
-}
\ No newline at end of file
+// UpdateStock adjusts a product's stock by req.Quantity as actorID.
+func (uc *ProductUseCase) UpdateStock(id uuid.UUID, req *AdjustStockRequest, actorID uuid.UUID) error {
+	product, err := uc.GetProduct(id)
+	if err != nil {
+		return err
+	}
+	if product.HasVariants() {
+		return errStockManagedByVariants
+	}
+	return uc.productRepo.UpdateStock(id, *req.Quantity, req.change(actorID))
+}
+
+// setStock moves a product's own stock to stock through the ledger.
+func (uc *ProductUseCase) setStock(product *entities.Product, stock int, actorID uuid.UUID) error {
+	if stock == product.Stock {
+		return nil
+	}
+	return uc.productRepo.UpdateStock(product.ID, stock-product.Stock, stockAdjustment(actorID))
+}
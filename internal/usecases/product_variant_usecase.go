@@ -0,0 +1,270 @@
+package usecases
+
+import (
+	"sort"
+	"strings"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/patch"
+
+	"github.com/google/uuid"
+)
+
+// SetProductOptionsRequest replaces a product's option types, e.g.
+// {"options": [{"name": "size", "values": ["S", "M", "L"]}]}. Options and
+// values keep the order they are given in.
+type SetProductOptionsRequest struct {
+	Options []ProductOptionInput `json:"options" validate:"max=3,dive"`
+}
+
+type ProductOptionInput struct {
+	Name   string   `json:"name" validate:"required,max=50"`
+	Values []string `json:"values" validate:"required,min=1,max=50,dive,required,max=50"`
+}
+
+// CreateVariantRequest picks one value for every option of the product by
+// option name, e.g. {"options": {"size": "M", "colour": "Red"}}.
+type CreateVariantRequest struct {
+	SKU      string            `json:"sku" validate:"required,sku"`
+	Price    *float64          `json:"price" validate:"omitnil,gt=0"`
+	Stock    int               `json:"stock" validate:"gte=0"`
+	ImageURL string            `json:"image_url" validate:"omitempty,url"`
+	Options  map[string]string `json:"options" validate:"required,min=1"`
+}
+
+// UpdateVariantRequest is the body of PATCH on a variant, applied as a JSON
+// Merge Patch. A null price reverts to the product's price.
+type UpdateVariantRequest struct {
+	SKU      *string  `json:"sku" validate:"omitnil,sku"`
+	Price    *float64 `json:"price" validate:"omitnil,gt=0" patch:"nullable"`
+	Stock    *int     `json:"stock" validate:"omitnil,gte=0"`
+	ImageURL *string  `json:"image_url" validate:"omitnil,url" patch:"nullable"`
+	IsActive *bool    `json:"is_active"`
+}
+
+// SetOptions replaces a product's options. Existing variants are built from
+// the current values, so options can only be changed before any exist.
+func (uc *ProductUseCase) SetOptions(productID uuid.UUID, req *SetProductOptionsRequest) (*entities.Product, error) {
+	product, err := uc.GetProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.HasVariants() {
+		return nil, apperrors.Conflict(apperrors.CodeProductHasVariants, "delete the product's variants before changing its options")
+	}
+
+	options := make([]entities.ProductOption, 0, len(req.Options))
+	names := make(map[string]bool)
+	for i, input := range req.Options {
+		name := strings.TrimSpace(input.Name)
+		if names[strings.ToLower(name)] {
+			return nil, apperrors.Validation(apperrors.CodeInvalidVariantOptions, "duplicate option: "+name)
+		}
+		names[strings.ToLower(name)] = true
+
+		option := entities.ProductOption{Name: name, Position: i}
+		values := make(map[string]bool)
+		for j, value := range input.Values {
+			value = strings.TrimSpace(value)
+			if values[strings.ToLower(value)] {
+				return nil, apperrors.Validation(apperrors.CodeInvalidVariantOptions, "duplicate value for option "+name+": "+value)
+			}
+			values[strings.ToLower(value)] = true
+			option.Values = append(option.Values, entities.ProductOptionValue{Value: value, Position: j})
+		}
+		options = append(options, option)
+	}
+
+	if err := uc.variantRepo.SetOptions(productID, options); err != nil {
+		return nil, err
+	}
+	return uc.GetProduct(productID)
+}
+
+func (uc *ProductUseCase) CreateVariant(productID uuid.UUID, req *CreateVariantRequest) (*entities.ProductVariant, error) {
+	product, err := uc.GetProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := selectOptionValues(product, req.Options)
+	if err != nil {
+		return nil, err
+	}
+	if existing := findVariantByValues(product, values); existing != nil {
+		return nil, apperrors.Conflict(apperrors.CodeVariantExists, "a variant with these options already exists: "+product.VariantLabel(existing))
+	}
+	if err := uc.ensureSKUAvailable(req.SKU, uuid.Nil, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	variant := &entities.ProductVariant{
+		ProductID:    productID,
+		SKU:          req.SKU,
+		Price:        req.Price,
+		Stock:        req.Stock,
+		ImageURL:     req.ImageURL,
+		IsActive:     true,
+		OptionValues: values,
+	}
+	if err := uc.variantRepo.Create(variant); err != nil {
+		return nil, err
+	}
+	return variant, nil
+}
+
+// UpdateVariant applies a merge patch as actorID; a stock change is
+// recorded as an adjustment.
+func (uc *ProductUseCase) UpdateVariant(productID, variantID uuid.UUID, req *UpdateVariantRequest, nulls patch.Nulls, actorID uuid.UUID) (*entities.ProductVariant, error) {
+	variant, err := uc.getVariant(productID, variantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.SKU != nil && *req.SKU != variant.SKU {
+		if err := uc.ensureSKUAvailable(*req.SKU, uuid.Nil, variant.ID); err != nil {
+			return nil, err
+		}
+		variant.SKU = *req.SKU
+	}
+	if req.Price != nil {
+		variant.Price = req.Price
+	} else if nulls.Has("price") {
+		variant.Price = nil
+	}
+	if req.ImageURL != nil {
+		variant.ImageURL = *req.ImageURL
+	} else if nulls.Has("image_url") {
+		variant.ImageURL = ""
+	}
+	if req.IsActive != nil {
+		variant.IsActive = *req.IsActive
+	}
+
+	if err := uc.variantRepo.Update(variant); err != nil {
+		return nil, err
+	}
+	if req.Stock != nil && *req.Stock != variant.Stock {
+		if err := uc.variantRepo.UpdateStock(variant.ID, *req.Stock-variant.Stock, stockAdjustment(actorID)); err != nil {
+			return nil, err
+		}
+		variant.Stock = *req.Stock
+	}
+
+	return variant, nil
+}
+
+func (uc *ProductUseCase) DeleteVariant(productID, variantID uuid.UUID) error {
+	if _, err := uc.getVariant(productID, variantID); err != nil {
+		return err
+	}
+	return uc.variantRepo.Delete(variantID)
+}
+
+// UpdateVariantStock adjusts a variant's stock by req.Quantity as actorID.
+func (uc *ProductUseCase) UpdateVariantStock(productID, variantID uuid.UUID, req *AdjustStockRequest, actorID uuid.UUID) error {
+	if _, err := uc.getVariant(productID, variantID); err != nil {
+		return err
+	}
+	return uc.variantRepo.UpdateStock(variantID, *req.Quantity, req.change(actorID))
+}
+
+func (uc *ProductUseCase) getVariant(productID, variantID uuid.UUID) (*entities.ProductVariant, error) {
+	variant, err := uc.variantRepo.GetByID(variantID)
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeVariantNotFound, "variant not found")
+	}
+	if variant.ProductID != productID {
+		return nil, apperrors.NotFound(apperrors.CodeVariantNotFound, "variant not found")
+	}
+	return variant, nil
+}
+
+// selectOptionValues maps option names to the product's option values. Every
+// option must be given exactly once; names and values match case-insensitively.
+func selectOptionValues(product *entities.Product, selected map[string]string) ([]entities.ProductOptionValue, error) {
+	if len(product.Options) == 0 {
+		return nil, apperrors.Validation(apperrors.CodeInvalidVariantOptions, "product has no options; set them before adding variants")
+	}
+	if len(selected) != len(product.Options) {
+		return nil, apperrors.Validation(apperrors.CodeInvalidVariantOptions, "a value is required for every option of the product")
+	}
+
+	byName := make(map[string]string, len(selected))
+	for name, value := range selected {
+		byName[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+
+	values := make([]entities.ProductOptionValue, 0, len(product.Options))
+	for _, option := range product.Options {
+		value, ok := byName[strings.ToLower(option.Name)]
+		if !ok {
+			return nil, apperrors.Validation(apperrors.CodeInvalidVariantOptions, "missing value for option: "+option.Name)
+		}
+		found := false
+		for _, candidate := range option.Values {
+			if strings.EqualFold(candidate.Value, value) {
+				values = append(values, candidate)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, apperrors.Validation(apperrors.CodeInvalidVariantOptions, "unknown value for option "+option.Name+": "+value)
+		}
+	}
+	return values, nil
+}
+
+func findVariantByValues(product *entities.Product, values []entities.ProductOptionValue) *entities.ProductVariant {
+	key := optionValueKey(values)
+	for i := range product.Variants {
+		if optionValueKey(product.Variants[i].OptionValues) == key {
+			return &product.Variants[i]
+		}
+	}
+	return nil
+}
+
+func optionValueKey(values []entities.ProductOptionValue) string {
+	ids := make([]string, len(values))
+	for i, value := range values {
+		ids[i] = value.ID.String()
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+// resolveVariant returns the variant a cart or order line refers to. A
+// product sold in variants cannot be bought without choosing one, and a
+// product without variants cannot be given one.
+func resolveVariant(product *entities.Product, variantID *uuid.UUID) (*entities.ProductVariant, error) {
+	if variantID == nil {
+		if product.HasVariants() {
+			return nil, apperrors.Validation(apperrors.CodeVariantRequired, "a variant must be chosen for product: "+product.Name)
+		}
+		return nil, nil
+	}
+	variant := product.Variant(*variantID)
+	if variant == nil {
+		return nil, apperrors.NotFound(apperrors.CodeVariantNotFound, "variant not found")
+	}
+	return variant, nil
+}
+
+// checkStock reports insufficient stock for the product, or for the variant
+// when one is given.
+func checkStock(product *entities.Product, variant *entities.ProductVariant, quantity int) error {
+	if variant == nil {
+		if !product.CanFulfillQuantity(quantity) {
+			return apperrors.InsufficientStock(product.ID, product.Name, quantity, product.Stock)
+		}
+		return nil
+	}
+	if !product.IsActive || !variant.CanFulfillQuantity(quantity) {
+		name := product.Name + " (" + product.VariantLabel(variant) + ")"
+		return apperrors.InsufficientVariantStock(product.ID, variant.ID, name, quantity, variant.Stock)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package usecases
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/pagination"
+	"prototype-fiber/pkg/patch"
+
+	"github.com/google/uuid"
+)
+
+type ReviewUseCase struct {
+	reviewRepo  entities.ReviewRepository
+	productRepo entities.ProductRepository
+	orderRepo   entities.OrderRepository
+	userRepo    entities.UserRepository
+}
+
+type CreateReviewRequest struct {
+	Rating int    `json:"rating" validate:"required,min=1,max=5"`
+	Title  string `json:"title" validate:"required,max=200"`
+	Body   string `json:"body" validate:"max=5000"`
+}
+
+// UpdateReviewRequest is the body of PATCH /reviews/{id}, applied as a JSON
+// Merge Patch.
+type UpdateReviewRequest struct {
+	Rating *int    `json:"rating" validate:"omitnil,min=1,max=5"`
+	Title  *string `json:"title" validate:"omitnil,min=1,max=200"`
+	Body   *string `json:"body" validate:"omitnil,max=5000" patch:"nullable"`
+}
+
+type ListReviewsQuery struct {
+	Status string `json:"status" query:"status" validate:"omitempty,oneof=pending approved rejected"`
+}
+
+type ModerateReviewRequest struct {
+	Status string `json:"status" validate:"required,oneof=approved rejected"`
+}
+
+func NewReviewUseCase(reviewRepo entities.ReviewRepository, productRepo entities.ProductRepository, orderRepo entities.OrderRepository, userRepo entities.UserRepository) *ReviewUseCase {
+	return &ReviewUseCase{
+		reviewRepo:  reviewRepo,
+		productRepo: productRepo,
+		orderRepo:   orderRepo,
+		userRepo:    userRepo,
+	}
+}
+
+// ListProductReviews returns a product's published reviews, newest first.
+func (uc *ReviewUseCase) ListProductReviews(productID uuid.UUID, params pagination.Params) (*pagination.Page[*entities.Review], error) {
+	if _, err := uc.productRepo.GetByID(productID); err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeProductNotFound, "product not found")
+	}
+	return uc.reviewRepo.ListByProduct(productID, entities.ReviewApproved, params)
+}
+
+// CreateReview records userID's review of a product, held for moderation.
+func (uc *ReviewUseCase) CreateReview(productID, userID uuid.UUID, req *CreateReviewRequest) (*entities.Review, error) {
+	if _, err := uc.productRepo.GetByID(productID); err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeProductNotFound, "product not found")
+	}
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeUserNotFound, "user not found")
+	}
+	verified, err := uc.orderRepo.HasDelivered(userID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	review := &entities.Review{
+		ProductID:        productID,
+		UserID:           userID,
+		AuthorName:       authorName(user),
+		Rating:           req.Rating,
+		Title:            strings.TrimSpace(req.Title),
+		Body:             strings.TrimSpace(req.Body),
+		VerifiedPurchase: verified,
+		Status:           entities.ReviewPending,
+	}
+	if err := uc.reviewRepo.Create(review); err != nil {
+		if errors.Is(err, apperrors.ErrConflict) {
+			return nil, apperrors.Conflict(apperrors.CodeReviewExists, "you have already reviewed this product")
+		}
+		return nil, err
+	}
+	return review, nil
+}
+
+// UpdateReview edits the author's own review. The edit goes back through
+// moderation, and the verified-purchase badge is checked again.
+func (uc *ReviewUseCase) UpdateReview(id, userID uuid.UUID, req *UpdateReviewRequest, nulls patch.Nulls) (*entities.Review, error) {
+	review, err := uc.ownReview(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Rating != nil {
+		review.Rating = *req.Rating
+	}
+	if req.Title != nil {
+		review.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Body != nil {
+		review.Body = strings.TrimSpace(*req.Body)
+	} else if nulls.Has("body") {
+		review.Body = ""
+	}
+	if !review.VerifiedPurchase {
+		if review.VerifiedPurchase, err = uc.orderRepo.HasDelivered(userID, review.ProductID); err != nil {
+			return nil, err
+		}
+	}
+	review.Status = entities.ReviewPending
+	review.ModeratedBy = nil
+	review.ModeratedAt = nil
+
+	if err := uc.reviewRepo.Update(review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (uc *ReviewUseCase) DeleteReview(id, userID uuid.UUID) error {
+	if _, err := uc.ownReview(id, userID); err != nil {
+		return err
+	}
+	return apperrors.MapNotFound(uc.reviewRepo.Delete(id), apperrors.CodeReviewNotFound, "review not found")
+}
+
+// MarkHelpful records userID's helpful vote on a published review other
+// than their own. Voting twice counts once.
+func (uc *ReviewUseCase) MarkHelpful(id, userID uuid.UUID) error {
+	review, err := uc.publishedReview(id)
+	if err != nil {
+		return err
+	}
+	if review.UserID == userID {
+		return apperrors.Forbidden(apperrors.CodeForbidden, "you cannot vote on your own review")
+	}
+	return uc.reviewRepo.AddVote(id, userID)
+}
+
+func (uc *ReviewUseCase) UnmarkHelpful(id, userID uuid.UUID) error {
+	if _, err := uc.publishedReview(id); err != nil {
+		return err
+	}
+	return uc.reviewRepo.RemoveVote(id, userID)
+}
+
+// ListReviews is the moderation queue: reviews of every product with the
+// given status, pending when status is empty.
+func (uc *ReviewUseCase) ListReviews(status entities.ReviewStatus, params pagination.Params) (*pagination.Page[*entities.Review], error) {
+	if status == "" {
+		status = entities.ReviewPending
+	}
+	return uc.reviewRepo.List(status, params)
+}
+
+// ModerateReview approves or rejects a review as moderatorID. Moderated
+// reviews can be moderated again, for instance to take down an approved
+// review.
+func (uc *ReviewUseCase) ModerateReview(id uuid.UUID, req *ModerateReviewRequest, moderatorID uuid.UUID) (*entities.Review, error) {
+	if err := uc.reviewRepo.SetStatus(id, entities.ReviewStatus(req.Status), moderatorID); err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeReviewNotFound, "review not found")
+	}
+	return uc.reviewRepo.GetByID(id)
+}
+
+func (uc *ReviewUseCase) ownReview(id, userID uuid.UUID) (*entities.Review, error) {
+	review, err := uc.reviewRepo.GetByID(id)
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeReviewNotFound, "review not found")
+	}
+	if review.UserID != userID {
+		return nil, apperrors.Forbidden(apperrors.CodeForbidden, "review belongs to another user")
+	}
+	return review, nil
+}
+
+// publishedReview hides unpublished reviews as if they did not exist.
+func (uc *ReviewUseCase) publishedReview(id uuid.UUID) (*entities.Review, error) {
+	review, err := uc.reviewRepo.GetByID(id)
+	if err == nil && review.Status != entities.ReviewApproved {
+		err = apperrors.ErrNotFound
+	}
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeReviewNotFound, "review not found")
+	}
+	return review, nil
+}
+
+// authorName shows a reviewer by first name and last initial.
+func authorName(user *entities.User) string {
+	name := user.FirstName
+	if initial, _ := utf8.DecodeRuneInString(user.LastName); initial != utf8.RuneError {
+		name += " " + string(initial) + "."
+	}
+	return name
+}
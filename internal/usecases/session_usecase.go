@@ -0,0 +1,212 @@
+package usecases
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+
+	"github.com/google/uuid"
+)
+
+// sessionStatusTTL bounds how long a cached "active" status is trusted
+// before the database is consulted again.
+const sessionStatusTTL = 5 * time.Minute
+
+var errSessionRevoked = apperrors.Unauthorized(apperrors.CodeSessionRevoked, "session expired or revoked")
+
+type SessionUseCase struct {
+	sessionRepo      entities.SessionRepository
+	sessionCache     entities.SessionCache
+	lastSeenInterval time.Duration
+
+	mu        sync.Mutex
+	lastTouch map[uuid.UUID]time.Time
+}
+
+// ClientInfo describes the device a login comes from.
+type ClientInfo struct {
+	DeviceName string
+	UserAgent  string
+	IPAddress  string
+}
+
+// NewSessionUseCase creates the session use case. sessionCache may be nil,
+// in which case status checks and last-seen writes go to the database.
+func NewSessionUseCase(sessionRepo entities.SessionRepository, sessionCache entities.SessionCache, lastSeenInterval time.Duration) *SessionUseCase {
+	return &SessionUseCase{
+		sessionRepo:      sessionRepo,
+		sessionCache:     sessionCache,
+		lastSeenInterval: lastSeenInterval,
+		lastTouch:        make(map[uuid.UUID]time.Time),
+	}
+}
+
+func (uc *SessionUseCase) Start(userID uuid.UUID, client ClientInfo, expiresAt time.Time) (*entities.Session, error) {
+	return uc.start(userID, nil, client, expiresAt)
+}
+
+// StartImpersonation opens a session on the customer's account that is
+// attributed to the staff member acting on their behalf.
+func (uc *SessionUseCase) StartImpersonation(userID, impersonatorID uuid.UUID, client ClientInfo, expiresAt time.Time) (*entities.Session, error) {
+	return uc.start(userID, &impersonatorID, client, expiresAt)
+}
+
+func (uc *SessionUseCase) start(userID uuid.UUID, impersonatorID *uuid.UUID, client ClientInfo, expiresAt time.Time) (*entities.Session, error) {
+	now := time.Now()
+	session := &entities.Session{
+		UserID:         userID,
+		DeviceName:     client.DeviceName,
+		UserAgent:      client.UserAgent,
+		IPAddress:      client.IPAddress,
+		ImpersonatorID: impersonatorID,
+		LastSeenAt:     now,
+		ExpiresAt:      expiresAt,
+	}
+
+	if err := uc.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Validate reports an error if the session does not belong to the user, has
+// been revoked or has expired.
+func (uc *SessionUseCase) Validate(sessionID, userID uuid.UUID) error {
+	if uc.sessionCache != nil {
+		active, found, err := uc.sessionCache.GetStatus(sessionID)
+		if err == nil && found {
+			if !active {
+				return errSessionRevoked
+			}
+			return nil
+		}
+	}
+
+	session, err := uc.sessionRepo.GetByID(sessionID)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return errSessionRevoked
+	}
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return errSessionRevoked
+	}
+
+	active := session.IsActive()
+	if uc.sessionCache != nil {
+		ttl := sessionStatusTTL
+		if remaining := time.Until(session.ExpiresAt); remaining < ttl {
+			ttl = remaining
+		}
+		if ttl > 0 {
+			_ = uc.sessionCache.SetStatus(sessionID, active, ttl)
+		}
+	}
+
+	if !active {
+		return errSessionRevoked
+	}
+	return nil
+}
+
+// Touch records activity on the session, which expires at expiresAt,
+// writing at most once per lastSeenInterval per session.
+func (uc *SessionUseCase) Touch(sessionID uuid.UUID, expiresAt time.Time) {
+	now := time.Now()
+
+	uc.mu.Lock()
+	if last, ok := uc.lastTouch[sessionID]; ok && now.Sub(last) < uc.lastSeenInterval {
+		uc.mu.Unlock()
+		return
+	}
+	uc.lastTouch[sessionID] = now
+	if len(uc.lastTouch) > 10000 {
+		for id, last := range uc.lastTouch {
+			if now.Sub(last) >= uc.lastSeenInterval {
+				delete(uc.lastTouch, id)
+			}
+		}
+	}
+	uc.mu.Unlock()
+
+	if ttl := expiresAt.Sub(now); uc.sessionCache != nil && ttl > 0 {
+		if err := uc.sessionCache.SetLastSeen(sessionID, now, ttl); err == nil {
+			return
+		}
+	}
+	_ = uc.sessionRepo.UpdateLastSeen(sessionID, now)
+}
+
+func (uc *SessionUseCase) ListSessions(userID uuid.UUID) ([]*entities.Session, error) {
+	sessions, err := uc.sessionRepo.ListActiveByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if uc.sessionCache == nil || len(sessions) == 0 {
+		return sessions, nil
+	}
+
+	ids := make([]uuid.UUID, len(sessions))
+	for i, session := range sessions {
+		ids[i] = session.ID
+	}
+
+	lastSeen, err := uc.sessionCache.GetLastSeen(ids)
+	if err != nil {
+		return sessions, nil
+	}
+
+	// Overlay the buffered timestamps and persist them while we are here.
+	for _, session := range sessions {
+		if seen, ok := lastSeen[session.ID]; ok && seen.After(session.LastSeenAt) {
+			session.LastSeenAt = seen
+			_ = uc.sessionRepo.UpdateLastSeen(session.ID, seen)
+		}
+	}
+	return sessions, nil
+}
+
+func (uc *SessionUseCase) RevokeSession(userID, sessionID uuid.UUID) error {
+	session, err := uc.sessionRepo.GetByID(sessionID)
+	if err != nil {
+		return apperrors.MapNotFound(err, apperrors.CodeSessionNotFound, "session not found")
+	}
+	if session.UserID != userID {
+		return apperrors.NotFound(apperrors.CodeSessionNotFound, "session not found")
+	}
+
+	if err := uc.sessionRepo.Revoke(sessionID); err != nil {
+		return err
+	}
+
+	uc.markRevoked(sessionID, session.ExpiresAt)
+	return nil
+}
+
+func (uc *SessionUseCase) RevokeAllSessions(userID uuid.UUID) error {
+	ids, err := uc.sessionRepo.RevokeAllByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	// A cached "active" status expires within sessionStatusTTL, after which
+	// the database answers, so overriding it for that long is enough.
+	for _, id := range ids {
+		uc.markRevoked(id, time.Now().Add(sessionStatusTTL))
+	}
+	return nil
+}
+
+func (uc *SessionUseCase) markRevoked(sessionID uuid.UUID, until time.Time) {
+	if uc.sessionCache == nil {
+		return
+	}
+	if ttl := time.Until(until); ttl > 0 {
+		_ = uc.sessionCache.SetStatus(sessionID, false, ttl)
+	}
+}
@@ -0,0 +1,69 @@
+package usecases
+
+import (
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+// StockAlertUseCase exposes low-stock alerts to admins and back-in-stock
+// subscriptions to customers. Both are raised and delivered by the
+// inventory watcher as stock moves.
+type StockAlertUseCase struct {
+	alertRepo        entities.StockAlertRepository
+	subscriptionRepo entities.BackInStockRepository
+	productRepo      entities.ProductRepository
+}
+
+type ListStockAlertsQuery struct {
+	Status string `json:"status" query:"status" validate:"omitempty,oneof=open resolved"`
+}
+
+func NewStockAlertUseCase(alertRepo entities.StockAlertRepository, subscriptionRepo entities.BackInStockRepository, productRepo entities.ProductRepository) *StockAlertUseCase {
+	return &StockAlertUseCase{
+		alertRepo:        alertRepo,
+		subscriptionRepo: subscriptionRepo,
+		productRepo:      productRepo,
+	}
+}
+
+// ListAlerts returns stock alerts newest first, all of them when status is
+// empty.
+func (uc *StockAlertUseCase) ListAlerts(status entities.StockAlertStatus, params pagination.Params) (*pagination.Page[*entities.StockAlert], error) {
+	return uc.alertRepo.List(status, params)
+}
+
+// ResolveAlert closes an alert by hand, for instance once stock has been
+// reordered. It reopens only if stock rises above the threshold and falls
+// to it again.
+func (uc *StockAlertUseCase) ResolveAlert(id uuid.UUID) (*entities.StockAlert, error) {
+	if err := uc.alertRepo.Resolve(id); err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeStockAlertNotFound, "stock alert not found")
+	}
+	return uc.alertRepo.GetByID(id)
+}
+
+// Subscribe asks for userID to be notified when the product can be bought
+// again. Subscribing again after a notification renews the subscription.
+func (uc *StockAlertUseCase) Subscribe(productID, userID uuid.UUID) (*entities.BackInStockSubscription, error) {
+	product, err := uc.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeProductNotFound, "product not found")
+	}
+	if product.IsInStock() {
+		return nil, apperrors.Conflict(apperrors.CodeProductInStock, "product is in stock")
+	}
+
+	subscription := &entities.BackInStockSubscription{ProductID: productID, UserID: userID}
+	if err := uc.subscriptionRepo.Subscribe(subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// Unsubscribe succeeds whether or not userID was subscribed.
+func (uc *StockAlertUseCase) Unsubscribe(productID, userID uuid.UUID) error {
+	return uc.subscriptionRepo.Unsubscribe(productID, userID)
+}
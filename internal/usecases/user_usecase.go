@@ -4,48 +4,85 @@ import (
 	"errors"
 	"time"
 
+	"prototype-fiber/internal/domain/apperrors"
 	"prototype-fiber/internal/domain/entities"
-	"prototype-fiber/pkg/config"
+	"prototype-fiber/pkg/password"
+	"prototype-fiber/pkg/patch"
+	"prototype-fiber/pkg/tokens"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+var errInvalidCredentials = apperrors.Unauthorized(apperrors.CodeInvalidLogin, "invalid credentials")
+
 type UserUseCase struct {
-	userRepo   entities.UserRepository
-	jwtConfig  config.JWTConfig
+	userRepo       entities.UserRepository
+	sessionUseCase *SessionUseCase
+	keySet         *tokens.KeySet
+	passwordPolicy *password.Policy
 }
 
 type AuthRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
+	Email      string `json:"email" validate:"required,email"`
+	Password   string `json:"password" validate:"required,max=256"`
+	DeviceName string `json:"device_name" validate:"max=100"`
 }
 
 type RegisterRequest struct {
-	Email     string `json:"email" validate:"required,email"`
-	Password  string `json:"password" validate:"required,min=6"`
-	FirstName string `json:"first_name" validate:"required"`
-	LastName  string `json:"last_name" validate:"required"`
-	Phone     string `json:"phone"`
+	Email      string `json:"email" validate:"required,email,max=254"`
+	Password   string `json:"password" validate:"required"`
+	FirstName  string `json:"first_name" validate:"required,max=100"`
+	LastName   string `json:"last_name" validate:"required,max=100"`
+	Phone      string `json:"phone" validate:"omitempty,max=32"`
+	DeviceName string `json:"device_name" validate:"max=100"`
+}
+
+// ReplaceProfileRequest is the body of PUT /users/profile.
+type ReplaceProfileRequest struct {
+	FirstName string `json:"first_name" validate:"required,max=100"`
+	LastName  string `json:"last_name" validate:"required,max=100"`
+	Phone     string `json:"phone" validate:"omitempty,max=32"`
+}
+
+// UpdateProfileRequest is the body of PATCH /users/profile, applied as a
+// JSON Merge Patch.
+type UpdateProfileRequest struct {
+	FirstName *string `json:"first_name" validate:"omitnil,min=1,max=100"`
+	LastName  *string `json:"last_name" validate:"omitnil,min=1,max=100"`
+	Phone     *string `json:"phone" validate:"omitnil,max=32" patch:"nullable"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
 }
 
 type AuthResponse struct {
-	Token string        `json:"token"`
+	Token string         `json:"token"`
 	User  *entities.User `json:"user"`
+	// Cart is the user's cart after a guest cart was merged into it.
+	Cart *entities.Cart `json:"cart,omitempty"`
 }
 
-func NewUserUseCase(userRepo entities.UserRepository, jwtConfig config.JWTConfig) *UserUseCase {
+func NewUserUseCase(userRepo entities.UserRepository, sessionUseCase *SessionUseCase, keySet *tokens.KeySet, passwordPolicy *password.Policy) *UserUseCase {
 	return &UserUseCase{
-		userRepo:  userRepo,
-		jwtConfig: jwtConfig,
+		userRepo:       userRepo,
+		sessionUseCase: sessionUseCase,
+		keySet:         keySet,
+		passwordPolicy: passwordPolicy,
 	}
 }
 
-func (uc *UserUseCase) Register(req *RegisterRequest) (*AuthResponse, error) {
+func (uc *UserUseCase) Register(req *RegisterRequest, client ClientInfo) (*AuthResponse, error) {
 	// Check if user already exists
 	existingUser, _ := uc.userRepo.GetByEmail(req.Email)
 	if existingUser != nil {
-		return nil, errors.New("user already exists")
+		return nil, apperrors.Conflict(apperrors.CodeUserExists, "user already exists")
+	}
+
+	if err := uc.passwordPolicy.Validate(req.Password, req.Email); err != nil {
+		return nil, apperrors.Validation(apperrors.CodeWeakPassword, err.Error())
 	}
 
 	// Create new user
@@ -66,7 +103,8 @@ func (uc *UserUseCase) Register(req *RegisterRequest) (*AuthResponse, error) {
 		return nil, err
 	}
 
-	token, err := uc.generateToken(user)
+	client.DeviceName = req.DeviceName
+	token, err := uc.startSession(user, client)
 	if err != nil {
 		return nil, err
 	}
@@ -77,21 +115,33 @@ func (uc *UserUseCase) Register(req *RegisterRequest) (*AuthResponse, error) {
 	}, nil
 }
 
-func (uc *UserUseCase) Login(req *AuthRequest) (*AuthResponse, error) {
+func (uc *UserUseCase) Login(req *AuthRequest, client ClientInfo) (*AuthResponse, error) {
 	user, err := uc.userRepo.GetByEmail(req.Email)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return nil, errInvalidCredentials
+	}
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		return nil, err
 	}
 
 	if !user.CheckPassword(req.Password) {
-		return nil, errors.New("invalid credentials")
+		return nil, errInvalidCredentials
 	}
 
 	if !user.IsActive {
-		return nil, errors.New("account is deactivated")
+		return nil, apperrors.Forbidden(apperrors.CodeAccountDisabled, "account is deactivated")
 	}
 
-	token, err := uc.generateToken(user)
+	// Upgrade hashes made with an old algorithm or weaker parameters while
+	// the plaintext is at hand. A failure here must not block the login.
+	if user.PasswordNeedsRehash() {
+		if err := user.HashPassword(req.Password); err == nil {
+			_ = uc.userRepo.Update(user)
+		}
+	}
+
+	client.DeviceName = req.DeviceName
+	token, err := uc.startSession(user, client)
 	if err != nil {
 		return nil, err
 	}
@@ -103,24 +153,46 @@ func (uc *UserUseCase) Login(req *AuthRequest) (*AuthResponse, error) {
 }
 
 func (uc *UserUseCase) GetProfile(userID uuid.UUID) (*entities.User, error) {
-	return uc.userRepo.GetByID(userID)
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeUserNotFound, "user not found")
+	}
+	return user, nil
 }
 
-func (uc *UserUseCase) UpdateProfile(userID uuid.UUID, updates map[string]interface{}) (*entities.User, error) {
-	user, err := uc.userRepo.GetByID(userID)
+func (uc *UserUseCase) ReplaceProfile(userID uuid.UUID, req *ReplaceProfileRequest) (*entities.User, error) {
+	user, err := uc.GetProfile(userID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Update fields if provided
-	if firstName, ok := updates["first_name"].(string); ok {
-		user.FirstName = firstName
+	user.FirstName = req.FirstName
+	user.LastName = req.LastName
+	user.Phone = req.Phone
+
+	if err := uc.userRepo.Update(user); err != nil {
+		return nil, err
 	}
-	if lastName, ok := updates["last_name"].(string); ok {
-		user.LastName = lastName
+
+	return user, nil
+}
+
+func (uc *UserUseCase) UpdateProfile(userID uuid.UUID, req *UpdateProfileRequest, nulls patch.Nulls) (*entities.User, error) {
+	user, err := uc.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.FirstName != nil {
+		user.FirstName = *req.FirstName
 	}
-	if phone, ok := updates["phone"].(string); ok {
-		user.Phone = phone
+	if req.LastName != nil {
+		user.LastName = *req.LastName
+	}
+	if req.Phone != nil {
+		user.Phone = *req.Phone
+	} else if nulls.Has("phone") {
+		user.Phone = ""
 	}
 
 	if err := uc.userRepo.Update(user); err != nil {
@@ -130,14 +202,45 @@ func (uc *UserUseCase) UpdateProfile(userID uuid.UUID, updates map[string]interf
 	return user, nil
 }
 
-func (uc *UserUseCase) generateToken(user *entities.User) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": user.ID.String(),
-		"email":   user.Email,
-		"role":    user.Role,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
+func (uc *UserUseCase) ChangePassword(userID uuid.UUID, req *ChangePasswordRequest) error {
+	user, err := uc.GetProfile(userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.CheckPassword(req.CurrentPassword) {
+		return apperrors.Validation(apperrors.CodeWrongPassword, "current password is incorrect")
+	}
+
+	if req.NewPassword == req.CurrentPassword {
+		return apperrors.Validation(apperrors.CodePasswordReused, "new password must differ from the current password")
+	}
+
+	if err := uc.passwordPolicy.Validate(req.NewPassword, user.Email); err != nil {
+		return apperrors.Validation(apperrors.CodeWeakPassword, err.Error())
+	}
+
+	if err := user.HashPassword(req.NewPassword); err != nil {
+		return err
+	}
+
+	return uc.userRepo.Update(user)
+}
+
+// startSession records a new login session and issues a token bound to it.
+func (uc *UserUseCase) startSession(user *entities.User, client ClientInfo) (string, error) {
+	expiresAt := time.Now().Add(uc.keySet.TTL())
+	session, err := uc.sessionUseCase.Start(user.ID, client, expiresAt)
+	if err != nil {
+		return "", err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(uc.jwtConfig.Secret))
+	claims := &tokens.Claims{
+		UserID:    user.ID.String(),
+		Email:     user.Email,
+		Role:      string(user.Role),
+		SessionID: session.ID.String(),
+	}
+	claims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+	return uc.keySet.Issue(claims)
 }
\ No newline at end of file
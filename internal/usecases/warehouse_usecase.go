@@ -0,0 +1,187 @@
+package usecases
+
+import (
+	"errors"
+	"strings"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/pkg/patch"
+
+	"github.com/google/uuid"
+)
+
+type WarehouseUseCase struct {
+	warehouseRepo entities.WarehouseRepository
+	productRepo   entities.ProductRepository
+}
+
+type CreateWarehouseRequest struct {
+	Code     string `json:"code" validate:"required,max=32"`
+	Name     string `json:"name" validate:"required,max=100"`
+	Country  string `json:"country" validate:"omitempty,country"`
+	Priority int    `json:"priority" validate:"gte=0"`
+	Pickup   bool   `json:"pickup"`
+}
+
+// UpdateWarehouseRequest is the body of PATCH, applied as a JSON Merge
+// Patch. A null country clears it.
+type UpdateWarehouseRequest struct {
+	Code     *string `json:"code" validate:"omitnil,min=1,max=32"`
+	Name     *string `json:"name" validate:"omitnil,min=1,max=100"`
+	Country  *string `json:"country" validate:"omitnil,country" patch:"nullable"`
+	Priority *int    `json:"priority" validate:"omitnil,gte=0"`
+	Pickup   *bool   `json:"pickup"`
+}
+
+// ProductAvailability is a product's total stock and where it is held.
+type ProductAvailability struct {
+	ProductID uuid.UUID        `json:"product_id"`
+	Stock     int              `json:"stock"`
+	Locations []*LocationStock `json:"locations"`
+}
+
+// LocationStock is the stock of a product, or of one of its variants, at a
+// warehouse.
+type LocationStock struct {
+	Warehouse *entities.Warehouse `json:"warehouse"`
+	VariantID *uuid.UUID          `json:"variant_id,omitempty"`
+	Quantity  int                 `json:"quantity"`
+}
+
+func NewWarehouseUseCase(warehouseRepo entities.WarehouseRepository, productRepo entities.ProductRepository) *WarehouseUseCase {
+	return &WarehouseUseCase{
+		warehouseRepo: warehouseRepo,
+		productRepo:   productRepo,
+	}
+}
+
+func (uc *WarehouseUseCase) ListWarehouses() ([]*entities.Warehouse, error) {
+	return uc.warehouseRepo.List()
+}
+
+func (uc *WarehouseUseCase) CreateWarehouse(req *CreateWarehouseRequest) (*entities.Warehouse, error) {
+	warehouse := &entities.Warehouse{
+		Code:     warehouseCode(req.Code),
+		Name:     strings.TrimSpace(req.Name),
+		Country:  strings.ToUpper(req.Country),
+		Priority: req.Priority,
+		Pickup:   req.Pickup,
+	}
+	if err := uc.warehouseRepo.Create(warehouse); err != nil {
+		return nil, warehouseConflict(err)
+	}
+	return warehouse, nil
+}
+
+func (uc *WarehouseUseCase) GetWarehouse(id uuid.UUID) (*entities.Warehouse, error) {
+	warehouse, err := uc.warehouseRepo.GetByID(id)
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeWarehouseNotFound, "warehouse not found")
+	}
+	return warehouse, nil
+}
+
+func (uc *WarehouseUseCase) UpdateWarehouse(id uuid.UUID, req *UpdateWarehouseRequest, nulls patch.Nulls) (*entities.Warehouse, error) {
+	warehouse, err := uc.GetWarehouse(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Code != nil {
+		warehouse.Code = warehouseCode(*req.Code)
+	}
+	if req.Name != nil {
+		warehouse.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Country != nil {
+		warehouse.Country = strings.ToUpper(*req.Country)
+	} else if nulls.Has("country") {
+		warehouse.Country = ""
+	}
+	if req.Priority != nil {
+		warehouse.Priority = *req.Priority
+	}
+	if req.Pickup != nil {
+		warehouse.Pickup = *req.Pickup
+	}
+
+	if err := uc.warehouseRepo.Update(warehouse); err != nil {
+		return nil, warehouseConflict(err)
+	}
+	return warehouse, nil
+}
+
+// DeleteWarehouse removes an empty warehouse. The last one is kept, since
+// stock changes that name no warehouse need a default.
+func (uc *WarehouseUseCase) DeleteWarehouse(id uuid.UUID) error {
+	if _, err := uc.GetWarehouse(id); err != nil {
+		return err
+	}
+	hasStock, err := uc.warehouseRepo.HasStock(id)
+	if err != nil {
+		return err
+	}
+	if hasStock {
+		return apperrors.Conflict(apperrors.CodeWarehouseNotEmpty, "warehouse still holds stock")
+	}
+	warehouses, err := uc.warehouseRepo.List()
+	if err != nil {
+		return err
+	}
+	if len(warehouses) <= 1 {
+		return apperrors.Conflict(apperrors.CodeLastWarehouse, "the last warehouse cannot be deleted")
+	}
+
+	err = uc.warehouseRepo.Delete(id)
+	return apperrors.MapNotFound(err, apperrors.CodeWarehouseNotFound, "warehouse not found")
+}
+
+// GetAvailability returns a product's stock by warehouse. With pickupOnly,
+// only pickup locations are listed and inactive products are not found.
+func (uc *WarehouseUseCase) GetAvailability(productID uuid.UUID, pickupOnly bool) (*ProductAvailability, error) {
+	product, err := uc.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeProductNotFound, "product not found")
+	}
+	if pickupOnly && !product.IsActive {
+		return nil, apperrors.NotFound(apperrors.CodeProductNotFound, "product not found")
+	}
+
+	warehouses, err := uc.warehouseRepo.List()
+	if err != nil {
+		return nil, err
+	}
+	levels, err := uc.warehouseRepo.ListStockLevels([]uuid.UUID{productID})
+	if err != nil {
+		return nil, err
+	}
+
+	availability := &ProductAvailability{ProductID: product.ID, Stock: product.Stock, Locations: []*LocationStock{}}
+	for _, warehouse := range warehouses {
+		if pickupOnly && !warehouse.Pickup {
+			continue
+		}
+		for _, level := range levels {
+			if level.WarehouseID == warehouse.ID {
+				availability.Locations = append(availability.Locations, &LocationStock{
+					Warehouse: warehouse,
+					VariantID: level.VariantID,
+					Quantity:  level.Quantity,
+				})
+			}
+		}
+	}
+	return availability, nil
+}
+
+func warehouseCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func warehouseConflict(err error) error {
+	if errors.Is(err, apperrors.ErrConflict) {
+		return apperrors.Conflict(apperrors.CodeWarehouseExists, "a warehouse with this code already exists")
+	}
+	return err
+}
@@ -0,0 +1,218 @@
+package usecases
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+
+	"github.com/google/uuid"
+)
+
+type WishlistUseCase struct {
+	wishlistRepo entities.WishlistRepository
+	productRepo  entities.ProductRepository
+	cartUseCase  *CartUseCase
+}
+
+type WishlistRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// AddToWishlistRequest saves a product. As in the cart, VariantID is
+// required for products sold in variants and must be omitted otherwise.
+type AddToWishlistRequest struct {
+	ProductID uuid.UUID  `json:"product_id" validate:"required"`
+	VariantID *uuid.UUID `json:"variant_id"`
+}
+
+// MoveToCartRequest moves a saved item to the cart, one unit unless
+// Quantity says otherwise.
+type MoveToCartRequest struct {
+	ProductID uuid.UUID  `json:"product_id" validate:"required"`
+	VariantID *uuid.UUID `json:"variant_id"`
+	Quantity  int        `json:"quantity" validate:"omitempty,gt=0,lte=1000"`
+}
+
+func NewWishlistUseCase(wishlistRepo entities.WishlistRepository, productRepo entities.ProductRepository, cartUseCase *CartUseCase) *WishlistUseCase {
+	return &WishlistUseCase{
+		wishlistRepo: wishlistRepo,
+		productRepo:  productRepo,
+		cartUseCase:  cartUseCase,
+	}
+}
+
+func (uc *WishlistUseCase) ListWishlists(userID uuid.UUID) ([]*entities.Wishlist, error) {
+	wishlists, err := uc.wishlistRepo.ListByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, wishlist := range wishlists {
+		wishlist.Refresh()
+	}
+	return wishlists, nil
+}
+
+func (uc *WishlistUseCase) CreateWishlist(userID uuid.UUID, req *WishlistRequest) (*entities.Wishlist, error) {
+	wishlist := &entities.Wishlist{
+		UserID: userID,
+		Name:   strings.TrimSpace(req.Name),
+		Items:  []entities.WishlistItem{},
+	}
+	if err := uc.wishlistRepo.Create(wishlist); err != nil {
+		return nil, err
+	}
+	return wishlist, nil
+}
+
+func (uc *WishlistUseCase) GetWishlist(id, userID uuid.UUID) (*entities.Wishlist, error) {
+	return uc.ownWishlist(id, userID)
+}
+
+func (uc *WishlistUseCase) RenameWishlist(id, userID uuid.UUID, req *WishlistRequest) (*entities.Wishlist, error) {
+	wishlist, err := uc.ownWishlist(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	wishlist.Name = strings.TrimSpace(req.Name)
+	if err := uc.wishlistRepo.Update(wishlist); err != nil {
+		return nil, err
+	}
+	return wishlist, nil
+}
+
+func (uc *WishlistUseCase) DeleteWishlist(id, userID uuid.UUID) error {
+	if _, err := uc.ownWishlist(id, userID); err != nil {
+		return err
+	}
+	return uc.wishlistRepo.Delete(id)
+}
+
+// AddItem saves a product to the wishlist. Saving it again changes
+// nothing.
+func (uc *WishlistUseCase) AddItem(id, userID uuid.UUID, req *AddToWishlistRequest) (*entities.Wishlist, error) {
+	if _, err := uc.ownWishlist(id, userID); err != nil {
+		return nil, err
+	}
+	product, err := uc.productRepo.GetByID(req.ProductID)
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeProductNotFound, "product not found")
+	}
+	if _, err := resolveVariant(product, req.VariantID); err != nil {
+		return nil, err
+	}
+
+	item := &entities.WishlistItem{ProductID: req.ProductID, VariantID: req.VariantID}
+	if err := uc.wishlistRepo.AddItem(id, item); err != nil {
+		return nil, err
+	}
+	return uc.ownWishlist(id, userID)
+}
+
+func (uc *WishlistUseCase) RemoveItem(id, userID, productID uuid.UUID, variantID *uuid.UUID) (*entities.Wishlist, error) {
+	if _, err := uc.ownWishlist(id, userID); err != nil {
+		return nil, err
+	}
+	if err := uc.wishlistRepo.RemoveItem(id, productID, variantID); err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeWishlistItemNotFound, "item is not in the wishlist")
+	}
+	return uc.ownWishlist(id, userID)
+}
+
+// MoveToCart adds a saved item to the user's cart with the cart's own stock
+// checks, and takes it off the wishlist once it is there.
+func (uc *WishlistUseCase) MoveToCart(id, userID uuid.UUID, req *MoveToCartRequest) (*entities.Cart, error) {
+	wishlist, err := uc.ownWishlist(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !wishlist.Contains(req.ProductID, req.VariantID) {
+		return nil, apperrors.NotFound(apperrors.CodeWishlistItemNotFound, "item is not in the wishlist")
+	}
+
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+	cart, err := uc.cartUseCase.AddToCart(userID, &AddToCartRequest{
+		ProductID: req.ProductID,
+		VariantID: req.VariantID,
+		Quantity:  quantity,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.wishlistRepo.RemoveItem(id, req.ProductID, req.VariantID); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+// Share gives the wishlist a new share token, replacing any previous one
+// so that old links stop working.
+func (uc *WishlistUseCase) Share(id, userID uuid.UUID) (*entities.Wishlist, error) {
+	wishlist, err := uc.ownWishlist(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	token, err := newShareToken()
+	if err != nil {
+		return nil, err
+	}
+	wishlist.ShareToken = &token
+	if err := uc.wishlistRepo.Update(wishlist); err != nil {
+		return nil, err
+	}
+	return wishlist, nil
+}
+
+func (uc *WishlistUseCase) Unshare(id, userID uuid.UUID) (*entities.Wishlist, error) {
+	wishlist, err := uc.ownWishlist(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	wishlist.ShareToken = nil
+	if err := uc.wishlistRepo.Update(wishlist); err != nil {
+		return nil, err
+	}
+	return wishlist, nil
+}
+
+// GetSharedWishlist returns the wishlist shared under token, without the
+// token itself.
+func (uc *WishlistUseCase) GetSharedWishlist(token string) (*entities.Wishlist, error) {
+	wishlist, err := uc.wishlistRepo.GetByShareToken(token)
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeWishlistNotFound, "wishlist not found")
+	}
+	wishlist.ShareToken = nil
+	wishlist.Refresh()
+	return wishlist, nil
+}
+
+// ownWishlist loads one of the user's wishlists. Other users' wishlists
+// are reported as not found rather than forbidden, so their IDs cannot be
+// probed.
+func (uc *WishlistUseCase) ownWishlist(id, userID uuid.UUID) (*entities.Wishlist, error) {
+	wishlist, err := uc.wishlistRepo.GetByID(id)
+	if err == nil && wishlist.UserID != userID {
+		err = apperrors.ErrNotFound
+	}
+	if err != nil {
+		return nil, apperrors.MapNotFound(err, apperrors.CodeWishlistNotFound, "wishlist not found")
+	}
+	wishlist.Refresh()
+	return wishlist, nil
+}
+
+// newShareToken returns 256 random bits, URL-safe.
+func newShareToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -4,15 +4,26 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	App      AppConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
+	App        AppConfig
+	Database   DatabaseConfig
+	Redis      RedisConfig
+	JWT        JWTConfig
+	Password   PasswordConfig
+	Session    SessionConfig
+	Search     SearchConfig
+	Storage    StorageConfig
+	Media      MediaConfig
+	Import     ImportConfig
+	Allocation AllocationConfig
+	Cart       CartConfig
+	Mail       MailConfig
 }
 
 type AppConfig struct {
@@ -38,8 +49,92 @@ type RedisConfig struct {
 }
 
 type JWTConfig struct {
-	Secret string
-	Expire string
+	Algorithm   string
+	KeysDir     string
+	ActiveKeyID string
+	Issuer      string
+	Audience    string
+	Expire      string
+}
+
+type PasswordConfig struct {
+	Algorithm         string
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+	BcryptCost        int
+	MinLength         int
+	MaxLength         int
+}
+
+type SessionConfig struct {
+	LastSeenInterval time.Duration
+	ImpersonationTTL time.Duration
+}
+
+type SearchConfig struct {
+	// Engine is "postgres" (full-text search on the products table) or
+	// "embedded" (an in-process index rebuilt from the database at startup).
+	Engine string
+}
+
+type StorageConfig struct {
+	// Driver is "local" (files under LocalDir) or "s3" (any S3-compatible
+	// object store).
+	Driver   string
+	LocalDir string
+	// PublicURL is the base URL media is served from. It defaults to /media
+	// for the local driver and to the bucket URL for s3.
+	PublicURL   string
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	// S3PathStyle addresses the bucket as endpoint/bucket instead of
+	// bucket.endpoint, as most self-hosted stores expect.
+	S3PathStyle bool
+}
+
+type MediaConfig struct {
+	MaxUploadSize  int64
+	ThumbnailSizes []int
+}
+
+type ImportConfig struct {
+	MaxUploadSize int64
+}
+
+type AllocationConfig struct {
+	// Strategy ranks warehouses when allocating an order: "priority" by
+	// warehouse priority alone, "nearest" with warehouses in the shipping
+	// country first.
+	Strategy string
+	// AllowSplit lets an order ship from several warehouses when no single
+	// one can fulfil it.
+	AllowSplit bool
+}
+
+type CartConfig struct {
+	// GuestTTL is how long a guest cart token stays valid.
+	GuestTTL time.Duration
+	// AbandonAfter is how long a cart with items must go untouched to count
+	// as abandoned; the job looks for such carts every AbandonCheckInterval.
+	AbandonAfter         time.Duration
+	AbandonCheckInterval time.Duration
+	// Retention is how long an untouched cart is kept before it is purged.
+	Retention time.Duration
+	// RecoveryURL is the storefront page recovery emails link to, with the
+	// cart's token appended as ?cart_token=.
+	RecoveryURL string
+}
+
+type MailConfig struct {
+	// Driver is "file", which writes each email to FileDir instead of
+	// sending it.
+	Driver  string
+	From    string
+	FileDir string
 }
 
 func Load() *Config {
@@ -49,6 +144,63 @@ func Load() *Config {
 	}
 
 	redisDB, _ := strconv.Atoi(getEnv("REDIS_DB", "0"))
+	// Argon2 settings that are out of range or unparsable are left at zero,
+	// which password.NewServiceFromConfig refuses at startup.
+	argonMemory, err := strconv.ParseUint(getEnv("PASSWORD_ARGON2_MEMORY_KB", "65536"), 10, 32)
+	if err != nil {
+		argonMemory = 0
+	}
+	argonIterations, err := strconv.ParseUint(getEnv("PASSWORD_ARGON2_ITERATIONS", "3"), 10, 32)
+	if err != nil {
+		argonIterations = 0
+	}
+	argonParallelism, err := strconv.ParseUint(getEnv("PASSWORD_ARGON2_PARALLELISM", "2"), 10, 8)
+	if err != nil {
+		argonParallelism = 0
+	}
+	bcryptCost, _ := strconv.Atoi(getEnv("PASSWORD_BCRYPT_COST", "12"))
+	passwordMinLength, _ := strconv.Atoi(getEnv("PASSWORD_MIN_LENGTH", "8"))
+	passwordMaxLength, _ := strconv.Atoi(getEnv("PASSWORD_MAX_LENGTH", "128"))
+	lastSeenInterval, err := time.ParseDuration(getEnv("SESSION_LAST_SEEN_INTERVAL", "1m"))
+	if err != nil {
+		lastSeenInterval = time.Minute
+	}
+	impersonationTTL, err := time.ParseDuration(getEnv("IMPERSONATION_TTL", "15m"))
+	if err != nil {
+		impersonationTTL = 15 * time.Minute
+	}
+	s3PathStyle, _ := strconv.ParseBool(getEnv("S3_PATH_STYLE", "true"))
+	maxUploadMB, _ := strconv.Atoi(getEnv("MEDIA_MAX_UPLOAD_MB", "10"))
+	if maxUploadMB <= 0 {
+		maxUploadMB = 10
+	}
+	importMaxMB, _ := strconv.Atoi(getEnv("IMPORT_MAX_UPLOAD_MB", "50"))
+	if importMaxMB <= 0 {
+		importMaxMB = 50
+	}
+	allowSplit, _ := strconv.ParseBool(getEnv("ALLOCATION_ALLOW_SPLIT", "true"))
+	guestCartTTL, err := time.ParseDuration(getEnv("CART_GUEST_TTL", "720h"))
+	if err != nil {
+		guestCartTTL = 30 * 24 * time.Hour
+	}
+	abandonAfter, err := time.ParseDuration(getEnv("CART_ABANDON_AFTER", "24h"))
+	if err != nil {
+		abandonAfter = 24 * time.Hour
+	}
+	abandonCheckInterval, err := time.ParseDuration(getEnv("CART_ABANDON_CHECK_INTERVAL", "1h"))
+	if err != nil || abandonCheckInterval <= 0 {
+		abandonCheckInterval = time.Hour
+	}
+	cartRetention, err := time.ParseDuration(getEnv("CART_RETENTION", "2160h"))
+	if err != nil {
+		cartRetention = 90 * 24 * time.Hour
+	}
+	var thumbnailSizes []int
+	for _, size := range strings.Split(getEnv("MEDIA_THUMBNAIL_SIZES", "150,400,800"), ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(size)); err == nil && n > 0 {
+			thumbnailSizes = append(thumbnailSizes, n)
+		}
+	}
 
 	return &Config{
 		App: AppConfig{
@@ -71,8 +223,62 @@ func Load() *Config {
 			DB:       redisDB,
 		},
 		JWT: JWTConfig{
-			Secret: getEnv("JWT_SECRET", "your-secret-key"),
-			Expire: getEnv("JWT_EXPIRE", "24h"),
+			Algorithm:   getEnv("JWT_ALGORITHM", "EdDSA"),
+			KeysDir:     getEnv("JWT_KEYS_DIR", ""),
+			ActiveKeyID: getEnv("JWT_ACTIVE_KID", ""),
+			Issuer:      getEnv("JWT_ISSUER", "prototype-fiber"),
+			Audience:    getEnv("JWT_AUDIENCE", "prototype-fiber-api"),
+			Expire:      getEnv("JWT_EXPIRE", "24h"),
+		},
+		Password: PasswordConfig{
+			Algorithm:         getEnv("PASSWORD_ALGORITHM", "argon2id"),
+			Argon2Memory:      uint32(argonMemory),
+			Argon2Iterations:  uint32(argonIterations),
+			Argon2Parallelism: uint8(argonParallelism),
+			BcryptCost:        bcryptCost,
+			MinLength:         passwordMinLength,
+			MaxLength:         passwordMaxLength,
+		},
+		Session: SessionConfig{
+			LastSeenInterval: lastSeenInterval,
+			ImpersonationTTL: impersonationTTL,
+		},
+		Search: SearchConfig{
+			Engine: getEnv("SEARCH_ENGINE", "postgres"),
+		},
+		Storage: StorageConfig{
+			Driver:      getEnv("STORAGE_DRIVER", "local"),
+			LocalDir:    getEnv("STORAGE_LOCAL_DIR", "./uploads"),
+			PublicURL:   getEnv("STORAGE_PUBLIC_URL", ""),
+			S3Endpoint:  getEnv("S3_ENDPOINT", ""),
+			S3Region:    getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:    getEnv("S3_BUCKET", ""),
+			S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+			S3PathStyle: s3PathStyle,
+		},
+		Media: MediaConfig{
+			MaxUploadSize:  int64(maxUploadMB) << 20,
+			ThumbnailSizes: thumbnailSizes,
+		},
+		Import: ImportConfig{
+			MaxUploadSize: int64(importMaxMB) << 20,
+		},
+		Allocation: AllocationConfig{
+			Strategy:   getEnv("ALLOCATION_STRATEGY", "priority"),
+			AllowSplit: allowSplit,
+		},
+		Cart: CartConfig{
+			GuestTTL:             guestCartTTL,
+			AbandonAfter:         abandonAfter,
+			AbandonCheckInterval: abandonCheckInterval,
+			Retention:            cartRetention,
+			RecoveryURL:          getEnv("CART_RECOVERY_URL", "http://localhost:3000/cart/recover"),
+		},
+		Mail: MailConfig{
+			Driver:  getEnv("MAIL_DRIVER", "file"),
+			From:    getEnv("MAIL_FROM", "shop@example.com"),
+			FileDir: getEnv("MAIL_FILE_DIR", "./mail"),
 		},
 	}
 }
@@ -82,4 +288,4 @@ func getEnv(key, defaultValue string) string {
 		return value
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
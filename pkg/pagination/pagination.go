@@ -0,0 +1,178 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+	// MaxOffset bounds offset pagination; deeper pages must use a cursor.
+	MaxOffset = 10000
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the keyset position of the last item on a page. Lists are
+// ordered newest first by (created_at, id) unless a sort is requested, in
+// which case Sort names it and Value holds the item's sort column; id
+// always breaks ties so the position is unique and stable under inserts.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+	Sort      string
+	Value     interface{}
+}
+
+type cursorPayload struct {
+	CreatedAt time.Time   `json:"t"`
+	ID        uuid.UUID   `json:"id"`
+	Sort      string      `json:"s,omitempty"`
+	Value     interface{} `json:"v,omitempty"`
+}
+
+// Encode returns the opaque form handed to clients.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(cursorPayload{CreatedAt: c.CreatedAt, ID: c.ID, Sort: c.Sort, Value: c.Value})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil || payload.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	// Newest-first cursors key on created_at, sorted ones on Value.
+	if (payload.Sort == "" && payload.CreatedAt.IsZero()) || (payload.Sort != "" && payload.Value == nil) {
+		return nil, ErrInvalidCursor
+	}
+	return &Cursor{CreatedAt: payload.CreatedAt, ID: payload.ID, Sort: payload.Sort, Value: payload.Value}, nil
+}
+
+// Params selects one page. When After is set the page is fetched by keyset,
+// otherwise by Offset.
+type Params struct {
+	Limit     int
+	Offset    int
+	After     *Cursor
+	WithTotal bool
+}
+
+// UsesCursor reports whether the page is fetched by keyset.
+func (p Params) UsesCursor() bool {
+	return p.After != nil
+}
+
+// Page is the 1-based page number for offset pagination.
+func (p Params) Page() int {
+	return p.Offset/p.Limit + 1
+}
+
+// Parse reads limit, cursor, page and include_total through get, which
+// returns the raw query parameter or "" when absent.
+func Parse(get func(key string) string, defaultLimit int) (Params, error) {
+	params := Params{Limit: defaultLimit}
+
+	if raw := get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return params, fmt.Errorf("limit must be an integer between 1 and %d", MaxLimit)
+		}
+		params.Limit = limit
+	}
+
+	rawCursor, rawPage := get("cursor"), get("page")
+	if rawCursor != "" && rawPage != "" {
+		return params, errors.New("cursor and page cannot be combined")
+	}
+
+	if rawCursor != "" {
+		cursor, err := DecodeCursor(rawCursor)
+		if err != nil {
+			return params, errors.New("cursor is invalid")
+		}
+		params.After = cursor
+	}
+
+	if rawPage != "" {
+		page, err := strconv.Atoi(rawPage)
+		if err != nil || page < 1 {
+			return params, errors.New("page must be a positive integer")
+		}
+		params.Offset = (page - 1) * params.Limit
+		if params.Offset > MaxOffset {
+			return params, fmt.Errorf("page is too deep for offset pagination; use cursor to go past %d items", MaxOffset)
+		}
+	}
+
+	if raw := get("include_total"); raw != "" {
+		withTotal, err := strconv.ParseBool(raw)
+		if err != nil {
+			return params, errors.New("include_total must be a boolean")
+		}
+		params.WithTotal = withTotal
+	}
+
+	return params, nil
+}
+
+// Page is one page of results. NextCursor is empty on the last page; Total
+// is only set when it was requested.
+type Page[T any] struct {
+	Items      []T
+	NextCursor string
+	HasMore    bool
+	Total      *int64
+}
+
+// Links builds an RFC 8288 Link header for the page, relative to base (the
+// request URL including its query). Offset pages link by page number,
+// cursor pages by cursor.
+func Links[T any](base *url.URL, params Params, page *Page[T]) string {
+	var links []string
+	add := func(rel string, set map[string]string) {
+		u := *base
+		query := u.Query()
+		query.Del("cursor")
+		query.Del("page")
+		for key, value := range set {
+			query.Set(key, value)
+		}
+		u.RawQuery = query.Encode()
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel))
+	}
+
+	add("first", nil)
+	if params.UsesCursor() {
+		if page.HasMore {
+			add("next", map[string]string{"cursor": page.NextCursor})
+		}
+		return strings.Join(links, ", ")
+	}
+
+	current := params.Page()
+	if current > 1 {
+		add("prev", map[string]string{"page": strconv.Itoa(current - 1)})
+	}
+	if page.HasMore {
+		add("next", map[string]string{"page": strconv.Itoa(current + 1)})
+	}
+	if page.Total != nil && *page.Total > 0 {
+		last := int((*page.Total + int64(params.Limit) - 1) / int64(params.Limit))
+		add("last", map[string]string{"page": strconv.Itoa(last)})
+	}
+	return strings.Join(links, ", ")
+}
@@ -0,0 +1,253 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"prototype-fiber/pkg/config"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+// Hasher hashes and verifies passwords for one algorithm. The algorithm and
+// its parameters are encoded in the stored hash, so hashes produced with old
+// settings keep verifying after the configuration changes.
+type Hasher interface {
+	Algorithm() string
+	Hash(password string) (string, error)
+	Verify(encoded, password string) (bool, error)
+	// Handles reports whether the encoded hash was produced by this algorithm.
+	Handles(encoded string) bool
+	// NeedsRehash reports whether the encoded hash uses weaker parameters
+	// than the hasher is currently configured with.
+	NeedsRehash(encoded string) bool
+}
+
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+func NewArgon2idHasher(memory, iterations uint32, parallelism uint8) *Argon2idHasher {
+	return &Argon2idHasher{
+		Memory:      memory,
+		Iterations:  iterations,
+		Parallelism: parallelism,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// validate refuses zero iterations or parallelism, on which argon2 panics.
+func (h *Argon2idHasher) validate() error {
+	if h.Iterations == 0 || h.Parallelism == 0 {
+		return fmt.Errorf("argon2id iterations and parallelism must be at least 1, got t=%d, p=%d", h.Iterations, h.Parallelism)
+	}
+	return nil
+}
+
+func (h *Argon2idHasher) Algorithm() string {
+	return AlgorithmArgon2id
+}
+
+// Hash returns the PHC string format: $argon2id$v=19$m=...,t=...,p=...$salt$key
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *Argon2idHasher) Verify(encoded, password string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func (h *Argon2idHasher) Handles(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, _, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params.Memory < h.Memory ||
+		params.Iterations < h.Iterations ||
+		params.Parallelism < h.Parallelism ||
+		uint32(len(key)) < h.KeyLength
+}
+
+func decodeArgon2id(encoded string) (*Argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return nil, nil, nil, ErrUnknownHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, nil, nil, err
+	}
+	if version != argon2.Version {
+		return nil, nil, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	params := &Argon2idHasher{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := params.validate(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return params, salt, key, nil
+}
+
+type BcryptHasher struct {
+	Cost int
+}
+
+func NewBcryptHasher(cost int) *BcryptHasher {
+	return &BcryptHasher{Cost: cost}
+}
+
+func (h *BcryptHasher) Algorithm() string {
+	return AlgorithmBcrypt
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+func (h *BcryptHasher) Verify(encoded, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (h *BcryptHasher) Handles(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
+
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost < h.Cost
+}
+
+// Service hashes new passwords with the preferred hasher and verifies
+// existing hashes with whichever registered hasher produced them.
+type Service struct {
+	preferred Hasher
+	hashers   []Hasher
+}
+
+func NewService(preferred Hasher, legacy ...Hasher) *Service {
+	return &Service{
+		preferred: preferred,
+		hashers:   append([]Hasher{preferred}, legacy...),
+	}
+}
+
+func NewServiceFromConfig(cfg config.PasswordConfig) (*Service, error) {
+	argon := NewArgon2idHasher(cfg.Argon2Memory, cfg.Argon2Iterations, cfg.Argon2Parallelism)
+	if err := argon.validate(); err != nil {
+		return nil, err
+	}
+	bc := NewBcryptHasher(cfg.BcryptCost)
+
+	switch cfg.Algorithm {
+	case AlgorithmArgon2id:
+		return NewService(argon, bc), nil
+	case AlgorithmBcrypt:
+		return NewService(bc, argon), nil
+	default:
+		return nil, fmt.Errorf("unsupported password algorithm %q", cfg.Algorithm)
+	}
+}
+
+func (s *Service) Hash(password string) (string, error) {
+	return s.preferred.Hash(password)
+}
+
+func (s *Service) Verify(encoded, password string) bool {
+	for _, h := range s.hashers {
+		if h.Handles(encoded) {
+			ok, err := h.Verify(encoded, password)
+			return err == nil && ok
+		}
+	}
+	return false
+}
+
+// NeedsRehash reports whether the hash was produced by a different algorithm
+// than the preferred one, or with weaker parameters.
+func (s *Service) NeedsRehash(encoded string) bool {
+	if !s.preferred.Handles(encoded) {
+		return true
+	}
+	return s.preferred.NeedsRehash(encoded)
+}
+
+var (
+	defaultMu      sync.RWMutex
+	defaultService = NewService(NewArgon2idHasher(64*1024, 3, 2), NewBcryptHasher(bcrypt.DefaultCost))
+)
+
+// Default returns the service used by entities.User. It is argon2id with
+// bcrypt verification until SetDefault is called at startup.
+func Default() *Service {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultService
+}
+
+func SetDefault(s *Service) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultService = s
+}
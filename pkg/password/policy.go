@@ -0,0 +1,77 @@
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+var (
+	ErrTooShort       = errors.New("password is too short")
+	ErrTooLong        = errors.New("password is too long")
+	ErrCommonPassword = errors.New("password is too common")
+	ErrMatchesEmail   = errors.New("password must not match the email address")
+)
+
+// Policy enforces the rules a new password must satisfy.
+type Policy struct {
+	MinLength int
+	MaxLength int
+	denylist  map[string]struct{}
+}
+
+// NewPolicy builds a policy that rejects the bundled list of common and
+// breached passwords in addition to the length limits.
+func NewPolicy(minLength, maxLength int) *Policy {
+	return &Policy{
+		MinLength: minLength,
+		MaxLength: maxLength,
+		denylist:  parseDenylist(commonPasswordsFile),
+	}
+}
+
+func parseDenylist(contents string) map[string]struct{} {
+	denylist := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		denylist[strings.ToLower(line)] = struct{}{}
+	}
+	return denylist
+}
+
+// Validate checks the password against the policy. The email is compared
+// case-insensitively, both in full and by its local part.
+func (p *Policy) Validate(password, email string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return fmt.Errorf("%w: minimum is %d characters", ErrTooShort, p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return fmt.Errorf("%w: maximum is %d characters", ErrTooLong, p.MaxLength)
+	}
+
+	lowered := strings.ToLower(password)
+	if _, found := p.denylist[lowered]; found {
+		return ErrCommonPassword
+	}
+
+	if email != "" {
+		email = strings.ToLower(strings.TrimSpace(email))
+		local, _, _ := strings.Cut(email, "@")
+		if lowered == email || lowered == local {
+			return ErrMatchesEmail
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"strings"
+)
+
+const ContentTypeMergePatch = "application/merge-patch+json"
+
+// IsMergePatch reports whether a request's Content-Type can carry a merge
+// patch. Plain application/json is tolerated for clients written before
+// PATCH took merge patches; any other type is refused.
+func IsMergePatch(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == ContentTypeMergePatch || mediaType == "application/json"
+}
+
+// Nulls is the set of members a merge patch explicitly set to null, keyed
+// by JSON name. Per RFC 7396 a null member removes (clears) the field,
+// while an absent member leaves it unchanged.
+type Nulls map[string]bool
+
+func (n Nulls) Has(field string) bool {
+	return n[field]
+}
+
+// FieldError reports a member that cannot be applied.
+type FieldError struct {
+	Field   string
+	Rule    string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// DecodeStrict decodes a JSON object into dst and rejects members that dst
+// does not declare.
+func DecodeStrict(body []byte, dst interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return translate(err)
+	}
+	if decoder.More() {
+		return errors.New("request body must contain a single JSON object")
+	}
+	return nil
+}
+
+// DecodeMergePatch decodes a JSON Merge Patch document into dst, a struct
+// of pointer fields, and returns the members set to null. Only fields
+// tagged `patch:"nullable"` may be nulled; nulling any other field is an
+// error, as is any member dst does not declare.
+func DecodeMergePatch(body []byte, dst interface{}) (Nulls, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil {
+		return nil, errors.New("merge patch must be a JSON object")
+	}
+
+	if err := DecodeStrict(body, dst); err != nil {
+		return nil, err
+	}
+
+	nullable := nullableFields(dst)
+	nulls := make(Nulls)
+	for name, raw := range members {
+		if !bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			continue
+		}
+		if !nullable[name] {
+			return nil, &FieldError{Field: name, Rule: "not_nullable", Message: fmt.Sprintf("%s cannot be null", name)}
+		}
+		nulls[name] = true
+	}
+	return nulls, nil
+}
+
+func nullableFields(dst interface{}) map[string]bool {
+	t := reflect.TypeOf(dst)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	result := make(map[string]bool)
+	if t.Kind() != reflect.Struct {
+		return result
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Tag.Get("patch") != "nullable" {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		result[name] = true
+	}
+	return result
+}
+
+func translate(err error) error {
+	message := err.Error()
+	if field, found := strings.CutPrefix(message, "json: unknown field "); found {
+		field = strings.Trim(field, `"`)
+		return &FieldError{Field: field, Rule: "unknown_field", Message: fmt.Sprintf("unknown field %s", field)}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return &FieldError{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: fmt.Sprintf("%s must be of type %s", typeErr.Field, typeErr.Type.String()),
+		}
+	}
+	return err
+}
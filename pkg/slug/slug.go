@@ -0,0 +1,16 @@
+// Package slug derives URL-safe identifiers from display names.
+package slug
+
+import (
+	"regexp"
+	"strings"
+)
+
+var separators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Make lowercases s and joins its runs of ASCII letters and digits with
+// hyphens: "Mugs & Cups" becomes "mugs-cups". It returns "" when s has no
+// letters or digits. database.migrateCategories applies the same rule in SQL.
+func Make(s string) string {
+	return strings.Trim(separators.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
@@ -0,0 +1,62 @@
+package tokens
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// cartAudience keeps cart tokens and access tokens apart: both are signed
+// with the same keys, but neither verifies as the other.
+const cartAudience = ":cart"
+
+// CartClaims identify a guest cart to whoever holds the token.
+type CartClaims struct {
+	CartID string `json:"cart_id"`
+	jwt.RegisteredClaims
+}
+
+// IssueCart signs a token for the guest cart, valid for ttl.
+func (ks *KeySet) IssueCart(cartID uuid.UUID, ttl time.Duration) (string, error) {
+	key := ks.keys[ks.activeKID]
+	now := time.Now()
+
+	claims := &CartClaims{
+		CartID: cartID.String(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    ks.issuer,
+			Audience:  jwt.ClaimStrings{ks.audience + cartAudience},
+			Subject:   cartID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	token := jwt.NewWithClaims(ks.signingMethod(key), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.private)
+}
+
+// ParseCart verifies a cart token as Parse does an access token and returns
+// the cart it names.
+func (ks *KeySet) ParseCart(tokenString string) (uuid.UUID, error) {
+	claims := &CartClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, ks.keyFunc,
+		jwt.WithValidMethods(ks.validMethods()),
+		jwt.WithIssuer(ks.issuer),
+		jwt.WithAudience(ks.audience+cartAudience),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30*time.Second),
+	)
+	if err != nil || !token.Valid {
+		return uuid.Nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	cartID, err := uuid.Parse(claims.CartID)
+	if err != nil || claims.CartID != claims.Subject {
+		return uuid.Nil, fmt.Errorf("%w: subject mismatch", ErrInvalidToken)
+	}
+	return cartID, nil
+}
@@ -0,0 +1,84 @@
+package tokens
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type Claims struct {
+	UserID         string `json:"user_id"`
+	Email          string `json:"email"`
+	Role           string `json:"role"`
+	SessionID      string `json:"sid"`
+	ImpersonatorID string `json:"impersonator_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Issue signs claims for the given user with the active key, filling in the
+// registered claims (iss, aud, sub, iat, exp, jti).
+func (ks *KeySet) Issue(claims *Claims) (string, error) {
+	key := ks.keys[ks.activeKID]
+	now := time.Now()
+
+	claims.Issuer = ks.issuer
+	claims.Audience = jwt.ClaimStrings{ks.audience}
+	claims.Subject = claims.UserID
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.NotBefore = jwt.NewNumericDate(now)
+	if claims.ExpiresAt == nil {
+		claims.ExpiresAt = jwt.NewNumericDate(now.Add(ks.ttl))
+	}
+	if claims.ID == "" {
+		claims.ID = uuid.NewString()
+	}
+
+	token := jwt.NewWithClaims(ks.signingMethod(key), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.private)
+}
+
+// Parse verifies the token signature, algorithm, issuer, audience and
+// expiry and returns its claims.
+func (ks *KeySet) Parse(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, ks.keyFunc,
+		jwt.WithValidMethods(ks.validMethods()),
+		jwt.WithIssuer(ks.issuer),
+		jwt.WithAudience(ks.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(30*time.Second),
+	)
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.UserID == "" || claims.UserID != claims.Subject {
+		return nil, fmt.Errorf("%w: subject mismatch", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+func (ks *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok || kid == "" {
+		return nil, errors.New("missing kid header")
+	}
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+
+	// The algorithm is bound to the key, never taken from the token alone.
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method %q for kid %q", token.Method.Alg(), kid)
+	}
+
+	return key.public, nil
+}
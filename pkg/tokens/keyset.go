@@ -0,0 +1,242 @@
+package tokens
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"prototype-fiber/pkg/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// Key is a single signing or verification key identified by its kid.
+// Keys loaded from a public key file can only verify tokens, which is how
+// retired keys stay valid until the tokens they signed have expired.
+type Key struct {
+	ID        string
+	Algorithm string
+	private   crypto.Signer
+	public    crypto.PublicKey
+}
+
+func (k *Key) CanSign() bool {
+	return k.private != nil
+}
+
+// KeySet signs tokens with the active key and verifies tokens against every
+// key it knows, enforcing the algorithm bound to each kid.
+type KeySet struct {
+	activeKID string
+	keys      map[string]*Key
+	issuer    string
+	audience  string
+	ttl       time.Duration
+}
+
+func NewKeySet(cfg config.JWTConfig, environment string) (*KeySet, error) {
+	ttl, err := time.ParseDuration(cfg.Expire)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_EXPIRE: %w", err)
+	}
+
+	ks := &KeySet{
+		activeKID: cfg.ActiveKeyID,
+		keys:      make(map[string]*Key),
+		issuer:    cfg.Issuer,
+		audience:  cfg.Audience,
+		ttl:       ttl,
+	}
+
+	if cfg.KeysDir == "" {
+		if environment == "production" {
+			return nil, errors.New("JWT_KEYS_DIR is required in production")
+		}
+		key, err := GenerateKey("dev-"+time.Now().UTC().Format("20060102150405"), cfg.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		ks.keys[key.ID] = key
+		ks.activeKID = key.ID
+		return ks, nil
+	}
+
+	if err := ks.loadDir(cfg.KeysDir); err != nil {
+		return nil, err
+	}
+
+	active, ok := ks.keys[ks.activeKID]
+	if !ok {
+		return nil, fmt.Errorf("active key %q not found in %s", ks.activeKID, cfg.KeysDir)
+	}
+	if !active.CanSign() {
+		return nil, fmt.Errorf("active key %q has no private key", ks.activeKID)
+	}
+	if active.Algorithm != cfg.Algorithm {
+		return nil, fmt.Errorf("active key %q is %s, expected %s", ks.activeKID, active.Algorithm, cfg.Algorithm)
+	}
+
+	return ks, nil
+}
+
+// NewKeySetFromKeys builds a key set from keys that are already in memory.
+func NewKeySetFromKeys(activeKID, issuer, audience string, ttl time.Duration, keys ...*Key) (*KeySet, error) {
+	ks := &KeySet{
+		activeKID: activeKID,
+		keys:      make(map[string]*Key),
+		issuer:    issuer,
+		audience:  audience,
+		ttl:       ttl,
+	}
+	for _, key := range keys {
+		ks.keys[key.ID] = key
+	}
+	if active, ok := ks.keys[activeKID]; !ok || !active.CanSign() {
+		return nil, fmt.Errorf("active key %q cannot sign", activeKID)
+	}
+	return ks, nil
+}
+
+// GenerateKey creates a fresh private key for the given algorithm.
+func GenerateKey(kid, algorithm string) (*Key, error) {
+	switch algorithm {
+	case AlgorithmEdDSA:
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return &Key{ID: kid, Algorithm: AlgorithmEdDSA, private: priv, public: pub}, nil
+	case AlgorithmRS256:
+		priv, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		return &Key{ID: kid, Algorithm: AlgorithmRS256, private: priv, public: &priv.PublicKey}, nil
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", algorithm)
+	}
+}
+
+// PublicOnly returns a copy of the key that can verify but not sign.
+func (k *Key) PublicOnly() *Key {
+	return &Key{ID: k.ID, Algorithm: k.Algorithm, public: k.public}
+}
+
+func (ks *KeySet) loadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no keys found in %s", dir)
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := ParsePEM(kid, data)
+		if err != nil {
+			return fmt.Errorf("failed to load key %s: %w", path, err)
+		}
+		ks.keys[kid] = key
+	}
+	return nil
+}
+
+// ParsePEM reads a PKCS#8 or PKCS#1 private key, or a PKIX public key.
+func ParsePEM(kid string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return keyFromPrivate(kid, parsed)
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return keyFromPrivate(kid, parsed)
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch pub := parsed.(type) {
+		case *rsa.PublicKey:
+			return &Key{ID: kid, Algorithm: AlgorithmRS256, public: pub}, nil
+		case ed25519.PublicKey:
+			return &Key{ID: kid, Algorithm: AlgorithmEdDSA, public: pub}, nil
+		}
+		return nil, fmt.Errorf("unsupported public key type %T", parsed)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+func keyFromPrivate(kid string, parsed interface{}) (*Key, error) {
+	switch priv := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &Key{ID: kid, Algorithm: AlgorithmRS256, private: priv, public: &priv.PublicKey}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: kid, Algorithm: AlgorithmEdDSA, private: priv, public: priv.Public()}, nil
+	}
+	return nil, fmt.Errorf("unsupported private key type %T", parsed)
+}
+
+func (ks *KeySet) TTL() time.Duration {
+	return ks.ttl
+}
+
+// Keys returns every known key ordered by kid.
+func (ks *KeySet) Keys() []*Key {
+	keys := make([]*Key, 0, len(ks.keys))
+	for _, key := range ks.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys
+}
+
+func (ks *KeySet) signingMethod(key *Key) jwt.SigningMethod {
+	if key.Algorithm == AlgorithmRS256 {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodEdDSA
+}
+
+func (ks *KeySet) validMethods() []string {
+	seen := make(map[string]bool)
+	var methods []string
+	for _, key := range ks.keys {
+		if !seen[key.Algorithm] {
+			seen[key.Algorithm] = true
+			methods = append(methods, key.Algorithm)
+		}
+	}
+	return methods
+}
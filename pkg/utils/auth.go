@@ -27,4 +27,34 @@ func GetUserRoleFromContext(c *fiber.Ctx) (string, error) {
 		return "", errors.New("user role not found in context")
 	}
 	return role, nil
-}
\ No newline at end of file
+}
+
+func GetSessionIDFromContext(c *fiber.Ctx) (uuid.UUID, error) {
+	sessionIDStr, ok := c.Locals("session_id").(string)
+	if !ok {
+		return uuid.Nil, errors.New("session ID not found in context")
+	}
+
+	sessionID, err := uuid.Parse(sessionIDStr)
+	if err != nil {
+		return uuid.Nil, errors.New("invalid session ID format")
+	}
+
+	return sessionID, nil
+}
+
+// GetImpersonatorIDFromContext returns the ID of the staff member acting on
+// behalf of the user, and false when the request is not impersonated.
+func GetImpersonatorIDFromContext(c *fiber.Ctx) (uuid.UUID, bool) {
+	impersonatorIDStr, ok := c.Locals("impersonator_id").(string)
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	impersonatorID, err := uuid.Parse(impersonatorIDStr)
+	if err != nil {
+		return uuid.Nil, false
+	}
+
+	return impersonatorID, true
+}
@@ -0,0 +1,253 @@
+package tests
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/infrastructure/mail"
+	"prototype-fiber/internal/usecases"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (r *cartStore) PurgeInactive(before time.Time) (int64, error) {
+	var purged int64
+	for id, cart := range r.carts {
+		if cart.UpdatedAt.Before(before) {
+			delete(r.carts, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// memoryAbandonedCartRepo records signed-in shoppers' carts with items as
+// abandoned once per last activity, as the unique index does.
+type memoryAbandonedCartRepo struct {
+	entities.AbandonedCartRepository
+	carts     *cartStore
+	abandoned []*entities.AbandonedCart
+	stats     entities.AbandonedCartStats
+	since     time.Time
+}
+
+func (r *memoryAbandonedCartRepo) RecordAbandoned(before time.Time) (int64, error) {
+	var recorded int64
+	for _, cart := range r.carts.carts {
+		if cart.UserID != nil && len(cart.Items) > 0 && cart.UpdatedAt.Before(before) && !r.recorded(cart) {
+			r.abandoned = append(r.abandoned, &entities.AbandonedCart{ID: uuid.New(), CartID: cart.ID, UserID: cart.UserID, Email: "jane@example.com", CartUpdatedAt: cart.UpdatedAt})
+			recorded++
+		}
+	}
+	return recorded, nil
+}
+
+func (r *memoryAbandonedCartRepo) recorded(cart *entities.Cart) bool {
+	for _, abandoned := range r.abandoned {
+		if abandoned.CartID == cart.ID && abandoned.CartUpdatedAt.Equal(cart.UpdatedAt) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryAbandonedCartRepo) ClaimPending() ([]*entities.AbandonedCart, error) {
+	var claimed []*entities.AbandonedCart
+	for _, abandoned := range r.abandoned {
+		if abandoned.NotifiedAt == nil {
+			now := time.Now()
+			abandoned.NotifiedAt = &now
+			claimed = append(claimed, abandoned)
+		}
+	}
+	return claimed, nil
+}
+
+func (r *memoryAbandonedCartRepo) ReleaseClaim(id uuid.UUID) error {
+	for _, abandoned := range r.abandoned {
+		if abandoned.ID == id {
+			abandoned.NotifiedAt = nil
+		}
+	}
+	return nil
+}
+
+func (r *memoryAbandonedCartRepo) MarkRecovered() (int64, error) {
+	return 0, nil
+}
+
+func (r *memoryAbandonedCartRepo) Stats(since time.Time) (*entities.AbandonedCartStats, error) {
+	r.since = since
+	stats := r.stats
+	return &stats, nil
+}
+
+type recordingMailer struct {
+	sent []*entities.MailMessage
+	err  error
+	// onSend runs before each message is sent.
+	onSend func()
+}
+
+func (m *recordingMailer) Send(message *entities.MailMessage) error {
+	if m.onSend != nil {
+		m.onSend()
+	}
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, message)
+	return nil
+}
+
+func newAbandonedCartUseCase(t *testing.T) (*usecases.AbandonedCartUseCase, *usecases.CartUseCase, *cartStore, *memoryAbandonedCartRepo, *recordingMailer) {
+	cartUseCase, carts, ks := newGuestCartUseCase(t, tshirt())
+	abandoned := &memoryAbandonedCartRepo{carts: carts}
+	mailer := &recordingMailer{}
+	uc := usecases.NewAbandonedCartUseCase(abandoned, carts, mailer, ks, 24*time.Hour, 90*24*time.Hour, "https://shop.example.com/cart/recover")
+	return uc, cartUseCase, carts, abandoned, mailer
+}
+
+func TestAbandonedCartJob(t *testing.T) {
+	now := time.Now()
+
+	t.Run("emails a link that restores the cart", func(t *testing.T) {
+		uc, cartUseCase, carts, abandoned, mailer := newAbandonedCartUseCase(t)
+		userID := uuid.New()
+		cart, err := cartUseCase.GetOrCreateCart(userID)
+		require.NoError(t, err)
+		cart.Items = []entities.CartItem{{ProductID: uuid.New(), Quantity: 2, Product: entities.Product{Name: "Mug"}}}
+		cart.UpdatedAt = now.Add(-48 * time.Hour)
+		otherUserID := uuid.New()
+		active := &entities.Cart{UserID: &otherUserID, UpdatedAt: now, Items: []entities.CartItem{{ProductID: uuid.New(), Quantity: 1}}}
+		require.NoError(t, carts.Create(active))
+		guest := &entities.Cart{UpdatedAt: now.Add(-48 * time.Hour), Items: []entities.CartItem{{ProductID: uuid.New(), Quantity: 1}}}
+		require.NoError(t, carts.Create(guest))
+
+		run, err := uc.Run(now)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), run.Abandoned)
+		assert.Equal(t, int64(1), run.Notified)
+		require.Len(t, abandoned.abandoned, 1, "guest carts are not recorded")
+		assert.Equal(t, cart.ID, abandoned.abandoned[0].CartID)
+		assert.NotNil(t, abandoned.abandoned[0].NotifiedAt)
+
+		require.Len(t, mailer.sent, 1)
+		message := mailer.sent[0]
+		assert.Equal(t, "jane@example.com", message.To)
+		assert.Contains(t, message.Body, "- 2 x Mug")
+
+		link := message.Body[strings.Index(message.Body, "https://"):]
+		parsed, err := url.Parse(strings.TrimSpace(link))
+		require.NoError(t, err)
+		assert.Equal(t, "/cart/recover", parsed.Path)
+		restored, err := cartUseCase.RecoverCart(parsed.Query().Get("cart_token"))
+		require.NoError(t, err)
+		assert.Equal(t, cart.ID, restored.ID)
+
+		// Nothing is sent twice.
+		run, err = uc.Run(now)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), run.Notified)
+		assert.Len(t, mailer.sent, 1)
+	})
+
+	t.Run("retries failed emails", func(t *testing.T) {
+		uc, _, carts, abandoned, mailer := newAbandonedCartUseCase(t)
+		userID := uuid.New()
+		cart := &entities.Cart{UserID: &userID, UpdatedAt: now.Add(-48 * time.Hour), Items: []entities.CartItem{{ProductID: uuid.New(), Quantity: 1}}}
+		require.NoError(t, carts.Create(cart))
+		mailer.err = errors.New("smtp down")
+
+		run, err := uc.Run(now)
+		assert.ErrorIs(t, err, mailer.err)
+		require.NotNil(t, run)
+		assert.Equal(t, int64(0), run.Notified)
+		assert.Nil(t, abandoned.abandoned[0].NotifiedAt)
+
+		mailer.err = nil
+		run, err = uc.Run(now)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), run.Notified)
+	})
+
+	t.Run("claims carts before emailing", func(t *testing.T) {
+		uc, _, carts, _, mailer := newAbandonedCartUseCase(t)
+		userID := uuid.New()
+		cart := &entities.Cart{UserID: &userID, UpdatedAt: now.Add(-48 * time.Hour), Items: []entities.CartItem{{ProductID: uuid.New(), Quantity: 1}}}
+		require.NoError(t, carts.Create(cart))
+
+		// A job on another instance runs while the email goes out.
+		var concurrent *usecases.AbandonedCartRun
+		mailer.onSend = func() {
+			mailer.onSend = nil
+			var err error
+			concurrent, err = uc.Run(now)
+			require.NoError(t, err)
+		}
+
+		run, err := uc.Run(now)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), run.Notified)
+		require.NotNil(t, concurrent)
+		assert.Equal(t, int64(0), concurrent.Notified)
+		assert.Len(t, mailer.sent, 1)
+	})
+
+	t.Run("purges carts past retention", func(t *testing.T) {
+		uc, _, carts, _, _ := newAbandonedCartUseCase(t)
+		stale := &entities.Cart{UpdatedAt: now.Add(-100 * 24 * time.Hour)}
+		kept := &entities.Cart{UpdatedAt: now.Add(-10 * 24 * time.Hour)}
+		require.NoError(t, carts.Create(stale))
+		require.NoError(t, carts.Create(kept))
+
+		run, err := uc.Run(now)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), run.Purged)
+		assert.Contains(t, carts.carts, kept.ID)
+		assert.NotContains(t, carts.carts, stale.ID)
+	})
+}
+
+func TestAbandonedCartReport(t *testing.T) {
+	uc, _, _, abandoned, _ := newAbandonedCartUseCase(t)
+	abandoned.stats = entities.AbandonedCartStats{Abandoned: 8, Notified: 6, Recovered: 2}
+	now := time.Now()
+
+	report, err := uc.Report(&usecases.AbandonedCartReportQuery{}, now)
+	require.NoError(t, err)
+	assert.Equal(t, 0.25, report.RecoveryRate)
+	assert.Equal(t, now.Add(-30*24*time.Hour), abandoned.since)
+
+	report, err = uc.Report(&usecases.AbandonedCartReportQuery{Since: "2026-01-01"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), report.Since)
+
+	_, err = uc.Report(&usecases.AbandonedCartReportQuery{Since: "last week"}, now)
+	requireCode(t, err, apperrors.CodeInvalidQuery)
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	mailer, err := mail.NewFileMailer(dir, "shop@example.com")
+	require.NoError(t, err)
+
+	require.NoError(t, mailer.Send(&entities.MailMessage{To: "jane@example.com", Subject: "Hello", Body: "Line one\nLine two"}))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	content, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "From: shop@example.com\r\nTo: jane@example.com\r\nSubject: Hello\r\n")
+	assert.True(t, strings.HasSuffix(string(content), "\r\n\r\nLine one\r\nLine two"))
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"testing"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/usecases"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (r *cartStore) UpdateLines(cartID uuid.UUID, lines []entities.CartItem) error {
+	return updateLines(r.carts[cartID], lines)
+}
+
+func (r *memoryCartRepo) UpdateLines(cartID uuid.UUID, lines []entities.CartItem) error {
+	return updateLines(r.cart, lines)
+}
+
+func updateLines(cart *entities.Cart, lines []entities.CartItem) error {
+	for _, line := range lines {
+		if item := cart.Item(line.ProductID, line.VariantID); item != nil {
+			item.Quantity, item.Price = line.Quantity, line.Price
+		}
+	}
+	return nil
+}
+
+func TestGetCart_Revalidates(t *testing.T) {
+	product := tshirt()
+	variantID := product.Variants[0].ID
+	userID := uuid.New()
+
+	t.Run("reprices and cuts quantities once", func(t *testing.T) {
+		uc, carts, _ := newGuestCartUseCase(t, product)
+		cart, err := uc.GetOrCreateCart(userID)
+		require.NoError(t, err)
+		cart.Items = []entities.CartItem{{ProductID: product.ID, VariantID: &variantID, Quantity: 5, Price: 22}}
+
+		cart, err = uc.GetCart(userID)
+		require.NoError(t, err)
+		require.Len(t, cart.Changes, 2)
+		assert.Equal(t, entities.CartChangePriceIncreased, cart.Changes[0].Kind)
+		assert.Equal(t, "price increased from 22.00 to 25.00", cart.Changes[0].Message)
+		assert.Equal(t, entities.CartChangeQuantityReduced, cart.Changes[1].Kind)
+		assert.Equal(t, 3, cart.Changes[1].NewQuantity)
+		assert.Equal(t, "T-Shirt (M / Red)", cart.Changes[1].Name)
+
+		saved, _ := carts.GetByUserID(userID)
+		assert.Equal(t, 25.0, saved.Items[0].Price)
+		assert.Equal(t, 3, saved.Items[0].Quantity)
+
+		cart, err = uc.GetCart(userID)
+		require.NoError(t, err)
+		assert.Empty(t, cart.Changes)
+	})
+
+	t.Run("flags lines that cannot be bought", func(t *testing.T) {
+		product := tshirt()
+		product.Variants[0].Stock = 0
+		uc, _, _ := newGuestCartUseCase(t, product)
+		cart, err := uc.GetOrCreateCart(userID)
+		require.NoError(t, err)
+		cart.Items = []entities.CartItem{
+			{ProductID: product.ID, VariantID: &product.Variants[0].ID, Quantity: 1, Price: 25},
+			{ProductID: uuid.New(), Quantity: 1, Price: 5},
+		}
+
+		for range 2 {
+			cart, err = uc.GetCart(userID)
+			require.NoError(t, err)
+			require.Len(t, cart.Changes, 2)
+			assert.Equal(t, entities.CartChangeOutOfStock, cart.Changes[0].Kind)
+			assert.Equal(t, entities.CartChangeUnavailable, cart.Changes[1].Kind)
+			assert.True(t, cart.Items[0].Unavailable)
+			assert.True(t, cart.Items[1].Unavailable)
+			assert.Equal(t, 1, cart.Items[0].Quantity)
+		}
+	})
+}
+
+func TestCreateOrder_RefusesChangedCart(t *testing.T) {
+	product := &entities.Product{ID: uuid.New(), Name: "Mug", Price: 9, Stock: 10, IsActive: true}
+	products := &ledgerProductRepo{variantProductRepo: variantProductRepo{product: product}}
+	carts := &memoryCartRepo{cart: &entities.Cart{ID: uuid.New(), Items: []entities.CartItem{{ProductID: product.ID, Quantity: 2, Price: 8}}}}
+	warehouses := &memoryWarehouseRepo{}
+	main := warehouses.add("MAIN", "", 0)
+	warehouses.levels = []*entities.StockLevel{{WarehouseID: main.ID, ProductID: product.ID, Quantity: 10}}
+	uc := usecases.NewOrderUseCase(&memoryOrderRepo{}, carts, products, warehouses, usecases.AllocationPolicy{})
+	req := &usecases.CreateOrderRequest{ShippingAddress: "1 Road", BillingAddress: "1 Road"}
+
+	_, err := uc.CreateOrder(uuid.New(), req)
+	requireCode(t, err, apperrors.CodeCartChanged)
+	assert.Empty(t, products.moves)
+
+	// Placing it again after review charges the new price.
+	order, err := uc.CreateOrder(uuid.New(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 9.0, order.Items[0].Price)
+	assert.Equal(t, 18.0, order.Total)
+}
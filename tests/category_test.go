@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/pagination"
+	"prototype-fiber/pkg/patch"
+	"prototype-fiber/pkg/slug"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlugMake(t *testing.T) {
+	assert.Equal(t, "mugs-cups", slug.Make("Mugs & Cups"))
+	assert.Equal(t, "t-shirts", slug.Make("  T-Shirts!  "))
+	assert.Equal(t, "", slug.Make("&&"))
+}
+
+// memoryCategoryRepo is a flat category table; DescendantIDs walks it the
+// way the recursive query does.
+type memoryCategoryRepo struct {
+	entities.CategoryRepository
+	categories []*entities.Category
+}
+
+func (r *memoryCategoryRepo) add(name string, parent *entities.Category) *entities.Category {
+	category := &entities.Category{ID: uuid.New(), Name: name, Slug: slug.Make(name), IsActive: true}
+	if parent != nil {
+		category.ParentID = &parent.ID
+	}
+	r.categories = append(r.categories, category)
+	return category
+}
+
+func (r *memoryCategoryRepo) GetByID(id uuid.UUID) (*entities.Category, error) {
+	for _, category := range r.categories {
+		if category.ID == id {
+			copied := *category
+			return &copied, nil
+		}
+	}
+	return nil, apperrors.ErrNotFound
+}
+
+func (r *memoryCategoryRepo) GetByIDs(ids []uuid.UUID) ([]*entities.Category, error) {
+	var found []*entities.Category
+	for _, category := range r.categories {
+		for _, id := range ids {
+			if category.ID == id {
+				found = append(found, category)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryCategoryRepo) GetBySlugs(slugs []string) ([]*entities.Category, error) {
+	var found []*entities.Category
+	for _, category := range r.categories {
+		for _, s := range slugs {
+			if category.Slug == s {
+				found = append(found, category)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryCategoryRepo) List() ([]*entities.Category, error) {
+	return r.categories, nil
+}
+
+func (r *memoryCategoryRepo) DescendantIDs(ids []uuid.UUID) ([]uuid.UUID, error) {
+	result := append([]uuid.UUID{}, ids...)
+	for i := 0; i < len(result); i++ {
+		for _, category := range r.categories {
+			if category.ParentID != nil && *category.ParentID == result[i] {
+				result = append(result, category.ID)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryCategoryRepo) Update(category *entities.Category) error {
+	return nil
+}
+
+func TestBuildCategoryTree(t *testing.T) {
+	repo := &memoryCategoryRepo{}
+	kitchen := repo.add("Kitchen", nil)
+	apparel := repo.add("Apparel", nil)
+	mugs := repo.add("Mugs", kitchen)
+	cups := repo.add("Cups", kitchen)
+	repo.add("Espresso Cups", cups)
+	hidden := repo.add("Hidden", apparel)
+	hidden.IsActive = false
+	repo.add("Hidden Child", hidden)
+	mugs.Position = 1
+
+	tree := entities.BuildCategoryTree(repo.categories, false)
+	require.Len(t, tree, 2)
+	assert.Equal(t, "Apparel", tree[0].Name)
+	assert.Empty(t, tree[0].Children)
+	assert.Equal(t, "Kitchen", tree[1].Name)
+	require.Len(t, tree[1].Children, 2)
+	assert.Equal(t, "Cups", tree[1].Children[0].Name)
+	assert.Equal(t, "Espresso Cups", tree[1].Children[0].Children[0].Name)
+	assert.Equal(t, "Mugs", tree[1].Children[1].Name)
+
+	tree = entities.BuildCategoryTree(repo.categories, true)
+	require.Len(t, tree[0].Children, 1)
+	assert.Equal(t, "Hidden Child", tree[0].Children[0].Children[0].Name)
+}
+
+func TestUpdateCategory_RejectsCycles(t *testing.T) {
+	repo := &memoryCategoryRepo{}
+	kitchen := repo.add("Kitchen", nil)
+	cups := repo.add("Cups", kitchen)
+	uc := usecases.NewCategoryUseCase(repo)
+
+	for _, parent := range []uuid.UUID{kitchen.ID, cups.ID} {
+		_, err := uc.UpdateCategory(kitchen.ID, &usecases.UpdateCategoryRequest{ParentID: &parent}, patch.Nulls{})
+
+		var appErr *apperrors.Error
+		require.True(t, errors.As(err, &appErr))
+		assert.Equal(t, apperrors.CodeInvalidCategoryParent, appErr.Code)
+	}
+
+	_, err := uc.UpdateCategory(cups.ID, &usecases.UpdateCategoryRequest{}, patch.Nulls{"parent_id": true})
+	require.NoError(t, err)
+}
+
+func TestDeleteCategory_RequiresNoChildren(t *testing.T) {
+	repo := &memoryCategoryRepo{}
+	kitchen := repo.add("Kitchen", nil)
+	repo.add("Cups", kitchen)
+
+	err := usecases.NewCategoryUseCase(repo).DeleteCategory(kitchen.ID)
+
+	var appErr *apperrors.Error
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperrors.CodeCategoryHasChildren, appErr.Code)
+}
+
+type filterCapturingRepo struct {
+	entities.ProductRepository
+	filter entities.ProductFilter
+}
+
+func (r *filterCapturingRepo) List(filter entities.ProductFilter, params pagination.Params) (*pagination.Page[*entities.Product], error) {
+	r.filter = filter
+	return &pagination.Page[*entities.Product]{}, nil
+}
+
+func TestListProducts_ExpandsCategoryDescendants(t *testing.T) {
+	categories := &memoryCategoryRepo{}
+	kitchen := categories.add("Kitchen", nil)
+	cups := categories.add("Cups", kitchen)
+	espresso := categories.add("Espresso Cups", cups)
+	categories.add("Apparel", nil)
+
+	products := &filterCapturingRepo{}
+	uc := usecases.NewProductUseCase(products, nil, categories, nil, nil, nil)
+
+	_, err := uc.ListProducts(entities.ProductFilter{CategorySlugs: []string{"cups"}}, pagination.Params{Limit: 10})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{cups.ID, espresso.ID}, products.filter.CategoryIDs)
+
+	_, err = uc.ListProducts(entities.ProductFilter{CategorySlugs: []string{"cups", "mugs"}}, pagination.Params{Limit: 10})
+	var appErr *apperrors.Error
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperrors.CodeInvalidQuery, appErr.Code)
+	assert.Contains(t, appErr.Message, "mugs")
+}
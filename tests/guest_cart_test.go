@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/tokens"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cartStore keeps every cart, guest or not, by ID.
+type cartStore struct {
+	entities.CartRepository
+	carts map[uuid.UUID]*entities.Cart
+}
+
+func (r *cartStore) Create(cart *entities.Cart) error {
+	cart.ID = uuid.New()
+	r.carts[cart.ID] = cart
+	return nil
+}
+
+func (r *cartStore) GetByID(id uuid.UUID) (*entities.Cart, error) {
+	cart, ok := r.carts[id]
+	if !ok {
+		return nil, apperrors.ErrNotFound
+	}
+	return cart, nil
+}
+
+func (r *cartStore) GetByUserID(userID uuid.UUID) (*entities.Cart, error) {
+	for _, cart := range r.carts {
+		if cart.UserID != nil && *cart.UserID == userID {
+			return cart, nil
+		}
+	}
+	return nil, apperrors.ErrNotFound
+}
+
+func (r *cartStore) AddItem(cartID uuid.UUID, item *entities.CartItem) error {
+	r.carts[cartID].Items = append(r.carts[cartID].Items, *item)
+	return nil
+}
+
+func (r *cartStore) Merge(guestCartID, cartID uuid.UUID, lines []entities.CartItem) error {
+	cart := r.carts[cartID]
+	for _, line := range lines {
+		if item := cart.Item(line.ProductID, line.VariantID); item != nil {
+			item.Quantity, item.Price = line.Quantity, line.Price
+			continue
+		}
+		cart.Items = append(cart.Items, line)
+	}
+	delete(r.carts, guestCartID)
+	return nil
+}
+
+func newGuestCartUseCase(t *testing.T, product *entities.Product) (*usecases.CartUseCase, *cartStore, *tokens.KeySet) {
+	key, err := tokens.GenerateKey("k1", tokens.AlgorithmEdDSA)
+	require.NoError(t, err)
+	ks := newTestKeySet(t, "k1", key)
+	carts := &cartStore{carts: map[uuid.UUID]*entities.Cart{}}
+	return usecases.NewCartUseCase(carts, &variantProductRepo{product: product}, ks, time.Hour), carts, ks
+}
+
+func requireCode(t *testing.T, err error, code string) {
+	t.Helper()
+	var appErr *apperrors.Error
+	require.True(t, errors.As(err, &appErr), "got %v", err)
+	assert.Equal(t, code, appErr.Code)
+}
+
+func TestKeySet_CartTokens(t *testing.T) {
+	key, err := tokens.GenerateKey("k1", tokens.AlgorithmEdDSA)
+	require.NoError(t, err)
+	ks := newTestKeySet(t, "k1", key)
+	cartID := uuid.New()
+
+	token, err := ks.IssueCart(cartID, time.Hour)
+	require.NoError(t, err)
+	parsed, err := ks.ParseCart(token)
+	require.NoError(t, err)
+	assert.Equal(t, cartID, parsed)
+
+	// Cart tokens and access tokens are not interchangeable.
+	_, err = ks.Parse(token)
+	assert.ErrorIs(t, err, tokens.ErrInvalidToken)
+	access, err := ks.Issue(&tokens.Claims{UserID: "u-1", Role: "customer"})
+	require.NoError(t, err)
+	_, err = ks.ParseCart(access)
+	assert.ErrorIs(t, err, tokens.ErrInvalidToken)
+
+	expired, err := ks.IssueCart(cartID, -time.Hour)
+	require.NoError(t, err)
+	_, err = ks.ParseCart(expired)
+	assert.ErrorIs(t, err, tokens.ErrInvalidToken)
+}
+
+func TestGuestCart_AddWithToken(t *testing.T) {
+	product := tshirt()
+	uc, _, _ := newGuestCartUseCase(t, product)
+
+	guest, err := uc.CreateGuestCart()
+	require.NoError(t, err)
+	assert.Nil(t, guest.Cart.UserID)
+
+	cart, err := uc.AddToGuestCart(guest.Token, &usecases.AddToCartRequest{ProductID: product.ID, VariantID: &product.Variants[0].ID, Quantity: 2})
+	require.NoError(t, err)
+	require.Len(t, cart.Items, 1)
+	assert.Equal(t, 25.0, cart.Items[0].Price)
+
+	_, err = uc.GetGuestCart(guest.Token + "x")
+	requireCode(t, err, apperrors.CodeInvalidCartToken)
+}
+
+func TestMergeGuestCart(t *testing.T) {
+	product := tshirt()
+	variantID := product.Variants[0].ID
+	userID := uuid.New()
+
+	t.Run("sums quantities and caps them at stock", func(t *testing.T) {
+		uc, carts, ks := newGuestCartUseCase(t, product)
+		_, err := uc.AddToCart(userID, &usecases.AddToCartRequest{ProductID: product.ID, VariantID: &variantID, Quantity: 2})
+		require.NoError(t, err)
+
+		guest := &entities.Cart{Items: []entities.CartItem{
+			{ProductID: product.ID, VariantID: &variantID, Quantity: 2, Price: 10},
+			{ProductID: uuid.New(), Quantity: 1, Price: 5},
+		}}
+		require.NoError(t, carts.Create(guest))
+		token, err := ks.IssueCart(guest.ID, time.Hour)
+		require.NoError(t, err)
+
+		cart, err := uc.MergeGuestCart(userID, token)
+		require.NoError(t, err)
+		require.Len(t, cart.Items, 1)
+		assert.Equal(t, 3, cart.Items[0].Quantity)
+		assert.Equal(t, 25.0, cart.Items[0].Price)
+
+		// The guest cart is gone, and its token with it.
+		_, err = uc.GetGuestCart(token)
+		requireCode(t, err, apperrors.CodeCartNotFound)
+	})
+
+	t.Run("drops inactive variants", func(t *testing.T) {
+		product := tshirt()
+		product.Variants[0].IsActive = false
+		uc, carts, ks := newGuestCartUseCase(t, product)
+
+		guest := &entities.Cart{Items: []entities.CartItem{{ProductID: product.ID, VariantID: &product.Variants[0].ID, Quantity: 1}}}
+		require.NoError(t, carts.Create(guest))
+		token, err := ks.IssueCart(guest.ID, time.Hour)
+		require.NoError(t, err)
+
+		cart, err := uc.MergeGuestCart(userID, token)
+		require.NoError(t, err)
+		assert.Empty(t, cart.Items)
+	})
+
+	t.Run("without a token merges nothing", func(t *testing.T) {
+		uc, _, _ := newGuestCartUseCase(t, product)
+		cart, err := uc.MergeGuestCart(userID, "")
+		require.NoError(t, err)
+		assert.Nil(t, cart)
+	})
+
+	t.Run("a user's cart is not a guest cart", func(t *testing.T) {
+		uc, carts, ks := newGuestCartUseCase(t, product)
+		owned, err := uc.GetOrCreateCart(uuid.New())
+		require.NoError(t, err)
+		token, err := ks.IssueCart(owned.ID, time.Hour)
+		require.NoError(t, err)
+
+		_, err = uc.MergeGuestCart(userID, token)
+		requireCode(t, err, apperrors.CodeCartNotFound)
+		assert.Len(t, carts.carts, 1)
+	})
+}
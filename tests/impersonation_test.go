@@ -0,0 +1,247 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/interfaces/http/middleware"
+	"prototype-fiber/internal/interfaces/http/problem"
+	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/logger"
+	"prototype-fiber/pkg/pagination"
+	"prototype-fiber/pkg/tokens"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memorySessionRepo struct {
+	sessions map[uuid.UUID]*entities.Session
+	lastSeen []uuid.UUID
+}
+
+func newMemorySessionRepo() *memorySessionRepo {
+	return &memorySessionRepo{sessions: map[uuid.UUID]*entities.Session{}}
+}
+
+func (r *memorySessionRepo) Create(session *entities.Session) error {
+	session.ID = uuid.New()
+	r.sessions[session.ID] = session
+	return nil
+}
+
+func (r *memorySessionRepo) GetByID(id uuid.UUID) (*entities.Session, error) {
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, apperrors.ErrNotFound
+	}
+	return session, nil
+}
+
+func (r *memorySessionRepo) ListActiveByUserID(userID uuid.UUID) ([]*entities.Session, error) {
+	var sessions []*entities.Session
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.IsActive() {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *memorySessionRepo) UpdateLastSeen(id uuid.UUID, lastSeen time.Time) error {
+	r.lastSeen = append(r.lastSeen, id)
+	if session, ok := r.sessions[id]; ok {
+		session.LastSeenAt = lastSeen
+	}
+	return nil
+}
+
+func (r *memorySessionRepo) Revoke(id uuid.UUID) error {
+	now := time.Now()
+	r.sessions[id].RevokedAt = &now
+	return nil
+}
+
+func (r *memorySessionRepo) RevokeAllByUserID(userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.IsActive() {
+			now := time.Now()
+			session.RevokedAt = &now
+			ids = append(ids, session.ID)
+		}
+	}
+	return ids, nil
+}
+
+type memoryImpersonationLogRepo struct {
+	entries []*entities.ImpersonationLog
+	err     error
+}
+
+func (r *memoryImpersonationLogRepo) Create(entry *entities.ImpersonationLog) error {
+	if r.err != nil {
+		return r.err
+	}
+	entry.ID = uuid.New()
+	copied := *entry
+	r.entries = append(r.entries, &copied)
+	return nil
+}
+
+func (r *memoryImpersonationLogRepo) UpdateStatusCode(id uuid.UUID, status int) error {
+	for _, entry := range r.entries {
+		if entry.ID == id {
+			entry.StatusCode = status
+			return nil
+		}
+	}
+	return apperrors.ErrNotFound
+}
+
+func (r *memoryImpersonationLogRepo) ListByUserID(userID uuid.UUID, params pagination.Params) (*pagination.Page[*entities.ImpersonationLog], error) {
+	return nil, nil
+}
+
+type impersonationFixture struct {
+	uc       *usecases.ImpersonationUseCase
+	sessions *memorySessionRepo
+	logs     *memoryImpersonationLogRepo
+	keySet   *tokens.KeySet
+	admin    uuid.UUID
+	customer *entities.User
+	writes   int
+}
+
+func newImpersonationFixture(t *testing.T) *impersonationFixture {
+	key, err := tokens.GenerateKey("k1", tokens.AlgorithmEdDSA)
+	require.NoError(t, err)
+
+	customer := &entities.User{ID: uuid.New(), Email: "ada@example.com", Role: entities.RoleCustomer, IsActive: true}
+	f := &impersonationFixture{
+		sessions: newMemorySessionRepo(),
+		logs:     &memoryImpersonationLogRepo{},
+		keySet:   newTestKeySet(t, "k1", key),
+		admin:    uuid.New(),
+		customer: customer,
+	}
+	users := &memoryUserRepo{users: map[uuid.UUID]*entities.User{customer.ID: customer}}
+	sessionUseCase := usecases.NewSessionUseCase(f.sessions, nil, time.Minute)
+	f.uc = usecases.NewImpersonationUseCase(users, f.logs, sessionUseCase, f.keySet, 15*time.Minute)
+	return f
+}
+
+// app mounts a profile write behind the same middleware chain as the API.
+func (f *impersonationFixture) app() *fiber.App {
+	sessionUseCase := usecases.NewSessionUseCase(f.sessions, nil, time.Minute)
+	app := fiber.New(fiber.Config{ErrorHandler: problem.ErrorHandler(logger.New())})
+	protected := app.Use(
+		middleware.AuthMiddleware(f.keySet, sessionUseCase),
+		middleware.AuditImpersonation(f.uc, logger.New()),
+	)
+	protected.Get("/users/profile", func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+	protected.Patch("/users/profile", middleware.DenyImpersonation(), func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+	protected.Post("/cart/items", func(c *fiber.Ctx) error {
+		f.writes++
+		return c.SendStatus(http.StatusCreated)
+	})
+	return app
+}
+
+func (f *impersonationFixture) impersonate(t *testing.T) string {
+	resp, err := f.uc.Impersonate(f.admin, f.customer.ID, &usecases.ImpersonateRequest{Reason: "ticket 42"}, usecases.ClientInfo{IPAddress: "10.0.0.1"})
+	require.NoError(t, err)
+	return resp.Token
+}
+
+func send(t *testing.T, app *fiber.App, method, path, token string) int {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestImpersonate_TokenCarriesImpersonator(t *testing.T) {
+	f := newImpersonationFixture(t)
+
+	claims, err := f.keySet.Parse(f.impersonate(t))
+	require.NoError(t, err)
+	assert.Equal(t, f.customer.ID.String(), claims.UserID)
+	assert.Equal(t, f.admin.String(), claims.ImpersonatorID)
+
+	sessionID := uuid.MustParse(claims.SessionID)
+	session := f.sessions.sessions[sessionID]
+	require.NotNil(t, session.ImpersonatorID)
+	assert.Equal(t, f.admin, *session.ImpersonatorID)
+
+	require.Len(t, f.logs.entries, 1)
+	assert.Equal(t, entities.ImpersonationActionStart, f.logs.entries[0].Action)
+	assert.Equal(t, "ticket 42", f.logs.entries[0].Reason)
+}
+
+func TestDenyImpersonation(t *testing.T) {
+	f := newImpersonationFixture(t)
+	app := f.app()
+	token := f.impersonate(t)
+
+	assert.Equal(t, http.StatusOK, send(t, app, "GET", "/users/profile", token))
+	assert.Equal(t, http.StatusForbidden, send(t, app, "PATCH", "/users/profile", token))
+
+	// The customer's own token is not affected
+	session, err := usecases.NewSessionUseCase(f.sessions, nil, time.Minute).Start(f.customer.ID, usecases.ClientInfo{}, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	own, err := f.keySet.Issue(&tokens.Claims{UserID: f.customer.ID.String(), Role: "customer", SessionID: session.ID.String()})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, send(t, app, "PATCH", "/users/profile", own))
+}
+
+func TestAuditImpersonation_RecordsRequests(t *testing.T) {
+	f := newImpersonationFixture(t)
+	app := f.app()
+	token := f.impersonate(t)
+
+	send(t, app, "GET", "/users/profile", token)
+	send(t, app, "PATCH", "/users/profile?fields=email", token)
+
+	// The first entry is the start of the impersonation
+	require.Len(t, f.logs.entries, 3)
+	read, denied := f.logs.entries[1], f.logs.entries[2]
+
+	assert.Equal(t, entities.ImpersonationActionRequest, read.Action)
+	assert.Equal(t, f.admin, read.ImpersonatorID)
+	assert.Equal(t, f.customer.ID, read.UserID)
+	assert.Equal(t, "GET", read.Method)
+	assert.Equal(t, http.StatusOK, read.StatusCode)
+
+	assert.Equal(t, "PATCH", denied.Method)
+	assert.Equal(t, "/users/profile?fields=email", denied.Path)
+	assert.Equal(t, http.StatusForbidden, denied.StatusCode)
+}
+
+func TestAuditImpersonation_RecordsWritesBeforeTheyRun(t *testing.T) {
+	f := newImpersonationFixture(t)
+	app := f.app()
+	token := f.impersonate(t)
+
+	assert.Equal(t, http.StatusCreated, send(t, app, "POST", "/cart/items", token))
+	require.Len(t, f.logs.entries, 2)
+	assert.Equal(t, http.StatusCreated, f.logs.entries[1].StatusCode)
+
+	// An unrecordable write is refused; an unrecordable read still runs
+	f.logs.err = errors.New("connection refused")
+	assert.Equal(t, http.StatusInternalServerError, send(t, app, "POST", "/cart/items", token))
+	assert.Equal(t, 1, f.writes)
+	assert.Equal(t, http.StatusOK, send(t, app, "GET", "/users/profile", token))
+}
@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"testing"
+
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/usecases"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// orderOne orders a single unit of the product or variant.
+func orderOne(t *testing.T, product *entities.Product, variantID *uuid.UUID, price float64) *entities.Order {
+	products := &ledgerProductRepo{variantProductRepo: variantProductRepo{product: product}}
+	carts := &memoryCartRepo{cart: &entities.Cart{ID: uuid.New(), Items: []entities.CartItem{{ProductID: product.ID, VariantID: variantID, Quantity: 1, Price: price}}}}
+	warehouses := &memoryWarehouseRepo{}
+	main := warehouses.add("MAIN", "", 0)
+	warehouses.levels = []*entities.StockLevel{{WarehouseID: main.ID, ProductID: product.ID, VariantID: variantID, Quantity: 3}}
+	uc := usecases.NewOrderUseCase(&memoryOrderRepo{}, carts, products, warehouses, usecases.AllocationPolicy{})
+
+	order, err := uc.CreateOrder(uuid.New(), &usecases.CreateOrderRequest{ShippingAddress: "1 Road", BillingAddress: "1 Road"})
+	require.NoError(t, err)
+	require.Len(t, order.Items, 1)
+	return order
+}
+
+func TestCreateOrder_SnapshotsProducts(t *testing.T) {
+	t.Run("variant", func(t *testing.T) {
+		product := tshirt()
+		product.SKU = "TSHIRT"
+		product.ImageURL = "/media/tshirt.jpg"
+
+		item := orderOne(t, product, &product.Variants[0].ID, 25).Items[0]
+		assert.Equal(t, "T-Shirt", item.ProductName)
+		assert.Equal(t, "TSHIRT-M-RED", item.SKU)
+		assert.Equal(t, []entities.OrderItemOption{{Name: "size", Value: "M"}, {Name: "colour", Value: "Red"}}, item.VariantOptions)
+		assert.Equal(t, "/media/tshirt.jpg", item.ImageURL)
+	})
+
+	t.Run("product without variants", func(t *testing.T) {
+		product := &entities.Product{ID: uuid.New(), Name: "Mug", SKU: "MUG", Price: 8, Stock: 3, IsActive: true, ImageURL: "/media/mug.jpg"}
+
+		item := orderOne(t, product, nil, 8).Items[0]
+		assert.Equal(t, "Mug", item.ProductName)
+		assert.Equal(t, "MUG", item.SKU)
+		assert.Empty(t, item.VariantOptions)
+		assert.Equal(t, "/media/mug.jpg", item.ImageURL)
+	})
+}
@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"prototype-fiber/pkg/pagination"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func queryValues(values map[string]string) func(string) string {
+	return func(key string) string { return values[key] }
+}
+
+func TestPagination_ParseBounds(t *testing.T) {
+	params, err := pagination.Parse(queryValues(nil), pagination.DefaultLimit)
+	require.NoError(t, err)
+	assert.Equal(t, pagination.DefaultLimit, params.Limit)
+	assert.Equal(t, 0, params.Offset)
+	assert.False(t, params.UsesCursor())
+
+	params, err = pagination.Parse(queryValues(map[string]string{"page": "3", "limit": "10", "include_total": "true"}), pagination.DefaultLimit)
+	require.NoError(t, err)
+	assert.Equal(t, 20, params.Offset)
+	assert.Equal(t, 3, params.Page())
+	assert.True(t, params.WithTotal)
+
+	for _, bad := range []map[string]string{
+		{"limit": "0"},
+		{"limit": "100000"},
+		{"page": "-1"},
+		{"page": "abc"},
+		{"page": "1000", "limit": "100"},
+		{"cursor": "not-a-cursor"},
+		{"cursor": pagination.Cursor{CreatedAt: time.Now(), ID: uuid.New()}.Encode(), "page": "2"},
+	} {
+		_, err := pagination.Parse(queryValues(bad), pagination.DefaultLimit)
+		assert.Error(t, err, "%v", bad)
+	}
+}
+
+func TestPagination_CursorRoundTrip(t *testing.T) {
+	cursor := pagination.Cursor{CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC), ID: uuid.New()}
+
+	params, err := pagination.Parse(queryValues(map[string]string{"cursor": cursor.Encode()}), pagination.DefaultLimit)
+	require.NoError(t, err)
+	require.True(t, params.UsesCursor())
+	assert.True(t, cursor.CreatedAt.Equal(params.After.CreatedAt))
+	assert.Equal(t, cursor.ID, params.After.ID)
+}
+
+func TestPagination_Links(t *testing.T) {
+	base, _ := url.Parse("/api/v1/products?category=mugs&page=2&limit=10")
+	total := int64(45)
+	page := &pagination.Page[int]{HasMore: true, NextCursor: "abc", Total: &total}
+
+	links := pagination.Links(base, pagination.Params{Limit: 10, Offset: 10}, page)
+	assert.Contains(t, links, `</api/v1/products?category=mugs&limit=10>; rel="first"`)
+	assert.Contains(t, links, `</api/v1/products?category=mugs&limit=10&page=1>; rel="prev"`)
+	assert.Contains(t, links, `</api/v1/products?category=mugs&limit=10&page=3>; rel="next"`)
+	assert.Contains(t, links, `</api/v1/products?category=mugs&limit=10&page=5>; rel="last"`)
+
+	after := &pagination.Cursor{CreatedAt: time.Now(), ID: uuid.New()}
+	links = pagination.Links(base, pagination.Params{Limit: 10, After: after}, page)
+	assert.Contains(t, links, `</api/v1/products?category=mugs&cursor=abc&limit=10>; rel="next"`)
+	assert.NotContains(t, links, `rel="prev"`)
+}
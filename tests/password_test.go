@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"prototype-fiber/pkg/config"
+	"prototype-fiber/pkg/password"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordService_HashEncodesAlgorithm(t *testing.T) {
+	service := password.NewService(password.NewArgon2idHasher(8*1024, 1, 1), password.NewBcryptHasher(4))
+
+	hash, err := service.Hash("correct horse battery")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=8192,t=1,p=1$"))
+	assert.True(t, service.Verify(hash, "correct horse battery"))
+	assert.False(t, service.Verify(hash, "wrong horse battery"))
+	assert.False(t, service.NeedsRehash(hash))
+}
+
+func TestPasswordService_LegacyBcryptNeedsRehash(t *testing.T) {
+	legacy, err := password.NewBcryptHasher(4).Hash("correct horse battery")
+	require.NoError(t, err)
+
+	service := password.NewService(password.NewArgon2idHasher(8*1024, 1, 1), password.NewBcryptHasher(4))
+	assert.True(t, service.Verify(legacy, "correct horse battery"))
+	assert.True(t, service.NeedsRehash(legacy))
+}
+
+func TestPasswordService_StrongerParamsNeedRehash(t *testing.T) {
+	weak := password.NewService(password.NewArgon2idHasher(8*1024, 1, 1))
+	hash, err := weak.Hash("correct horse battery")
+	require.NoError(t, err)
+
+	strong := password.NewService(password.NewArgon2idHasher(16*1024, 2, 1))
+	assert.True(t, strong.Verify(hash, "correct horse battery"))
+	assert.True(t, strong.NeedsRehash(hash))
+}
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := password.NewPolicy(8, 64)
+
+	assert.ErrorIs(t, policy.Validate("short", "jane@example.com"), password.ErrTooShort)
+	assert.ErrorIs(t, policy.Validate(strings.Repeat("x", 65), "jane@example.com"), password.ErrTooLong)
+	assert.ErrorIs(t, policy.Validate("Password123", "jane@example.com"), password.ErrCommonPassword)
+	assert.ErrorIs(t, policy.Validate("Jane@Example.com", "jane@example.com"), password.ErrMatchesEmail)
+	assert.NoError(t, policy.Validate("plum-orbit-42-lantern", "jane@example.com"))
+}
+
+func TestNewServiceFromConfig_RefusesZeroArgon2Params(t *testing.T) {
+	cfg := config.PasswordConfig{Algorithm: password.AlgorithmArgon2id, Argon2Memory: 8 * 1024, Argon2Iterations: 1, Argon2Parallelism: 1, BcryptCost: 4}
+	_, err := password.NewServiceFromConfig(cfg)
+	require.NoError(t, err)
+
+	noIterations := cfg
+	noIterations.Argon2Iterations = 0
+	_, err = password.NewServiceFromConfig(noIterations)
+	assert.Error(t, err)
+
+	noParallelism := cfg
+	noParallelism.Argon2Parallelism = 0
+	_, err = password.NewServiceFromConfig(noParallelism)
+	assert.Error(t, err)
+}
+
+func TestPasswordService_RejectsHashWithZeroArgon2Params(t *testing.T) {
+	service := password.NewService(password.NewArgon2idHasher(8*1024, 1, 1))
+	hash, err := service.Hash("correct horse battery")
+	require.NoError(t, err)
+
+	for _, params := range []string{"m=8192,t=0,p=1", "m=8192,t=1,p=0"} {
+		tampered := strings.Replace(hash, "m=8192,t=1,p=1", params, 1)
+		assert.False(t, service.Verify(tampered, "correct horse battery"), params)
+		assert.True(t, service.NeedsRehash(tampered), params)
+	}
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"testing"
+
+	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/patch"
+	"prototype-fiber/pkg/validation"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeMergePatch_AbsentNullAndSet(t *testing.T) {
+	var req usecases.UpdateProductRequest
+	nulls, err := patch.DecodeMergePatch([]byte(`{"name":"Mug","description":null,"stock":0}`), &req)
+	require.NoError(t, err)
+
+	require.NotNil(t, req.Name)
+	assert.Equal(t, "Mug", *req.Name)
+	require.NotNil(t, req.Stock)
+	assert.Equal(t, 0, *req.Stock)
+	assert.Nil(t, req.Price)
+	assert.True(t, nulls.Has("description"))
+	assert.False(t, nulls.Has("category"))
+}
+
+func TestIsMergePatch(t *testing.T) {
+	assert.True(t, patch.IsMergePatch("application/merge-patch+json"))
+	assert.True(t, patch.IsMergePatch("application/json; charset=utf-8"))
+	assert.False(t, patch.IsMergePatch("text/plain"))
+	assert.False(t, patch.IsMergePatch("application/x-www-form-urlencoded"))
+	assert.False(t, patch.IsMergePatch(""))
+}
+
+func TestDecodeMergePatch_RejectsInvalidMembers(t *testing.T) {
+	var req usecases.UpdateProductRequest
+
+	_, err := patch.DecodeMergePatch([]byte(`{"name":null}`), &req)
+	var fieldErr *patch.FieldError
+	require.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "not_nullable", fieldErr.Rule)
+
+	_, err = patch.DecodeMergePatch([]byte(`{"colour":"red"}`), &req)
+	require.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "unknown_field", fieldErr.Rule)
+	assert.Equal(t, "colour", fieldErr.Field)
+
+	_, err = patch.DecodeMergePatch([]byte(`{"price":"cheap"}`), &req)
+	require.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "type", fieldErr.Rule)
+
+	_, err = patch.DecodeMergePatch([]byte(`[1,2]`), &req)
+	assert.Error(t, err)
+}
+
+func TestUpdateProductRequest_ValidatesPresentFields(t *testing.T) {
+	v := validation.New()
+
+	zero := 0.0
+	err := v.Struct(&usecases.UpdateProductRequest{Price: &zero})
+	require.Error(t, err)
+	assert.Equal(t, "price", err.(validation.Errors)[0].Field)
+
+	assert.NoError(t, v.Struct(&usecases.UpdateProductRequest{}))
+}
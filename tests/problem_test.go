@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/interfaces/http/middleware"
+	"prototype-fiber/internal/interfaces/http/problem"
+	"prototype-fiber/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblem_FromError(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{apperrors.NotFound(apperrors.CodeProductNotFound, "product not found"), 404, "product_not_found"},
+		{apperrors.Forbidden(apperrors.CodeForbidden, "nope"), 403, "forbidden"},
+		{apperrors.Conflict(apperrors.CodeSKUExists, "dup"), 409, "sku_already_exists"},
+		{apperrors.Validation(apperrors.CodeInvalidBody, "bad"), 400, "invalid_request_body"},
+		{apperrors.InsufficientStock(uuid.New(), "Mug", 3, 1), 409, "insufficient_stock"},
+		{apperrors.TooLarge(apperrors.CodeMediaTooLarge, "big"), 413, "media_too_large"},
+		{apperrors.UnsupportedMedia(apperrors.CodeUnsupportedMedia, "pdf"), 415, "unsupported_media_type"},
+		{fiber.ErrMethodNotAllowed, 405, "method_not_allowed"},
+		{errors.New("connection refused"), 500, "internal_error"},
+	}
+
+	for _, tc := range cases {
+		p := problem.FromError(tc.err)
+		assert.Equal(t, tc.status, p.Status, tc.code)
+		assert.Equal(t, tc.code, p.Code)
+	}
+
+	// Internal details never leak
+	assert.NotContains(t, problem.FromError(errors.New("connection refused")).Detail, "refused")
+}
+
+func TestApperrors_IsMatchesKind(t *testing.T) {
+	err := apperrors.NotFound(apperrors.CodeOrderNotFound, "order not found")
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	assert.NotErrorIs(t, err, apperrors.ErrConflict)
+
+	mapped := apperrors.MapNotFound(apperrors.ErrNotFound.Wrap(errors.New("record not found")), apperrors.CodeCartNotFound, "cart not found")
+	assert.ErrorIs(t, mapped, apperrors.NotFound(apperrors.CodeCartNotFound, ""))
+
+	outage := errors.New("connection refused")
+	assert.Equal(t, outage, apperrors.MapNotFound(outage, apperrors.CodeCartNotFound, "cart not found"))
+}
+
+func TestProblem_ErrorHandlerWritesProblemJSON(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: problem.ErrorHandler(logger.New())})
+	app.Get("/stock", func(c *fiber.Ctx) error {
+		return apperrors.InsufficientStock(uuid.New(), "Mug", 3, 1)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/stock", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+	assert.Equal(t, problem.ContentType, resp.Header.Get("Content-Type"))
+
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "insufficient_stock", body["code"])
+	assert.Equal(t, "/stock", body["instance"])
+	details := body["details"].(map[string]interface{})
+	assert.Equal(t, float64(1), details["available"])
+}
+
+func TestBodyLimit(t *testing.T) {
+	app := fiber.New(fiber.Config{
+		ErrorHandler:      problem.ErrorHandler(logger.New()),
+		BodyLimit:         1024,
+		StreamRequestBody: true,
+	})
+	echo := func(c *fiber.Ctx) error {
+		return c.Send(c.Body())
+	}
+	app.Post("/upload", middleware.BodyLimit(8192), echo)
+	app.Use(middleware.BodyLimit(1024))
+	app.Post("/login", echo)
+
+	post := func(path string, body io.Reader, chunked bool) (*http.Response, string) {
+		req := httptest.NewRequest("POST", path, body)
+		if chunked {
+			req.ContentLength = -1
+			req.TransferEncoding = []string{"chunked"}
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(data)
+	}
+	large := strings.Repeat("x", 4096)
+
+	resp, body := post("/login", strings.NewReader(large), false)
+	assert.Equal(t, 413, resp.StatusCode)
+	assert.Contains(t, body, "request_body_too_large")
+
+	resp, body = post("/upload", strings.NewReader(large), false)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, large, body)
+
+	// Chunked bodies declare no length and are cut off while read
+	resp, _ = post("/login", strings.NewReader(large), true)
+	assert.Equal(t, 413, resp.StatusCode)
+	resp, body = post("/login", strings.NewReader("small"), true)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "small", body)
+}
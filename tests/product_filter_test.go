@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/usecases"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseProductQuery(t *testing.T, rawQuery string) (entities.ProductFilter, error) {
+	t.Helper()
+
+	var filter entities.ProductFilter
+	var filterErr error
+	app := fiber.New()
+	app.Get("/products", func(c *fiber.Ctx) error {
+		var query usecases.ListProductsQuery
+		require.NoError(t, c.QueryParser(&query))
+		filter, filterErr = query.Filter()
+		return nil
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/products?"+rawQuery, nil))
+	require.NoError(t, err)
+	return filter, filterErr
+}
+
+func TestListProductsQuery_Filter(t *testing.T) {
+	filter, err := parseProductQuery(t, "category=mugs,cups&category=plates&min_price=5&max_price=20.5&in_stock=true&created_after=2026-01-01&sort=price&order=desc&limit=10")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"mugs", "cups", "plates"}, filter.CategorySlugs)
+	require.NotNil(t, filter.MinPrice)
+	assert.Equal(t, 5.0, *filter.MinPrice)
+	require.NotNil(t, filter.MaxPrice)
+	assert.Equal(t, 20.5, *filter.MaxPrice)
+	assert.True(t, filter.InStockOnly)
+	require.NotNil(t, filter.CreatedAfter)
+	assert.Equal(t, 2026, filter.CreatedAfter.Year())
+	assert.Equal(t, entities.ProductSortPrice, filter.Sort)
+	assert.True(t, filter.Descending)
+}
+
+func TestListProductsQuery_DefaultDirections(t *testing.T) {
+	filter, err := parseProductQuery(t, "")
+	require.NoError(t, err)
+	assert.Equal(t, entities.ProductSortNewest, filter.Sort)
+	assert.True(t, filter.Descending)
+
+	filter, err = parseProductQuery(t, "sort=name")
+	require.NoError(t, err)
+	assert.False(t, filter.Descending)
+
+	filter, err = parseProductQuery(t, "sort=popularity")
+	require.NoError(t, err)
+	assert.True(t, filter.Descending)
+
+	filter, err = parseProductQuery(t, "sort=rating")
+	require.NoError(t, err)
+	assert.Equal(t, entities.ProductSortRating, filter.Sort)
+	assert.True(t, filter.Descending)
+}
+
+func TestListProductsQuery_RejectsInvalidRanges(t *testing.T) {
+	_, err := parseProductQuery(t, "min_price=30&max_price=10")
+	assert.Error(t, err)
+
+	_, err = parseProductQuery(t, "created_after=yesterday")
+	assert.Error(t, err)
+}
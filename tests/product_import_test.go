@@ -0,0 +1,305 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/logger"
+	"prototype-fiber/pkg/pagination"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// catalogueRepo keeps products in memory, enough for imports and exports.
+type catalogueRepo struct {
+	entities.ProductRepository
+	categories *memoryCategoryRepo
+	products   map[uuid.UUID]*entities.Product
+	writes     int
+}
+
+func (r *catalogueRepo) GetStockLevels(ids []uuid.UUID) (map[uuid.UUID]*entities.Product, error) {
+	levels := map[uuid.UUID]*entities.Product{}
+	for _, id := range ids {
+		if product, ok := r.products[id]; ok {
+			copied := *product
+			levels[id] = &copied
+		}
+	}
+	return levels, nil
+}
+
+func (r *catalogueRepo) GetByID(id uuid.UUID) (*entities.Product, error) {
+	product, ok := r.products[id]
+	if !ok {
+		return nil, apperrors.ErrNotFound
+	}
+	copied := *product
+	return &copied, nil
+}
+
+func (r *catalogueRepo) GetBySKU(sku string) (*entities.Product, error) {
+	for _, product := range r.products {
+		if product.SKU == sku {
+			return r.GetByID(product.ID)
+		}
+	}
+	return nil, apperrors.ErrNotFound
+}
+
+func (r *catalogueRepo) Create(product *entities.Product) error {
+	product.ID = uuid.New()
+	copied := *product
+	r.products[product.ID] = &copied
+	r.writes++
+	return nil
+}
+
+func (r *catalogueRepo) Update(product *entities.Product) error {
+	copied := *product
+	r.products[product.ID] = &copied
+	r.writes++
+	return nil
+}
+
+func (r *catalogueRepo) SetCategories(id uuid.UUID, categoryIDs []uuid.UUID) error {
+	categories, _ := r.categories.GetByIDs(categoryIDs)
+	r.products[id].Categories = nil
+	for _, category := range categories {
+		r.products[id].Categories = append(r.products[id].Categories, *category)
+	}
+	r.writes++
+	return nil
+}
+
+func (r *catalogueRepo) List(filter entities.ProductFilter, params pagination.Params) (*pagination.Page[*entities.Product], error) {
+	var items []*entities.Product
+	for _, product := range r.products {
+		if product.IsActive || filter.IncludeInactive {
+			items = append(items, product)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+	return &pagination.Page[*entities.Product]{Items: items}, nil
+}
+
+func newImportUseCase(maxSize int64) (*usecases.ProductImportUseCase, *catalogueRepo, *entities.Category) {
+	categories := &memoryCategoryRepo{}
+	kitchen := categories.add("Kitchen", nil)
+	mug := &entities.Product{ID: uuid.New(), Name: "Mug", SKU: "MUG-1", Price: 8, Stock: 10, IsActive: true, Categories: []entities.Category{*kitchen}}
+	repo := &catalogueRepo{categories: categories, products: map[uuid.UUID]*entities.Product{mug.ID: mug}}
+
+	products := usecases.NewProductUseCase(repo, &memoryVariantRepo{}, categories, nil, nil, nil)
+	return usecases.NewProductImportUseCase(products, nil, maxSize, logger.New()), repo, kitchen
+}
+
+func TestRunImport_DryRunReportsEveryRow(t *testing.T) {
+	uc, repo, _ := newImportUseCase(0)
+	file := strings.Join([]string{
+		"sku,name,price,stock,categories,is_active",
+		"MUG-1,Mug,8,10,kitchen,true",
+		"MUG-1,Mug,9,10,kitchen,true",
+		"BOWL-1,Bowl,12.5,4,kitchen,false",
+		"PLATE-1,Plate,,4,,",
+		"CUP-1,Cup,3,1,garden,",
+		"JUG-1,Jug,abc,1,,",
+		"bad sku,Thing,1,1,,",
+		"SHORT-1,Short",
+	}, "\n")
+
+	report, err := uc.RunImport(usecases.ImportFormatCSV, strings.NewReader(file), true)
+	require.NoError(t, err)
+
+	assert.Equal(t, 8, report.Rows)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 0, report.Updated)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, 6, report.Failed)
+	assert.Zero(t, repo.writes)
+
+	failures := make(map[int]string)
+	for _, rowErr := range report.Errors {
+		failures[rowErr.Row] = rowErr.Field
+	}
+	assert.Equal(t, map[int]string{2: "sku", 4: "price", 5: "categories", 6: "price", 7: "sku", 8: ""}, failures)
+}
+
+func TestRunImport_UpsertsBySKU(t *testing.T) {
+	uc, repo, kitchen := newImportUseCase(0)
+	file := `[
+		{"sku": "MUG-1", "price": 9.5},
+		{"sku": "BOWL-1", "name": "Bowl", "price": 12, "stock": 4, "categories": ["Kitchen"], "is_active": false},
+		{"sku": "CUP-1", "name": "Cup", "price": "cheap"},
+		{"sku": "JUG-1", "name": "Jug", "price": 3, "colour": "blue"}
+	]`
+
+	report, err := uc.RunImport(usecases.ImportFormatJSON, strings.NewReader(file), false)
+	require.NoError(t, err)
+	assert.Equal(t, entities.ImportReport{Rows: 4, Created: 1, Updated: 1, Failed: 2}, entities.ImportReport{
+		Rows: report.Rows, Created: report.Created, Updated: report.Updated, Skipped: report.Skipped, Failed: report.Failed,
+	})
+
+	mug, err := repo.GetBySKU("MUG-1")
+	require.NoError(t, err)
+	assert.Equal(t, 9.5, mug.Price)
+	assert.Equal(t, 10, mug.Stock)
+
+	bowl, err := repo.GetBySKU("BOWL-1")
+	require.NoError(t, err)
+	assert.False(t, bowl.IsActive)
+	require.Len(t, bowl.Categories, 1)
+	assert.Equal(t, kitchen.ID, bowl.Categories[0].ID)
+
+	_, err = repo.GetBySKU("CUP-1")
+	assert.True(t, errors.Is(err, apperrors.ErrNotFound))
+}
+
+func TestExportProducts_RoundTripsThroughImport(t *testing.T) {
+	for _, format := range []string{usecases.ImportFormatCSV, usecases.ImportFormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			uc, _, _ := newImportUseCase(0)
+			_, err := uc.RunImport(usecases.ImportFormatCSV, strings.NewReader("sku,name,description,price\nBOWL-1,\"Bowl, large\",\"Deep, \"\"wide\"\"\",12\n"), false)
+			require.NoError(t, err)
+
+			var exported bytes.Buffer
+			require.NoError(t, uc.ExportProducts(format, &exported))
+
+			report, err := uc.RunImport(format, &exported, true)
+			require.NoError(t, err)
+			assert.Equal(t, 2, report.Rows)
+			assert.Equal(t, 2, report.Skipped, "%s", exported.String())
+		})
+	}
+}
+
+func TestRunImport_RejectsUnusableFiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		file   string
+		code   string
+	}{
+		{"unknown column", usecases.ImportFormatCSV, "sku,colour\nMUG-1,blue\n", apperrors.CodeInvalidImportFile},
+		{"no sku column", usecases.ImportFormatCSV, "name,price\nMug,8\n", apperrors.CodeInvalidImportFile},
+		{"not an array", usecases.ImportFormatJSON, `{"sku": "MUG-1"}`, apperrors.CodeInvalidImportFile},
+		{"too large", usecases.ImportFormatCSV, "sku,name\n" + strings.Repeat("MUG-1,Mug\n", 20), apperrors.CodeImportTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, _, _ := newImportUseCase(100)
+
+			_, err := uc.RunImport(tt.format, strings.NewReader(tt.file), true)
+
+			var appErr *apperrors.Error
+			require.True(t, errors.As(err, &appErr), "got %v", err)
+			assert.Equal(t, tt.code, appErr.Code)
+		})
+	}
+}
+
+// memoryImportJobRepo keeps copies of jobs and signals when one finishes.
+type memoryImportJobRepo struct {
+	mu       sync.Mutex
+	jobs     map[uuid.UUID]entities.ImportJob
+	finished chan uuid.UUID
+}
+
+func (r *memoryImportJobRepo) Create(job *entities.ImportJob) error {
+	job.ID = uuid.New()
+	return r.Update(job)
+}
+
+func (r *memoryImportJobRepo) GetByID(id uuid.UUID) (*entities.ImportJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, apperrors.ErrNotFound
+	}
+	return &job, nil
+}
+
+func (r *memoryImportJobRepo) Update(job *entities.ImportJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[job.ID] = *job
+	if job.Status == entities.ImportStatusCompleted || job.Status == entities.ImportStatusFailed {
+		r.finished <- job.ID
+	}
+	return nil
+}
+
+func (r *memoryImportJobRepo) AddErrors(jobID uuid.UUID, errors []entities.ImportRowError) error {
+	return nil
+}
+
+func (r *memoryImportJobRepo) FailUnfinished(message string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var failed int64
+	for id, job := range r.jobs {
+		if job.Status == entities.ImportStatusPending || job.Status == entities.ImportStatusRunning {
+			job.Status = entities.ImportStatusFailed
+			job.Error = message
+			r.jobs[id] = job
+			failed++
+		}
+	}
+	return failed, nil
+}
+
+// panickingCatalogueRepo panics on every product it is asked to create.
+type panickingCatalogueRepo struct {
+	*catalogueRepo
+}
+
+func (r *panickingCatalogueRepo) Create(product *entities.Product) error {
+	panic("unexpected nil product")
+}
+
+func TestStartImport_PanicFailsTheJob(t *testing.T) {
+	_, repo, _ := newImportUseCase(0)
+	jobs := &memoryImportJobRepo{jobs: map[uuid.UUID]entities.ImportJob{}, finished: make(chan uuid.UUID, 1)}
+	products := usecases.NewProductUseCase(&panickingCatalogueRepo{repo}, &memoryVariantRepo{}, repo.categories, nil, nil, nil)
+	uc := usecases.NewProductImportUseCase(products, jobs, 0, logger.New())
+
+	job, err := uc.StartImport(usecases.ImportFormatCSV, strings.NewReader("sku,name,price\nBOWL-1,Bowl,12"), nil)
+	require.NoError(t, err)
+
+	select {
+	case id := <-jobs.finished:
+		assert.Equal(t, job.ID, id)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the import job did not finish")
+	}
+	stored, err := jobs.GetByID(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.ImportStatusFailed, stored.Status)
+	assert.Equal(t, "the import stopped because of an internal error", stored.Error)
+	assert.NotNil(t, stored.FinishedAt)
+}
+
+func TestFailInterruptedJobs(t *testing.T) {
+	jobs := &memoryImportJobRepo{jobs: map[uuid.UUID]entities.ImportJob{}, finished: make(chan uuid.UUID, 1)}
+	running := entities.ImportJob{ID: uuid.New(), Status: entities.ImportStatusRunning}
+	done := entities.ImportJob{ID: uuid.New(), Status: entities.ImportStatusCompleted}
+	jobs.jobs[running.ID], jobs.jobs[done.ID] = running, done
+
+	failed, err := usecases.NewProductImportUseCase(nil, jobs, 0, logger.New()).FailInterruptedJobs()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), failed)
+	assert.Equal(t, entities.ImportStatusFailed, jobs.jobs[running.ID].Status)
+	assert.Equal(t, "the import was interrupted by a restart", jobs.jobs[running.ID].Error)
+	assert.Equal(t, entities.ImportStatusCompleted, jobs.jobs[done.ID].Status)
+}
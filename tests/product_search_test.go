@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/infrastructure/database"
+	"prototype-fiber/internal/interfaces/repositories"
+	"prototype-fiber/pkg/config"
+	"prototype-fiber/pkg/pagination"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newPostgresTx opens a transaction on TEST_DB_NAME, a scratch database on
+// the server given by the usual DB_* settings, for tests of SQL that only
+// Postgres runs. It is rolled back after the test, which is skipped when
+// there is no database to run against.
+func newPostgresTx(t *testing.T, what string) *gorm.DB {
+	name := os.Getenv("TEST_DB_NAME")
+	if name == "" {
+		t.Skipf("TEST_DB_NAME not set; %s needs Postgres", what)
+	}
+	cfg := config.Load().Database
+	cfg.Name = name
+	db, err := database.NewPostgresDB(cfg)
+	require.NoError(t, err)
+
+	tx := db.Begin()
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
+
+// newSearchRepo empties the products for a search test, since the search
+// is written in Postgres full-text and trigram SQL.
+func newSearchRepo(t *testing.T, products ...*entities.Product) entities.ProductRepository {
+	tx := newPostgresTx(t, "product search")
+	require.NoError(t, tx.Exec("TRUNCATE products CASCADE").Error)
+
+	repo := repositories.NewProductRepository(tx)
+	for i, product := range products {
+		product.SKU = fmt.Sprintf("SEARCH-%d", i)
+		product.IsActive = true
+		require.NoError(t, repo.Create(product))
+	}
+	return repo
+}
+
+func relevanceOrder(t *testing.T, repo entities.ProductRepository, term string) []string {
+	page, err := repo.Search(term, pagination.Params{Limit: 20})
+	require.NoError(t, err)
+	names := []string{}
+	for _, product := range page.Items {
+		names = append(names, product.Name)
+	}
+	return names
+}
+
+func TestProductSearch_NameOutranksDescription(t *testing.T) {
+	repo := newSearchRepo(t,
+		&entities.Product{Name: "Teapot", Description: "Pairs well with any kettle", Price: 20},
+		&entities.Product{Name: "Steel Kettle", Description: "Boils a litre in two minutes", Price: 30},
+		&entities.Product{Name: "Mug", Description: "Holds 300ml", Price: 8},
+	)
+
+	assert.Equal(t, []string{"Steel Kettle", "Teapot"}, relevanceOrder(t, repo, "kettle"))
+}
+
+func TestProductSearch_IgnoresHyphens(t *testing.T) {
+	repo := newSearchRepo(t,
+		&entities.Product{Name: "Cotton T-Shirt", Price: 15},
+		&entities.Product{Name: "Linen Tshirt", Price: 25},
+	)
+
+	for _, term := range []string{"t-shirt", "tshirt"} {
+		assert.ElementsMatch(t, []string{"Cotton T-Shirt", "Linen Tshirt"}, relevanceOrder(t, repo, term), term)
+	}
+}
+
+func TestProductSearch_FindsMisspelledNames(t *testing.T) {
+	repo := newSearchRepo(t,
+		&entities.Product{Name: "Kettle", Price: 30},
+		&entities.Product{Name: "Teapot", Price: 20},
+	)
+
+	assert.Equal(t, []string{"Kettle"}, relevanceOrder(t, repo, "ketle"))
+}
+
+func TestProductSearch_HighlightsMatches(t *testing.T) {
+	repo := newSearchRepo(t,
+		&entities.Product{Name: "Steel Kettle", Description: "A kettle for the stove top", Price: 30},
+	)
+
+	page, err := repo.Search("kettle", pagination.Params{Limit: 20})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "Steel <mark>Kettle</mark>", page.Items[0].NameHighlight)
+	assert.Contains(t, page.Items[0].DescriptionHighlight, "<mark>kettle</mark>")
+}
+
+func TestProductSearch_PagesByRelevance(t *testing.T) {
+	// Equal ranks for the three mugs check the tie-break between pages
+	repo := newSearchRepo(t,
+		&entities.Product{Name: "Mug Tree", Description: "Hangs six mugs", Price: 12},
+		&entities.Product{Name: "Blue Mug", Price: 8},
+		&entities.Product{Name: "Red Mug", Price: 8},
+		&entities.Product{Name: "Green Mug", Price: 8},
+		&entities.Product{Name: "Coaster", Description: "Protects the table from a hot mug", Price: 3},
+	)
+	all := relevanceOrder(t, repo, "mug")
+	require.Len(t, all, 5)
+	assert.Equal(t, "Coaster", all[4])
+
+	var paged []string
+	params := pagination.Params{Limit: 2}
+	for {
+		page, err := repo.Search("mug", params)
+		require.NoError(t, err)
+		for _, product := range page.Items {
+			paged = append(paged, product.Name)
+		}
+		if !page.HasMore {
+			break
+		}
+		params.After, err = pagination.DecodeCursor(page.NextCursor)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, all, paged)
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/usecases"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type suggestRepo struct {
+	entities.ProductRepository
+	calls    int
+	prefixes []string
+}
+
+func (r *suggestRepo) Suggest(prefix string, limit int) ([]*entities.ProductSuggestion, error) {
+	r.calls++
+	r.prefixes = append(r.prefixes, prefix)
+	return []*entities.ProductSuggestion{{ID: uuid.New(), Name: "Mug"}}, nil
+}
+
+type memorySuggestionCache map[string][]*entities.ProductSuggestion
+
+func (c memorySuggestionCache) GetSuggestions(prefix string, limit int) ([]*entities.ProductSuggestion, bool, error) {
+	suggestions, found := c[prefix]
+	return suggestions, found, nil
+}
+
+func (c memorySuggestionCache) SetSuggestions(prefix string, limit int, suggestions []*entities.ProductSuggestion, ttl time.Duration) error {
+	c[prefix] = suggestions
+	return nil
+}
+
+func TestSuggestProducts_NormalizesAndCaches(t *testing.T) {
+	repo := &suggestRepo{}
+	uc := usecases.NewProductUseCase(repo, nil, nil, nil, memorySuggestionCache{}, nil)
+
+	first, err := uc.SuggestProducts("  Coffee   MUG ", 0)
+	require.NoError(t, err)
+	second, err := uc.SuggestProducts("coffee mug", 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, repo.calls)
+	assert.Equal(t, []string{"coffee mug"}, repo.prefixes)
+}
+
+func TestSuggestProducts_IgnoresShortPrefixes(t *testing.T) {
+	repo := &suggestRepo{}
+	uc := usecases.NewProductUseCase(repo, nil, nil, nil, nil, nil)
+
+	suggestions, err := uc.SuggestProducts("m", 0)
+	require.NoError(t, err)
+	assert.Empty(t, suggestions)
+	assert.Equal(t, 0, repo.calls)
+}
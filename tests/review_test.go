@@ -0,0 +1,191 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/patch"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryReviewRepo struct {
+	entities.ReviewRepository
+	reviews map[uuid.UUID]*entities.Review
+	votes   map[[2]uuid.UUID]bool
+}
+
+func (r *memoryReviewRepo) Create(review *entities.Review) error {
+	for _, existing := range r.reviews {
+		if existing.ProductID == review.ProductID && existing.UserID == review.UserID {
+			return apperrors.ErrConflict
+		}
+	}
+	review.ID = uuid.New()
+	copied := *review
+	r.reviews[review.ID] = &copied
+	return nil
+}
+
+func (r *memoryReviewRepo) GetByID(id uuid.UUID) (*entities.Review, error) {
+	review, ok := r.reviews[id]
+	if !ok {
+		return nil, apperrors.ErrNotFound
+	}
+	copied := *review
+	return &copied, nil
+}
+
+func (r *memoryReviewRepo) Update(review *entities.Review) error {
+	copied := *review
+	copied.HelpfulCount = r.reviews[review.ID].HelpfulCount
+	r.reviews[review.ID] = &copied
+	return nil
+}
+
+func (r *memoryReviewRepo) SetStatus(id uuid.UUID, status entities.ReviewStatus, moderatorID uuid.UUID) error {
+	review, ok := r.reviews[id]
+	if !ok {
+		return apperrors.ErrNotFound
+	}
+	review.Status = status
+	review.ModeratedBy = &moderatorID
+	return nil
+}
+
+func (r *memoryReviewRepo) AddVote(reviewID, userID uuid.UUID) error {
+	if !r.votes[[2]uuid.UUID{reviewID, userID}] {
+		r.votes[[2]uuid.UUID{reviewID, userID}] = true
+		r.reviews[reviewID].HelpfulCount++
+	}
+	return nil
+}
+
+type memoryUserRepo struct {
+	entities.UserRepository
+	users map[uuid.UUID]*entities.User
+}
+
+func (r *memoryUserRepo) GetByID(id uuid.UUID) (*entities.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, apperrors.ErrNotFound
+	}
+	return user, nil
+}
+
+// deliveryOrderRepo answers HasDelivered from a set of delivered products.
+type deliveryOrderRepo struct {
+	entities.OrderRepository
+	delivered map[uuid.UUID]bool
+}
+
+func (r *deliveryOrderRepo) HasDelivered(userID, productID uuid.UUID) (bool, error) {
+	return r.delivered[productID], nil
+}
+
+type reviewFixture struct {
+	uc      *usecases.ReviewUseCase
+	reviews *memoryReviewRepo
+	orders  *deliveryOrderRepo
+	product *entities.Product
+	author  *entities.User
+	reader  *entities.User
+}
+
+func newReviewFixture() *reviewFixture {
+	product := &entities.Product{ID: uuid.New(), Name: "Mug", IsActive: true}
+	author := &entities.User{ID: uuid.New(), FirstName: "Ada", LastName: "Lovelace"}
+	reader := &entities.User{ID: uuid.New(), FirstName: "Grace", LastName: "Hopper"}
+	f := &reviewFixture{
+		reviews: &memoryReviewRepo{reviews: map[uuid.UUID]*entities.Review{}, votes: map[[2]uuid.UUID]bool{}},
+		orders:  &deliveryOrderRepo{delivered: map[uuid.UUID]bool{}},
+		product: product,
+		author:  author,
+		reader:  reader,
+	}
+	users := &memoryUserRepo{users: map[uuid.UUID]*entities.User{author.ID: author, reader.ID: reader}}
+	f.uc = usecases.NewReviewUseCase(f.reviews, &variantProductRepo{product: product}, f.orders, users)
+	return f
+}
+
+func TestCreateReview_HeldForModeration(t *testing.T) {
+	f := newReviewFixture()
+
+	review, err := f.uc.CreateReview(f.product.ID, f.author.ID, &usecases.CreateReviewRequest{Rating: 4, Title: " Sturdy ", Body: "Holds tea."})
+	require.NoError(t, err)
+	assert.Equal(t, entities.ReviewPending, review.Status)
+	assert.Equal(t, "Ada L.", review.AuthorName)
+	assert.Equal(t, "Sturdy", review.Title)
+	assert.False(t, review.VerifiedPurchase)
+
+	var appErr *apperrors.Error
+	_, err = f.uc.CreateReview(f.product.ID, f.author.ID, &usecases.CreateReviewRequest{Rating: 5, Title: "Again"})
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperrors.CodeReviewExists, appErr.Code)
+
+	_, err = f.uc.CreateReview(uuid.New(), f.author.ID, &usecases.CreateReviewRequest{Rating: 5, Title: "Elsewhere"})
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperrors.CodeProductNotFound, appErr.Code)
+}
+
+func TestReview_VerifiedPurchase(t *testing.T) {
+	f := newReviewFixture()
+	review, err := f.uc.CreateReview(f.product.ID, f.author.ID, &usecases.CreateReviewRequest{Rating: 3, Title: "Fine"})
+	require.NoError(t, err)
+	assert.False(t, review.VerifiedPurchase)
+
+	// Delivered since the review was written: the badge is earned on edit.
+	f.orders.delivered[f.product.ID] = true
+	rating := 5
+	review, err = f.uc.UpdateReview(review.ID, f.author.ID, &usecases.UpdateReviewRequest{Rating: &rating}, patch.Nulls{})
+	require.NoError(t, err)
+	assert.True(t, review.VerifiedPurchase)
+	assert.Equal(t, 5, review.Rating)
+}
+
+func TestUpdateReview_GoesBackToModeration(t *testing.T) {
+	f := newReviewFixture()
+	review, err := f.uc.CreateReview(f.product.ID, f.author.ID, &usecases.CreateReviewRequest{Rating: 3, Title: "Fine", Body: "Just fine."})
+	require.NoError(t, err)
+	_, err = f.uc.ModerateReview(review.ID, &usecases.ModerateReviewRequest{Status: "approved"}, uuid.New())
+	require.NoError(t, err)
+
+	var appErr *apperrors.Error
+	title := "Hijacked"
+	_, err = f.uc.UpdateReview(review.ID, f.reader.ID, &usecases.UpdateReviewRequest{Title: &title}, patch.Nulls{})
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperrors.CodeForbidden, appErr.Code)
+
+	updated, err := f.uc.UpdateReview(review.ID, f.author.ID, &usecases.UpdateReviewRequest{}, patch.Nulls{"body": true})
+	require.NoError(t, err)
+	assert.Equal(t, entities.ReviewPending, updated.Status)
+	assert.Nil(t, updated.ModeratedBy)
+	assert.Empty(t, updated.Body)
+	assert.Equal(t, "Fine", updated.Title)
+}
+
+func TestMarkHelpful(t *testing.T) {
+	f := newReviewFixture()
+	review, err := f.uc.CreateReview(f.product.ID, f.author.ID, &usecases.CreateReviewRequest{Rating: 4, Title: "Good"})
+	require.NoError(t, err)
+
+	var appErr *apperrors.Error
+	require.True(t, errors.As(f.uc.MarkHelpful(review.ID, f.reader.ID), &appErr))
+	assert.Equal(t, apperrors.CodeReviewNotFound, appErr.Code, "unpublished reviews cannot be voted on")
+
+	_, err = f.uc.ModerateReview(review.ID, &usecases.ModerateReviewRequest{Status: "approved"}, uuid.New())
+	require.NoError(t, err)
+
+	require.True(t, errors.As(f.uc.MarkHelpful(review.ID, f.author.ID), &appErr))
+	assert.Equal(t, apperrors.CodeForbidden, appErr.Code)
+
+	require.NoError(t, f.uc.MarkHelpful(review.ID, f.reader.ID))
+	require.NoError(t, f.uc.MarkHelpful(review.ID, f.reader.ID))
+	assert.Equal(t, 1, f.reviews.reviews[review.ID].HelpfulCount)
+}
@@ -0,0 +1,209 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/infrastructure/search"
+	"prototype-fiber/pkg/logger"
+	"prototype-fiber/pkg/pagination"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newIndexedCatalogue(t *testing.T) (entities.SearchIndex, map[string]*entities.Product) {
+	t.Helper()
+
+	apparel := []entities.Category{{ID: uuid.New(), Name: "Apparel", Slug: "apparel"}}
+	kitchen := []entities.Category{{ID: uuid.New(), Name: "Kitchen", Slug: "kitchen"}}
+	coffee := []entities.Category{{ID: uuid.New(), Name: "Coffee", Slug: "coffee"}}
+
+	products := map[string]*entities.Product{
+		"tshirt":  {ID: uuid.New(), Name: "Classic T-Shirt", Categories: apparel, Description: "Soft cotton tee", Price: 19, Stock: 5, IsActive: true},
+		"mug":     {ID: uuid.New(), Name: "Coffee Mug", Categories: kitchen, Description: "Holds coffee or tea", Price: 9.5, Stock: 0, IsActive: true},
+		"beans":   {ID: uuid.New(), Name: "Espresso Beans", Categories: coffee, Description: "Dark roast for strong coffee", Price: 14, Stock: 3, IsActive: true},
+		"retired": {ID: uuid.New(), Name: "Old Coffee Grinder", Categories: kitchen, Price: 40, Stock: 1, IsActive: false},
+	}
+
+	index := search.NewEmbeddedIndex()
+	for _, p := range products {
+		p.CreatedAt = time.Now()
+		require.NoError(t, index.Index(p))
+	}
+	return index, products
+}
+
+func searchNames(t *testing.T, index entities.SearchIndex, query entities.SearchQuery) []string {
+	t.Helper()
+	if query.Page.Limit == 0 {
+		query.Page.Limit = pagination.DefaultLimit
+	}
+	result, err := index.Search(query)
+	require.NoError(t, err)
+
+	names := make([]string, len(result.Products.Items))
+	for i, p := range result.Products.Items {
+		names[i] = p.Name
+	}
+	return names
+}
+
+func TestEmbeddedIndex_MatchesHyphenationAndTypos(t *testing.T) {
+	index, _ := newIndexedCatalogue(t)
+
+	assert.Equal(t, []string{"Classic T-Shirt"}, searchNames(t, index, entities.SearchQuery{Text: "tshirt"}))
+	assert.Equal(t, []string{"Classic T-Shirt"}, searchNames(t, index, entities.SearchQuery{Text: "t-shirts"}))
+	assert.Equal(t, []string{"Espresso Beans"}, searchNames(t, index, entities.SearchQuery{Text: "expresso"}))
+}
+
+func TestEmbeddedIndex_RanksByFieldWeightAndHidesInactive(t *testing.T) {
+	index, _ := newIndexedCatalogue(t)
+
+	// "coffee" is in the mug's name, the beans' category and description.
+	names := searchNames(t, index, entities.SearchQuery{Text: "coffee"})
+	assert.Equal(t, []string{"Coffee Mug", "Espresso Beans"}, names[:2])
+	assert.NotContains(t, names, "Old Coffee Grinder")
+}
+
+func TestEmbeddedIndex_FiltersFacetsAndHighlights(t *testing.T) {
+	index, _ := newIndexedCatalogue(t)
+
+	result, err := index.Search(entities.SearchQuery{
+		Text:   "coffee",
+		Filter: entities.ProductFilter{InStockOnly: true},
+		Page:   pagination.Params{Limit: 10},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Products.Items, 1)
+	assert.Equal(t, "Espresso Beans", result.Products.Items[0].Name)
+	assert.Contains(t, result.Products.Items[0].DescriptionHighlight, "strong <mark>coffee</mark>")
+
+	assert.Equal(t, int64(1), result.Facets.InStock)
+	assert.Equal(t, []entities.FacetCount{{Value: "coffee", Name: "Coffee", Count: 1}}, result.Facets.Categories)
+	assert.Equal(t, int64(1), result.Facets.PriceBuckets[1].Count)
+}
+
+func TestEmbeddedIndex_CursorPagesWithoutOverlap(t *testing.T) {
+	index := search.NewEmbeddedIndex()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, index.Index(&entities.Product{ID: uuid.New(), Name: "Mug", IsActive: true}))
+	}
+
+	seen := map[uuid.UUID]bool{}
+	params := pagination.Params{Limit: 2}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 5)
+		result, err := index.Search(entities.SearchQuery{Text: "mug", Page: params})
+		require.NoError(t, err)
+		for _, p := range result.Products.Items {
+			assert.False(t, seen[p.ID])
+			seen[p.ID] = true
+		}
+		if !result.Products.HasMore {
+			break
+		}
+		params.After, err = pagination.DecodeCursor(result.Products.NextCursor)
+		require.NoError(t, err)
+	}
+	assert.Len(t, seen, 5)
+}
+
+func TestEmbeddedIndex_DeleteAndReset(t *testing.T) {
+	index, products := newIndexedCatalogue(t)
+
+	require.NoError(t, index.Delete(products["mug"].ID))
+	assert.NotContains(t, searchNames(t, index, entities.SearchQuery{Text: "mug"}), "Coffee Mug")
+
+	require.NoError(t, index.Reset())
+	assert.Empty(t, searchNames(t, index, entities.SearchQuery{Text: "coffee"}))
+}
+
+func TestEmbeddedIndex_FiltersByCategoryIDs(t *testing.T) {
+	index, products := newIndexedCatalogue(t)
+
+	names := searchNames(t, index, entities.SearchQuery{
+		Text:   "coffee",
+		Filter: entities.ProductFilter{CategoryIDs: []uuid.UUID{products["mug"].Categories[0].ID}},
+	})
+	assert.Equal(t, []string{"Coffee Mug"}, names)
+}
+
+// brokenIndex refuses every write, like an unreachable search cluster.
+type brokenIndex struct {
+	entities.SearchIndex
+}
+
+func (brokenIndex) StoresProducts() bool {
+	return true
+}
+
+func (brokenIndex) Index(products ...*entities.Product) error {
+	return errors.New("connection refused")
+}
+
+func (brokenIndex) Delete(id uuid.UUID) error {
+	return errors.New("connection refused")
+}
+
+func TestSyncedProductRepository_IndexFailureKeepsTheWrite(t *testing.T) {
+	product := &entities.Product{ID: uuid.New(), Name: "Mug", Stock: 3, IsActive: true}
+	shelf := &shelfRepo{catalogueRepo{products: map[uuid.UUID]*entities.Product{product.ID: product}}}
+	repo := search.NewSyncedProductRepository(shelf, brokenIndex{}, logger.New())
+
+	require.NoError(t, repo.UpdateStock(product.ID, -1, entities.StockChange{Reason: entities.StockReasonSale}))
+	assert.Equal(t, 2, product.Stock)
+}
+
+func TestNewSyncedProductRepository_LeavesTableIndexesAlone(t *testing.T) {
+	shelf := &shelfRepo{catalogueRepo{products: map[uuid.UUID]*entities.Product{}}}
+
+	assert.Same(t, shelf, search.NewSyncedProductRepository(shelf, search.NewPostgresIndex(nil, shelf), logger.New()))
+	assert.NotSame(t, shelf, search.NewSyncedProductRepository(shelf, search.NewEmbeddedIndex(), logger.New()))
+}
+
+func TestSyncedVariantRepository_ReindexesTheProduct(t *testing.T) {
+	product := tshirt()
+	product.IsActive = true
+	index := search.NewEmbeddedIndex()
+	variants := search.NewSyncedVariantRepository(&memoryVariantRepo{}, &variantProductRepo{product: product}, index, logger.New())
+
+	require.NoError(t, variants.Create(&entities.ProductVariant{ProductID: product.ID, SKU: "TSHIRT-S-RED"}))
+	assert.Equal(t, []string{product.Name}, searchNames(t, index, entities.SearchQuery{Text: "shirt"}))
+}
+
+// assignedCategoryRepo renames a category on the products assigned to it, as
+// the preloads of the real repository would after the update.
+type assignedCategoryRepo struct {
+	memoryCategoryRepo
+	product *entities.Product
+}
+
+func (r *assignedCategoryRepo) Update(category *entities.Category) error {
+	for i := range r.product.Categories {
+		if r.product.Categories[i].ID == category.ID {
+			r.product.Categories[i].Name = category.Name
+		}
+	}
+	return nil
+}
+
+func (r *assignedCategoryRepo) ProductIDs(id uuid.UUID) ([]uuid.UUID, error) {
+	return []uuid.UUID{r.product.ID}, nil
+}
+
+func TestSyncedCategoryRepository_ReindexesRenamedCategories(t *testing.T) {
+	category := entities.Category{ID: uuid.New(), Name: "Kitchen", Slug: "kitchen", IsActive: true}
+	product := &entities.Product{ID: uuid.New(), Name: "Mug", Categories: []entities.Category{category}, IsActive: true}
+	index := search.NewEmbeddedIndex()
+	require.NoError(t, index.Index(product))
+	categories := search.NewSyncedCategoryRepository(&assignedCategoryRepo{product: product}, &variantProductRepo{product: product}, index, logger.New())
+
+	category.Name = "Cookware"
+	require.NoError(t, categories.Update(&category))
+	assert.Equal(t, []string{"Mug"}, searchNames(t, index, entities.SearchQuery{Text: "cookware"}))
+	assert.Empty(t, searchNames(t, index, entities.SearchQuery{Text: "kitchen"}))
+}
@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/usecases"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSession_IsActive(t *testing.T) {
+	session := &entities.Session{ExpiresAt: time.Now().Add(time.Hour)}
+	assert.True(t, session.IsActive())
+
+	// Revoked session
+	revokedAt := time.Now()
+	session.RevokedAt = &revokedAt
+	assert.False(t, session.IsActive())
+
+	// Expired session
+	expired := &entities.Session{ExpiresAt: time.Now().Add(-time.Minute)}
+	assert.False(t, expired.IsActive())
+}
+
+// memorySessionCache is a SessionCache whose entries never expire; it
+// keeps the last-seen TTLs it was given.
+type memorySessionCache struct {
+	status      map[uuid.UUID]bool
+	lastSeen    map[uuid.UUID]time.Time
+	lastSeenTTL map[uuid.UUID]time.Duration
+}
+
+func newMemorySessionCache() *memorySessionCache {
+	return &memorySessionCache{status: map[uuid.UUID]bool{}, lastSeen: map[uuid.UUID]time.Time{}, lastSeenTTL: map[uuid.UUID]time.Duration{}}
+}
+
+func (c *memorySessionCache) GetStatus(id uuid.UUID) (bool, bool, error) {
+	active, found := c.status[id]
+	return active, found, nil
+}
+
+func (c *memorySessionCache) SetStatus(id uuid.UUID, active bool, ttl time.Duration) error {
+	c.status[id] = active
+	return nil
+}
+
+func (c *memorySessionCache) SetLastSeen(id uuid.UUID, lastSeen time.Time, ttl time.Duration) error {
+	c.lastSeen[id] = lastSeen
+	c.lastSeenTTL[id] = ttl
+	return nil
+}
+
+func (c *memorySessionCache) GetLastSeen(ids []uuid.UUID) (map[uuid.UUID]time.Time, error) {
+	seen := make(map[uuid.UUID]time.Time)
+	for _, id := range ids {
+		if t, ok := c.lastSeen[id]; ok {
+			seen[id] = t
+		}
+	}
+	return seen, nil
+}
+
+func startSession(t *testing.T, uc *usecases.SessionUseCase, userID uuid.UUID) *entities.Session {
+	session, err := uc.Start(userID, usecases.ClientInfo{DeviceName: "Laptop"}, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	return session
+}
+
+func TestSessionUseCase_Validate(t *testing.T) {
+	repo, cache := newMemorySessionRepo(), newMemorySessionCache()
+	uc := usecases.NewSessionUseCase(repo, cache, time.Minute)
+	userID := uuid.New()
+	session := startSession(t, uc, userID)
+
+	// A cache miss falls back to the database and fills the cache
+	require.NoError(t, uc.Validate(session.ID, userID))
+	assert.True(t, cache.status[session.ID])
+
+	// Once cached, the database is no longer consulted
+	delete(repo.sessions, session.ID)
+	assert.NoError(t, uc.Validate(session.ID, userID))
+
+	// A cached revocation wins
+	cache.status[session.ID] = false
+	requireCode(t, uc.Validate(session.ID, userID), apperrors.CodeSessionRevoked)
+
+	// Without a cache, sessions of another user or unknown sessions are refused
+	uncached := usecases.NewSessionUseCase(repo, nil, time.Minute)
+	other := startSession(t, uncached, uuid.New())
+	requireCode(t, uncached.Validate(other.ID, userID), apperrors.CodeSessionRevoked)
+	requireCode(t, uncached.Validate(uuid.New(), userID), apperrors.CodeSessionRevoked)
+}
+
+func TestSessionUseCase_Touch(t *testing.T) {
+	repo, cache := newMemorySessionRepo(), newMemorySessionCache()
+	uc := usecases.NewSessionUseCase(repo, cache, time.Hour)
+	session := startSession(t, uc, uuid.New())
+
+	uc.Touch(session.ID, session.ExpiresAt)
+	first, ok := cache.lastSeen[session.ID]
+	require.True(t, ok)
+
+	// The buffered timestamp lives no longer than the session
+	ttl := cache.lastSeenTTL[session.ID]
+	assert.Positive(t, ttl)
+	assert.LessOrEqual(t, ttl, time.Until(session.ExpiresAt)+time.Second)
+
+	// Further requests within the interval are not written
+	uc.Touch(session.ID, session.ExpiresAt)
+	assert.Equal(t, first, cache.lastSeen[session.ID])
+	assert.Empty(t, repo.lastSeen)
+
+	// Without a cache the write goes to the database, still throttled
+	uncached := usecases.NewSessionUseCase(repo, nil, time.Hour)
+	uncached.Touch(session.ID, session.ExpiresAt)
+	uncached.Touch(session.ID, session.ExpiresAt)
+	assert.Equal(t, []uuid.UUID{session.ID}, repo.lastSeen)
+}
+
+func TestSessionUseCase_Revoke(t *testing.T) {
+	repo, cache := newMemorySessionRepo(), newMemorySessionCache()
+	uc := usecases.NewSessionUseCase(repo, cache, time.Minute)
+	userID := uuid.New()
+	session := startSession(t, uc, userID)
+	require.NoError(t, uc.Validate(session.ID, userID))
+
+	// Other users cannot revoke the session
+	requireCode(t, uc.RevokeSession(uuid.New(), session.ID), apperrors.CodeSessionNotFound)
+
+	require.NoError(t, uc.RevokeSession(userID, session.ID))
+	assert.NotNil(t, repo.sessions[session.ID].RevokedAt)
+	assert.False(t, cache.status[session.ID])
+	requireCode(t, uc.Validate(session.ID, userID), apperrors.CodeSessionRevoked)
+}
+
+func TestSessionUseCase_RevokeAll(t *testing.T) {
+	repo, cache := newMemorySessionRepo(), newMemorySessionCache()
+	uc := usecases.NewSessionUseCase(repo, cache, time.Minute)
+	userID := uuid.New()
+	laptop, phone := startSession(t, uc, userID), startSession(t, uc, userID)
+	stranger := startSession(t, uc, uuid.New())
+	for _, session := range []*entities.Session{laptop, phone} {
+		require.NoError(t, uc.Validate(session.ID, userID))
+	}
+
+	require.NoError(t, uc.RevokeAllSessions(userID))
+	for _, session := range []*entities.Session{laptop, phone} {
+		requireCode(t, uc.Validate(session.ID, userID), apperrors.CodeSessionRevoked)
+	}
+	assert.NoError(t, uc.Validate(stranger.ID, stranger.UserID))
+
+	sessions, err := uc.ListSessions(userID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
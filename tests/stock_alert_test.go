@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/infrastructure/inventory"
+	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/logger"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shelfRepo is a catalogue whose stock writes apply straight to the product.
+type shelfRepo struct {
+	catalogueRepo
+}
+
+func (r *shelfRepo) UpdateStock(id uuid.UUID, quantity int, change entities.StockChange) error {
+	r.products[id].Stock += quantity
+	return nil
+}
+
+type memoryStockAlertRepo struct {
+	entities.StockAlertRepository
+	alerts []*entities.StockAlert
+	err    error
+}
+
+func (r *memoryStockAlertRepo) Open(alert *entities.StockAlert) (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+	for _, existing := range r.alerts {
+		if existing.ProductID == alert.ProductID && existing.Status == entities.StockAlertOpen {
+			return false, nil
+		}
+	}
+	alert.ID = uuid.New()
+	alert.Status = entities.StockAlertOpen
+	r.alerts = append(r.alerts, alert)
+	return true, nil
+}
+
+func (r *memoryStockAlertRepo) ResolveOpen(productID uuid.UUID) error {
+	for _, alert := range r.alerts {
+		if alert.ProductID == productID {
+			alert.Status = entities.StockAlertResolved
+		}
+	}
+	return nil
+}
+
+type memoryBackInStockRepo struct {
+	entities.BackInStockRepository
+	subscriptions []*entities.BackInStockSubscription
+}
+
+func (r *memoryBackInStockRepo) Subscribe(subscription *entities.BackInStockSubscription) error {
+	subscription.ID = uuid.New()
+	r.subscriptions = append(r.subscriptions, subscription)
+	return nil
+}
+
+func (r *memoryBackInStockRepo) ClaimPending(productID uuid.UUID) ([]*entities.BackInStockSubscription, error) {
+	var claimed []*entities.BackInStockSubscription
+	for _, subscription := range r.subscriptions {
+		if subscription.ProductID == productID && subscription.NotifiedAt == nil {
+			now := time.Now()
+			subscription.NotifiedAt = &now
+			claimed = append(claimed, subscription)
+		}
+	}
+	return claimed, nil
+}
+
+type recordingNotifier struct {
+	lowStock    []*entities.StockAlert
+	backInStock []uuid.UUID
+}
+
+func (n *recordingNotifier) LowStock(product *entities.Product, alert *entities.StockAlert) {
+	n.lowStock = append(n.lowStock, alert)
+}
+
+func (n *recordingNotifier) BackInStock(product *entities.Product, subscriptions []*entities.BackInStockSubscription) {
+	for _, subscription := range subscriptions {
+		n.backInStock = append(n.backInStock, subscription.UserID)
+	}
+}
+
+func newWatchedShelf(product *entities.Product) (entities.ProductRepository, *memoryStockAlertRepo, *memoryBackInStockRepo, *recordingNotifier) {
+	shelf := &shelfRepo{catalogueRepo{products: map[uuid.UUID]*entities.Product{product.ID: product}}}
+	alerts := &memoryStockAlertRepo{}
+	subscriptions := &memoryBackInStockRepo{}
+	notifier := &recordingNotifier{}
+	watcher := inventory.NewWatcher(shelf, alerts, subscriptions, notifier, logger.New())
+	return inventory.NewWatchedProductRepository(shelf, watcher), alerts, subscriptions, notifier
+}
+
+func TestWatchedProductRepository_LowStockAlerts(t *testing.T) {
+	product := &entities.Product{ID: uuid.New(), Name: "Mug", Stock: 10, ReorderThreshold: 5, IsActive: true}
+	repo, alerts, _, notifier := newWatchedShelf(product)
+	adjust := entities.StockChange{Reason: entities.StockReasonAdjustment}
+
+	require.NoError(t, repo.UpdateStock(product.ID, -4, adjust))
+	assert.Empty(t, alerts.alerts, "still above the threshold")
+
+	require.NoError(t, repo.UpdateStock(product.ID, -1, adjust))
+	require.Len(t, alerts.alerts, 1)
+	assert.Equal(t, 5, alerts.alerts[0].Stock)
+	assert.Equal(t, 5, alerts.alerts[0].Threshold)
+	assert.Len(t, notifier.lowStock, 1)
+
+	require.NoError(t, repo.UpdateStock(product.ID, -2, adjust))
+	assert.Len(t, alerts.alerts, 1, "falling further does not alert again")
+
+	require.NoError(t, repo.UpdateStock(product.ID, 10, entities.StockChange{Reason: entities.StockReasonReceipt}))
+	assert.Equal(t, entities.StockAlertResolved, alerts.alerts[0].Status)
+
+	require.NoError(t, repo.UpdateStock(product.ID, -10, adjust))
+	assert.Len(t, alerts.alerts, 2, "crossing again opens a new alert")
+	assert.Len(t, notifier.lowStock, 2)
+}
+
+func TestWatchedProductRepository_AlertFailureKeepsTheWrite(t *testing.T) {
+	product := &entities.Product{ID: uuid.New(), Stock: 6, ReorderThreshold: 5, IsActive: true}
+	repo, alerts, _, _ := newWatchedShelf(product)
+	alerts.err = errors.New("connection reset")
+
+	// The stock has moved, so callers such as order placement must not fail
+	require.NoError(t, repo.UpdateStock(product.ID, -2, entities.StockChange{Reason: entities.StockReasonSale}))
+	assert.Equal(t, 4, product.Stock)
+}
+
+func TestWatchedProductRepository_ZeroThresholdNeverAlerts(t *testing.T) {
+	product := &entities.Product{ID: uuid.New(), Stock: 3, IsActive: true}
+	repo, alerts, _, _ := newWatchedShelf(product)
+
+	require.NoError(t, repo.UpdateStock(product.ID, -3, entities.StockChange{Reason: entities.StockReasonAdjustment}))
+	assert.Empty(t, alerts.alerts)
+}
+
+func TestWatchedProductRepository_NotifiesBackInStockOnce(t *testing.T) {
+	product := &entities.Product{ID: uuid.New(), Stock: 0, IsActive: true}
+	repo, _, subscriptions, notifier := newWatchedShelf(product)
+	first, second := uuid.New(), uuid.New()
+	require.NoError(t, subscriptions.Subscribe(&entities.BackInStockSubscription{ProductID: product.ID, UserID: first}))
+	require.NoError(t, subscriptions.Subscribe(&entities.BackInStockSubscription{ProductID: product.ID, UserID: second}))
+	receipt := entities.StockChange{Reason: entities.StockReasonReceipt}
+
+	require.NoError(t, repo.UpdateStock(product.ID, 2, receipt))
+	assert.ElementsMatch(t, []uuid.UUID{first, second}, notifier.backInStock)
+
+	require.NoError(t, repo.UpdateStock(product.ID, 3, receipt))
+	require.NoError(t, repo.UpdateStock(product.ID, -5, entities.StockChange{Reason: entities.StockReasonAdjustment}))
+	require.NoError(t, repo.UpdateStock(product.ID, 1, receipt))
+	assert.Len(t, notifier.backInStock, 2, "each subscription is notified once")
+}
+
+func TestStockAlertUseCase_Subscribe(t *testing.T) {
+	product := &entities.Product{ID: uuid.New(), Stock: 4, IsActive: true}
+	repo, _, subscriptions, _ := newWatchedShelf(product)
+	uc := usecases.NewStockAlertUseCase(&memoryStockAlertRepo{}, subscriptions, repo)
+	userID := uuid.New()
+
+	var appErr *apperrors.Error
+	_, err := uc.Subscribe(product.ID, userID)
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperrors.CodeProductInStock, appErr.Code)
+
+	_, err = uc.Subscribe(uuid.New(), userID)
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperrors.CodeProductNotFound, appErr.Code)
+
+	require.NoError(t, repo.UpdateStock(product.ID, -4, entities.StockChange{Reason: entities.StockReasonAdjustment}))
+	subscription, err := uc.Subscribe(product.ID, userID)
+	require.NoError(t, err)
+	assert.Equal(t, userID, subscription.UserID)
+	assert.Len(t, subscriptions.subscriptions, 1)
+}
@@ -0,0 +1,351 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/interfaces/repositories"
+	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/pagination"
+	"prototype-fiber/pkg/patch"
+	"prototype-fiber/pkg/validation"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stockMove is one stock write seen by ledgerProductRepo.
+type stockMove struct {
+	variantID *uuid.UUID
+	quantity  int
+	change    entities.StockChange
+}
+
+// ledgerProductRepo records stock writes instead of applying them.
+type ledgerProductRepo struct {
+	variantProductRepo
+	orders     *memoryOrderRepo
+	moves      []stockMove
+	unreturned []stockMove
+	deleted    bool
+}
+
+func (r *ledgerProductRepo) GetByID(id uuid.UUID) (*entities.Product, error) {
+	if r.deleted {
+		return nil, apperrors.ErrNotFound
+	}
+	return r.variantProductRepo.GetByID(id)
+}
+
+func (r *ledgerProductRepo) Delete(id uuid.UUID) error {
+	r.deleted = true
+	return nil
+}
+
+func (r *ledgerProductRepo) Update(product *entities.Product) error {
+	return nil
+}
+
+func (r *ledgerProductRepo) SetCategories(id uuid.UUID, categoryIDs []uuid.UUID) error {
+	return nil
+}
+
+func (r *ledgerProductRepo) UpdateStock(id uuid.UUID, quantity int, change entities.StockChange) error {
+	r.moves = append(r.moves, stockMove{quantity: quantity, change: change})
+	return nil
+}
+
+func (r *ledgerProductRepo) RecordSale(id uuid.UUID, variantID *uuid.UUID, quantity int, change entities.StockChange) error {
+	r.moves = append(r.moves, stockMove{variantID: variantID, quantity: -quantity, change: change})
+	return nil
+}
+
+func (r *ledgerProductRepo) RecordOrder(order *entities.Order) error {
+	return recordOrder(r.orders, order, r.RecordSale)
+}
+
+// recordOrder stands in for ProductRepository.RecordOrder, saving the order
+// to orders when given one and selling each allocation with recordSale.
+func recordOrder(orders *memoryOrderRepo, order *entities.Order, recordSale func(uuid.UUID, *uuid.UUID, int, entities.StockChange) error) error {
+	order.ID = uuid.New()
+	if orders != nil {
+		orders.order = order
+	}
+	for _, item := range order.Items {
+		for _, allocation := range item.Allocations {
+			sale := entities.StockChange{Reason: entities.StockReasonSale, WarehouseID: &allocation.WarehouseID, OrderID: &order.ID, UserID: &order.UserID}
+			if err := recordSale(item.ProductID, item.VariantID, allocation.Quantity, sale); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *ledgerProductRepo) ReturnOrder(order *entities.Order, status entities.OrderStatus, returns []entities.StockReturn) error {
+	if r.orders != nil {
+		r.orders.order.Status = status
+	}
+	for _, stock := range returns {
+		move := stockMove{variantID: stock.VariantID, quantity: stock.Quantity, change: stock.Change}
+		if stock.Unreturned {
+			r.unreturned = append(r.unreturned, move)
+		} else {
+			r.moves = append(r.moves, move)
+		}
+	}
+	return nil
+}
+
+type memoryOrderRepo struct {
+	entities.OrderRepository
+	order *entities.Order
+}
+
+func (r *memoryOrderRepo) GetByID(id uuid.UUID) (*entities.Order, error) {
+	return r.order, nil
+}
+
+func (r *memoryOrderRepo) UpdateStatus(id uuid.UUID, status entities.OrderStatus) error {
+	r.order.Status = status
+	return nil
+}
+
+func (r *memoryCartRepo) Clear(cartID uuid.UUID) error {
+	r.cart.Items = nil
+	return nil
+}
+
+func TestOrders_RecordStockMovements(t *testing.T) {
+	product := &entities.Product{ID: uuid.New(), Name: "Mug", Price: 8, Stock: 10, IsActive: true}
+	orders := &memoryOrderRepo{}
+	products := &ledgerProductRepo{variantProductRepo: variantProductRepo{product: product}, orders: orders}
+	carts := &memoryCartRepo{cart: &entities.Cart{ID: uuid.New(), Items: []entities.CartItem{{ProductID: product.ID, Quantity: 3, Price: 8}}}}
+	warehouses := &memoryWarehouseRepo{}
+	main := warehouses.add("MAIN", "", 0)
+	warehouses.levels = []*entities.StockLevel{{WarehouseID: main.ID, ProductID: product.ID, Quantity: 10}}
+	uc := usecases.NewOrderUseCase(orders, carts, products, warehouses, usecases.AllocationPolicy{})
+	userID := uuid.New()
+
+	order, err := uc.CreateOrder(userID, &usecases.CreateOrderRequest{ShippingAddress: "1 Road", BillingAddress: "1 Road"})
+	require.NoError(t, err)
+	require.NoError(t, uc.CancelOrder(userID, order.ID))
+
+	require.Len(t, products.moves, 2)
+	sale, cancel := products.moves[0], products.moves[1]
+	assert.Equal(t, -3, sale.quantity)
+	assert.Equal(t, entities.StockReasonSale, sale.change.Reason)
+	assert.Equal(t, &order.ID, sale.change.OrderID)
+	assert.Equal(t, &userID, sale.change.UserID)
+	assert.Equal(t, &main.ID, sale.change.WarehouseID)
+	assert.Equal(t, 3, cancel.quantity)
+	assert.Equal(t, entities.StockReasonCancel, cancel.change.Reason)
+	assert.Equal(t, &order.ID, cancel.change.OrderID)
+	assert.Equal(t, &main.ID, cancel.change.WarehouseID)
+}
+
+// soldOutProductRepo finds the stock gone by the time the order is written.
+type soldOutProductRepo struct {
+	ledgerProductRepo
+}
+
+func (r *soldOutProductRepo) RecordOrder(order *entities.Order) error {
+	return apperrors.InsufficientStock(order.Items[0].ProductID, "", order.Items[0].Quantity, 0)
+}
+
+func TestCreateOrder_StockTakenSinceAllocation(t *testing.T) {
+	product := &entities.Product{ID: uuid.New(), Name: "Mug", Price: 8, Stock: 10, IsActive: true}
+	products := &soldOutProductRepo{ledgerProductRepo{variantProductRepo: variantProductRepo{product: product}}}
+	carts := &memoryCartRepo{cart: &entities.Cart{ID: uuid.New(), Items: []entities.CartItem{{ProductID: product.ID, Quantity: 3, Price: 8}}}}
+	warehouses := &memoryWarehouseRepo{}
+	main := warehouses.add("MAIN", "", 0)
+	warehouses.levels = []*entities.StockLevel{{WarehouseID: main.ID, ProductID: product.ID, Quantity: 10}}
+	uc := usecases.NewOrderUseCase(&memoryOrderRepo{}, carts, products, warehouses, usecases.AllocationPolicy{})
+
+	_, err := uc.CreateOrder(uuid.New(), &usecases.CreateOrderRequest{ShippingAddress: "1 Road", BillingAddress: "1 Road"})
+	requireCode(t, err, apperrors.CodeInsufficientStock)
+	assert.Len(t, carts.cart.Items, 1, "the cart is kept for another try")
+}
+
+func TestUpdateOrderStatus_RefundRestocks(t *testing.T) {
+	product := &entities.Product{ID: uuid.New(), Name: "Mug", Stock: 7}
+	orders := &memoryOrderRepo{order: &entities.Order{
+		ID:     uuid.New(),
+		Status: entities.OrderStatusPaid,
+		Items:  []entities.OrderItem{{ProductID: product.ID, Quantity: 2}},
+	}}
+	products := &ledgerProductRepo{variantProductRepo: variantProductRepo{product: product}, orders: orders}
+	adminID := uuid.New()
+
+	err := usecases.NewOrderUseCase(orders, nil, products, nil, usecases.AllocationPolicy{}).UpdateOrderStatus(orders.order.ID, entities.OrderStatusRefunded, adminID)
+	require.NoError(t, err)
+
+	require.Len(t, products.moves, 1)
+	assert.Equal(t, 2, products.moves[0].quantity)
+	assert.Equal(t, entities.StockReasonRefund, products.moves[0].change.Reason)
+	assert.Equal(t, &adminID, products.moves[0].change.UserID)
+	assert.Equal(t, entities.OrderStatusRefunded, orders.order.Status)
+}
+
+// returnedProductRepo finds the order already returned by someone else.
+type returnedProductRepo struct {
+	ledgerProductRepo
+}
+
+func (r *returnedProductRepo) ReturnOrder(order *entities.Order, status entities.OrderStatus, returns []entities.StockReturn) error {
+	return apperrors.ErrConflict
+}
+
+func TestCancelOrder_AlreadyReturned(t *testing.T) {
+	product := &entities.Product{ID: uuid.New(), Name: "Mug", Stock: 7}
+	userID := uuid.New()
+	orders := &memoryOrderRepo{order: &entities.Order{
+		ID:     uuid.New(),
+		UserID: userID,
+		Status: entities.OrderStatusPaid,
+		Items:  []entities.OrderItem{{ProductID: product.ID, Quantity: 2}},
+	}}
+	products := &returnedProductRepo{ledgerProductRepo{variantProductRepo: variantProductRepo{product: product}}}
+
+	err := usecases.NewOrderUseCase(orders, nil, products, nil, usecases.AllocationPolicy{}).CancelOrder(userID, orders.order.ID)
+	requireCode(t, err, apperrors.CodeInvalidStatusTransition)
+}
+
+func TestRestock_DeletedFromCatalogue(t *testing.T) {
+	t.Run("product", func(t *testing.T) {
+		product := &entities.Product{ID: uuid.New(), Name: "Mug", Price: 8, Stock: 10, IsActive: true}
+		orders := &memoryOrderRepo{}
+		products := &ledgerProductRepo{variantProductRepo: variantProductRepo{product: product}, orders: orders}
+		carts := &memoryCartRepo{cart: &entities.Cart{ID: uuid.New(), Items: []entities.CartItem{{ProductID: product.ID, Quantity: 3, Price: 8}}}}
+		warehouses := &memoryWarehouseRepo{}
+		main := warehouses.add("MAIN", "", 0)
+		warehouses.levels = []*entities.StockLevel{{WarehouseID: main.ID, ProductID: product.ID, Quantity: 10}}
+		uc := usecases.NewOrderUseCase(orders, carts, products, warehouses, usecases.AllocationPolicy{})
+		userID := uuid.New()
+
+		order, err := uc.CreateOrder(userID, &usecases.CreateOrderRequest{ShippingAddress: "1 Road", BillingAddress: "1 Road"})
+		require.NoError(t, err)
+		require.NoError(t, usecases.NewProductUseCase(products, &memoryVariantRepo{}, nil, nil, nil, nil).DeleteProduct(product.ID))
+		require.NoError(t, uc.CancelOrder(userID, order.ID))
+
+		assert.Len(t, products.moves, 1, "only the sale moved stock")
+		require.Len(t, products.unreturned, 1)
+		assert.Equal(t, 3, products.unreturned[0].quantity)
+		assert.Equal(t, entities.StockReasonCancel, products.unreturned[0].change.Reason)
+		assert.Equal(t, &order.ID, products.unreturned[0].change.OrderID)
+	})
+
+	t.Run("variant", func(t *testing.T) {
+		product := tshirt()
+		deletedVariant := uuid.New()
+		orders := &memoryOrderRepo{order: &entities.Order{
+			ID:     uuid.New(),
+			Status: entities.OrderStatusPaid,
+			Items: []entities.OrderItem{
+				{ProductID: product.ID, VariantID: &deletedVariant, Quantity: 1},
+				{ProductID: product.ID, VariantID: &product.Variants[0].ID, Quantity: 2},
+			},
+		}}
+		products := &ledgerProductRepo{variantProductRepo: variantProductRepo{product: product}, orders: orders}
+
+		err := usecases.NewOrderUseCase(orders, nil, products, nil, usecases.AllocationPolicy{}).UpdateOrderStatus(orders.order.ID, entities.OrderStatusRefunded, uuid.New())
+		require.NoError(t, err)
+
+		require.Len(t, products.unreturned, 1)
+		assert.Equal(t, &deletedVariant, products.unreturned[0].variantID)
+		require.Len(t, products.moves, 1)
+		assert.Equal(t, &product.Variants[0].ID, products.moves[0].variantID)
+		assert.Equal(t, entities.OrderStatusRefunded, orders.order.Status)
+	})
+}
+
+func TestUpdateProduct_MovesStockThroughLedger(t *testing.T) {
+	product := &entities.Product{ID: uuid.New(), Name: "Mug", SKU: "MUG-1", Price: 8, Stock: 10, IsActive: true}
+	products := &ledgerProductRepo{variantProductRepo: variantProductRepo{product: product}}
+	uc := usecases.NewProductUseCase(products, &memoryVariantRepo{}, nil, nil, nil, nil)
+	adminID := uuid.New()
+
+	stock := 4
+	_, err := uc.UpdateProduct(product.ID, &usecases.UpdateProductRequest{Stock: &stock}, patch.Nulls{}, adminID)
+	require.NoError(t, err)
+
+	quantity := 5
+	err = uc.UpdateStock(product.ID, &usecases.AdjustStockRequest{Quantity: &quantity, Reason: entities.StockReasonReceipt, Note: "PO-17"}, adminID)
+	require.NoError(t, err)
+
+	require.Len(t, products.moves, 2)
+	assert.Equal(t, stockMove{quantity: -6, change: entities.StockChange{Reason: entities.StockReasonAdjustment, UserID: &adminID}}, products.moves[0])
+	assert.Equal(t, stockMove{quantity: 5, change: entities.StockChange{Reason: entities.StockReasonReceipt, UserID: &adminID, Note: "PO-17"}}, products.moves[1])
+}
+
+func TestAdjustStockRequest_Validation(t *testing.T) {
+	quantity := 3
+	validator := validation.New()
+
+	assert.NoError(t, validator.Struct(&usecases.AdjustStockRequest{Quantity: &quantity}))
+	assert.NoError(t, validator.Struct(&usecases.AdjustStockRequest{Quantity: &quantity, Reason: entities.StockReasonReceipt}))
+	assert.Error(t, validator.Struct(&usecases.AdjustStockRequest{Quantity: &quantity, Reason: entities.StockReasonSale}))
+	assert.Error(t, validator.Struct(&usecases.AdjustStockRequest{}))
+}
+
+type fixedLedger struct {
+	entities.StockMovementRepository
+	discrepancies []*entities.StockDiscrepancy
+}
+
+func (l *fixedLedger) Reconcile() ([]*entities.StockDiscrepancy, error) {
+	return l.discrepancies, nil
+}
+
+func TestReconcile(t *testing.T) {
+	ledger := &fixedLedger{discrepancies: []*entities.StockDiscrepancy{}}
+	uc := usecases.NewInventoryUseCase(ledger, nil)
+
+	result, err := uc.Reconcile()
+	require.NoError(t, err)
+	assert.True(t, result.Consistent)
+
+	ledger.discrepancies = append(ledger.discrepancies, &entities.StockDiscrepancy{SKU: "MUG-1", Stock: 5, LedgerBalance: 3})
+	result, err = uc.Reconcile()
+	require.NoError(t, err)
+	assert.False(t, result.Consistent)
+	assert.Len(t, result.Discrepancies, 1)
+}
+
+func TestListStockMovements_UnknownProduct(t *testing.T) {
+	products := &variantProductRepo{product: &entities.Product{ID: uuid.New()}}
+	_, err := usecases.NewInventoryUseCase(&fixedLedger{}, products).ListStockMovements(uuid.New(), pagination.Params{Limit: 10})
+
+	var appErr *apperrors.Error
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperrors.CodeProductNotFound, appErr.Code)
+}
+
+func TestDeleteVariant_WritesOffItsStock(t *testing.T) {
+	tx := newPostgresTx(t, "the variant ledger")
+	require.NoError(t, tx.Create(&entities.Warehouse{Code: "LEDGER-TEST", Name: "Ledger test", Priority: -1}).Error)
+	product := &entities.Product{Name: "T-Shirt", SKU: "LEDGER-TSHIRT", Price: 15, IsActive: true}
+	require.NoError(t, repositories.NewProductRepository(tx).Create(product))
+	variants := repositories.NewProductVariantRepository(tx)
+	variant := &entities.ProductVariant{ProductID: product.ID, SKU: "LEDGER-TSHIRT-S", Stock: 4, IsActive: true}
+	require.NoError(t, variants.Create(variant))
+
+	require.NoError(t, variants.Delete(variant.ID))
+
+	page, err := repositories.NewStockMovementRepository(tx).ListByProductID(product.ID, pagination.Params{Limit: 10})
+	require.NoError(t, err)
+	total, writtenOff := 0, 0
+	for _, movement := range page.Items {
+		if movement.VariantID != nil && *movement.VariantID == variant.ID {
+			total += movement.Delta
+			if movement.Note == "variant deleted" {
+				writtenOff = movement.Delta
+			}
+		}
+	}
+	assert.Zero(t, total)
+	assert.Equal(t, -4, writtenOff)
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"prototype-fiber/pkg/tokens"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeySet(t *testing.T, activeKID string, keys ...*tokens.Key) *tokens.KeySet {
+	ks, err := tokens.NewKeySetFromKeys(activeKID, "test-issuer", "test-audience", time.Hour, keys...)
+	require.NoError(t, err)
+	return ks
+}
+
+func TestKeySet_IssueAndParse(t *testing.T) {
+	for _, alg := range []string{tokens.AlgorithmEdDSA, tokens.AlgorithmRS256} {
+		key, err := tokens.GenerateKey("k1", alg)
+		require.NoError(t, err)
+		ks := newTestKeySet(t, "k1", key)
+
+		token, err := ks.Issue(&tokens.Claims{UserID: "u-1", Email: "a@b.c", Role: "customer"})
+		require.NoError(t, err)
+
+		claims, err := ks.Parse(token)
+		require.NoError(t, err)
+		assert.Equal(t, "u-1", claims.UserID)
+		assert.Equal(t, "customer", claims.Role)
+		assert.Equal(t, "test-issuer", claims.Issuer)
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldKey, _ := tokens.GenerateKey("old", tokens.AlgorithmEdDSA)
+	newKey, _ := tokens.GenerateKey("new", tokens.AlgorithmEdDSA)
+
+	oldSet := newTestKeySet(t, "old", oldKey)
+	token, err := oldSet.Issue(&tokens.Claims{UserID: "u-1"})
+	require.NoError(t, err)
+
+	// Tokens signed by a retired key still verify while it is published
+	rotated := newTestKeySet(t, "new", newKey, oldKey.PublicOnly())
+	_, err = rotated.Parse(token)
+	assert.NoError(t, err)
+
+	// Once removed, they no longer do
+	removed := newTestKeySet(t, "new", newKey)
+	_, err = removed.Parse(token)
+	assert.ErrorIs(t, err, tokens.ErrInvalidToken)
+
+	assert.Len(t, rotated.JWKS().Keys, 2)
+}
+
+func TestKeySet_RejectsUnexpectedAlgorithm(t *testing.T) {
+	key, _ := tokens.GenerateKey("k1", tokens.AlgorithmEdDSA)
+	ks := newTestKeySet(t, "k1", key)
+
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": "u-1",
+		"sub":     "u-1",
+		"iss":     "test-issuer",
+		"aud":     "test-audience",
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	})
+	hmac.Header["kid"] = "k1"
+	signed, err := hmac.SignedString([]byte("your-secret-key"))
+	require.NoError(t, err)
+
+	_, err = ks.Parse(signed)
+	assert.ErrorIs(t, err, tokens.ErrInvalidToken)
+}
+
+func TestKeySet_RejectsWrongAudience(t *testing.T) {
+	key, _ := tokens.GenerateKey("k1", tokens.AlgorithmEdDSA)
+	issuer := newTestKeySet(t, "k1", key)
+	token, err := issuer.Issue(&tokens.Claims{UserID: "u-1"})
+	require.NoError(t, err)
+
+	other, err := tokens.NewKeySetFromKeys("k1", "test-issuer", "other-audience", time.Hour, key)
+	require.NoError(t, err)
+	_, err = other.Parse(token)
+	assert.ErrorIs(t, err, tokens.ErrInvalidToken)
+}
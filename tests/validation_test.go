@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"testing"
+
+	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/validation"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidator_ReportsFieldErrors(t *testing.T) {
+	v := validation.New()
+
+	err := v.Struct(&usecases.AddToCartRequest{Quantity: -1})
+	require.Error(t, err)
+
+	fieldErrors, ok := err.(validation.Errors)
+	require.True(t, ok)
+	require.Len(t, fieldErrors, 2)
+	assert.Equal(t, validation.FieldError{Field: "product_id", Rule: "required", Message: "product_id is required"}, fieldErrors[0])
+	assert.Equal(t, "quantity", fieldErrors[1].Field)
+	assert.Equal(t, "gt", fieldErrors[1].Rule)
+
+	assert.NoError(t, v.Struct(&usecases.AddToCartRequest{ProductID: uuid.New(), Quantity: 2}))
+}
+
+func TestValidator_CustomRules(t *testing.T) {
+	v := validation.New()
+
+	valid := &usecases.CreateProductRequest{Name: "Mug", Price: 9.5, SKU: "MUG-001"}
+	assert.NoError(t, v.Struct(valid))
+
+	lowercase := &usecases.CreateProductRequest{Name: "Mug", Price: 9.5, SKU: "mug-001"}
+	assert.NoError(t, v.Struct(lowercase))
+
+	badSKU := &usecases.CreateProductRequest{Name: "Mug", Price: 9.5, SKU: "mug 001"}
+	err := v.Struct(badSKU)
+	require.Error(t, err)
+	assert.Equal(t, "sku", err.(validation.Errors)[0].Rule)
+
+	order := &usecases.CreateOrderRequest{ShippingAddress: "1 Main St", BillingAddress: "1 Main St", ShippingCountry: "XX"}
+	err = v.Struct(order)
+	require.Error(t, err)
+	assert.Equal(t, "shipping_country", err.(validation.Errors)[0].Field)
+	assert.Equal(t, "country", err.(validation.Errors)[0].Rule)
+
+	order.ShippingCountry = "DE"
+	assert.NoError(t, v.Struct(order))
+}
+
+func TestValidator_RegisterRequiresValidEmail(t *testing.T) {
+	v := validation.New()
+
+	err := v.Struct(&usecases.RegisterRequest{Email: "not-an-email", Password: "x", FirstName: "A", LastName: "B"})
+	require.Error(t, err)
+	assert.Equal(t, "email", err.(validation.Errors)[0].Field)
+}
+
+func TestValidator_IgnoresNonStructs(t *testing.T) {
+	assert.NoError(t, validation.New().Struct(&map[string]interface{}{"stock": 1}))
+}
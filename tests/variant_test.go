@@ -0,0 +1,225 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/infrastructure/search"
+	"prototype-fiber/internal/usecases"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tshirt returns an active product with size and colour options and one
+// variant, M / Red, priced above the product.
+func tshirt() *entities.Product {
+	size := entities.ProductOption{ID: uuid.New(), Name: "size", Position: 0}
+	size.Values = []entities.ProductOptionValue{
+		{ID: uuid.New(), OptionID: size.ID, Value: "S"},
+		{ID: uuid.New(), OptionID: size.ID, Value: "M", Position: 1},
+	}
+	colour := entities.ProductOption{ID: uuid.New(), Name: "colour", Position: 1}
+	colour.Values = []entities.ProductOptionValue{
+		{ID: uuid.New(), OptionID: colour.ID, Value: "Red"},
+	}
+
+	price := 25.0
+	product := &entities.Product{
+		ID:       uuid.New(),
+		Name:     "T-Shirt",
+		Price:    20,
+		Stock:    3,
+		IsActive: true,
+		Options:  []entities.ProductOption{size, colour},
+	}
+	product.Variants = []entities.ProductVariant{{
+		ID:        uuid.New(),
+		ProductID: product.ID,
+		SKU:       "TSHIRT-M-RED",
+		Price:     &price,
+		Stock:     3,
+		IsActive:  true,
+		// Stored order is not option order.
+		OptionValues: []entities.ProductOptionValue{colour.Values[0], size.Values[1]},
+	}}
+	return product
+}
+
+func TestProductVariant_PriceAndStock(t *testing.T) {
+	product := tshirt()
+	variant := &product.Variants[0]
+
+	assert.Equal(t, 25.0, variant.EffectivePrice(product))
+	variant.Price = nil
+	assert.Equal(t, 20.0, variant.EffectivePrice(product))
+
+	assert.True(t, variant.IsInStock())
+	assert.True(t, variant.CanFulfillQuantity(3))
+	assert.False(t, variant.CanFulfillQuantity(4))
+
+	variant.IsActive = false
+	assert.False(t, variant.IsInStock())
+	assert.False(t, variant.CanFulfillQuantity(1))
+}
+
+func TestProduct_Variants(t *testing.T) {
+	product := tshirt()
+	variant := &product.Variants[0]
+
+	assert.True(t, product.HasVariants())
+	assert.Same(t, variant, product.Variant(variant.ID))
+	assert.Nil(t, product.Variant(uuid.New()))
+	assert.Equal(t, "M / Red", product.VariantLabel(variant))
+
+	assert.False(t, (&entities.Product{}).HasVariants())
+}
+
+type variantProductRepo struct {
+	entities.ProductRepository
+	product *entities.Product
+}
+
+func (r *variantProductRepo) GetByID(id uuid.UUID) (*entities.Product, error) {
+	if id != r.product.ID {
+		return nil, apperrors.ErrNotFound
+	}
+	return r.product, nil
+}
+
+func (r *variantProductRepo) GetBySKU(sku string) (*entities.Product, error) {
+	return nil, apperrors.ErrNotFound
+}
+
+type memoryVariantRepo struct {
+	entities.ProductVariantRepository
+	created []*entities.ProductVariant
+}
+
+func (r *memoryVariantRepo) GetBySKU(sku string) (*entities.ProductVariant, error) {
+	for _, variant := range r.created {
+		if variant.SKU == sku {
+			return variant, nil
+		}
+	}
+	return nil, apperrors.ErrNotFound
+}
+
+func (r *memoryVariantRepo) Create(variant *entities.ProductVariant) error {
+	variant.ID = uuid.New()
+	r.created = append(r.created, variant)
+	return nil
+}
+
+func newVariantUseCase(product *entities.Product) (*usecases.ProductUseCase, *memoryVariantRepo) {
+	variants := &memoryVariantRepo{}
+	uc := usecases.NewProductUseCase(&variantProductRepo{product: product}, variants, nil, search.NewEmbeddedIndex(), nil, nil)
+	return uc, variants
+}
+
+func TestCreateVariant_MatchesOptionsByName(t *testing.T) {
+	product := tshirt()
+	uc, variants := newVariantUseCase(product)
+
+	variant, err := uc.CreateVariant(product.ID, &usecases.CreateVariantRequest{
+		SKU:     "TSHIRT-S-RED",
+		Stock:   5,
+		Options: map[string]string{"Colour": "red", "size": "S"},
+	})
+	require.NoError(t, err)
+	require.Len(t, variants.created, 1)
+	assert.True(t, variant.IsActive)
+	assert.Equal(t, product.ID, variant.ProductID)
+	assert.Equal(t, []string{"S", "Red"}, []string{variant.OptionValues[0].Value, variant.OptionValues[1].Value})
+}
+
+func TestCreateVariant_Rejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		sku     string
+		options map[string]string
+		code    string
+	}{
+		{"missing option", "TSHIRT-S", map[string]string{"size": "S"}, apperrors.CodeInvalidVariantOptions},
+		{"unknown option", "TSHIRT-S", map[string]string{"size": "S", "fit": "slim"}, apperrors.CodeInvalidVariantOptions},
+		{"unknown value", "TSHIRT-XL", map[string]string{"size": "XL", "colour": "Red"}, apperrors.CodeInvalidVariantOptions},
+		{"duplicate combination", "TSHIRT-M-RED-2", map[string]string{"size": "M", "colour": "Red"}, apperrors.CodeVariantExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := tshirt()
+			uc, variants := newVariantUseCase(product)
+
+			_, err := uc.CreateVariant(product.ID, &usecases.CreateVariantRequest{SKU: tt.sku, Options: tt.options})
+
+			var appErr *apperrors.Error
+			require.True(t, errors.As(err, &appErr), "got %v", err)
+			assert.Equal(t, tt.code, appErr.Code)
+			assert.Empty(t, variants.created)
+		})
+	}
+}
+
+type memoryCartRepo struct {
+	entities.CartRepository
+	cart *entities.Cart
+}
+
+func (r *memoryCartRepo) GetByUserID(userID uuid.UUID) (*entities.Cart, error) {
+	return r.cart, nil
+}
+
+func (r *memoryCartRepo) GetByID(id uuid.UUID) (*entities.Cart, error) {
+	return r.cart, nil
+}
+
+func (r *memoryCartRepo) AddItem(cartID uuid.UUID, item *entities.CartItem) error {
+	r.cart.Items = append(r.cart.Items, *item)
+	return nil
+}
+
+func TestAddToCart_Variants(t *testing.T) {
+	product := tshirt()
+	variant := product.Variants[0]
+
+	t.Run("uses the variant price", func(t *testing.T) {
+		carts := &memoryCartRepo{cart: &entities.Cart{ID: uuid.New()}}
+		uc := usecases.NewCartUseCase(carts, &variantProductRepo{product: product}, nil, 0)
+
+		cart, err := uc.AddToCart(uuid.New(), &usecases.AddToCartRequest{ProductID: product.ID, VariantID: &variant.ID, Quantity: 2})
+		require.NoError(t, err)
+		require.Len(t, cart.Items, 1)
+		assert.Equal(t, &variant.ID, cart.Items[0].VariantID)
+		assert.Equal(t, 25.0, cart.Items[0].Price)
+	})
+
+	t.Run("requires a variant", func(t *testing.T) {
+		carts := &memoryCartRepo{cart: &entities.Cart{ID: uuid.New()}}
+		uc := usecases.NewCartUseCase(carts, &variantProductRepo{product: product}, nil, 0)
+
+		_, err := uc.AddToCart(uuid.New(), &usecases.AddToCartRequest{ProductID: product.ID, Quantity: 1})
+
+		var appErr *apperrors.Error
+		require.True(t, errors.As(err, &appErr))
+		assert.Equal(t, apperrors.CodeVariantRequired, appErr.Code)
+	})
+
+	t.Run("checks variant stock", func(t *testing.T) {
+		carts := &memoryCartRepo{cart: &entities.Cart{ID: uuid.New()}}
+		uc := usecases.NewCartUseCase(carts, &variantProductRepo{product: product}, nil, 0)
+
+		_, err := uc.AddToCart(uuid.New(), &usecases.AddToCartRequest{ProductID: product.ID, VariantID: &variant.ID, Quantity: 4})
+
+		var appErr *apperrors.Error
+		require.True(t, errors.As(err, &appErr))
+		assert.Equal(t, apperrors.CodeInsufficientStock, appErr.Code)
+		details := appErr.Details.(apperrors.StockDetails)
+		assert.Equal(t, &variant.ID, details.VariantID)
+		assert.Equal(t, "T-Shirt (M / Red)", details.ProductName)
+		assert.Empty(t, carts.cart.Items)
+	})
+}
@@ -0,0 +1,212 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/usecases"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryWarehouseRepo struct {
+	entities.WarehouseRepository
+	warehouses []*entities.Warehouse
+	levels     []*entities.StockLevel
+}
+
+func (r *memoryWarehouseRepo) add(code, country string, priority int) *entities.Warehouse {
+	warehouse := &entities.Warehouse{ID: uuid.New(), Code: code, Name: code, Country: country, Priority: priority}
+	r.warehouses = append(r.warehouses, warehouse)
+	return warehouse
+}
+
+func (r *memoryWarehouseRepo) stock(warehouse *entities.Warehouse, product *entities.Product, quantity int) {
+	r.levels = append(r.levels, &entities.StockLevel{WarehouseID: warehouse.ID, ProductID: product.ID, Quantity: quantity})
+	product.Stock += quantity
+}
+
+func (r *memoryWarehouseRepo) GetByID(id uuid.UUID) (*entities.Warehouse, error) {
+	for _, warehouse := range r.warehouses {
+		if warehouse.ID == id {
+			return warehouse, nil
+		}
+	}
+	return nil, apperrors.ErrNotFound
+}
+
+func (r *memoryWarehouseRepo) List() ([]*entities.Warehouse, error) {
+	return r.warehouses, nil
+}
+
+func (r *memoryWarehouseRepo) HasStock(id uuid.UUID) (bool, error) {
+	for _, level := range r.levels {
+		if level.WarehouseID == id && level.Quantity != 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryWarehouseRepo) Delete(id uuid.UUID) error {
+	return nil
+}
+
+func (r *memoryWarehouseRepo) ListStockLevels(productIDs []uuid.UUID) ([]*entities.StockLevel, error) {
+	return r.levels, nil
+}
+
+// stockProductRepo is a catalogue that records sales instead of applying
+// them.
+type stockProductRepo struct {
+	catalogueRepo
+	moves []stockMove
+}
+
+func (r *stockProductRepo) RecordSale(id uuid.UUID, variantID *uuid.UUID, quantity int, change entities.StockChange) error {
+	r.moves = append(r.moves, stockMove{variantID: variantID, quantity: -quantity, change: change})
+	return nil
+}
+
+func (r *stockProductRepo) RecordOrder(order *entities.Order) error {
+	return recordOrder(nil, order, r.RecordSale)
+}
+
+// placeOrder orders the given quantities and returns the warehouse each
+// unit of stock was taken from, keyed by product.
+func placeOrder(t *testing.T, policy usecases.AllocationPolicy, warehouses *memoryWarehouseRepo, country string, items map[*entities.Product]int) (map[uuid.UUID]map[uuid.UUID]int, error) {
+	products := &stockProductRepo{catalogueRepo: catalogueRepo{products: map[uuid.UUID]*entities.Product{}}}
+	cart := &entities.Cart{ID: uuid.New()}
+	for product, quantity := range items {
+		products.products[product.ID] = product
+		cart.Items = append(cart.Items, entities.CartItem{ProductID: product.ID, Quantity: quantity, Price: product.Price})
+	}
+	uc := usecases.NewOrderUseCase(&memoryOrderRepo{}, &memoryCartRepo{cart: cart}, products, warehouses, policy)
+
+	order, err := uc.CreateOrder(uuid.New(), &usecases.CreateOrderRequest{ShippingAddress: "1 Road", ShippingCountry: country, BillingAddress: "1 Road"})
+	if err != nil {
+		return nil, err
+	}
+
+	taken := make(map[uuid.UUID]map[uuid.UUID]int)
+	for _, item := range order.Items {
+		taken[item.ProductID] = make(map[uuid.UUID]int)
+		for _, allocation := range item.Allocations {
+			taken[item.ProductID][allocation.WarehouseID] += allocation.Quantity
+		}
+	}
+	for _, move := range products.moves {
+		require.NotNil(t, move.change.WarehouseID)
+		assert.Equal(t, entities.StockReasonSale, move.change.Reason)
+	}
+	return taken, nil
+}
+
+func TestCreateOrder_PrefersOneWarehouseForTheWholeOrder(t *testing.T) {
+	warehouses := &memoryWarehouseRepo{}
+	east := warehouses.add("EAST", "US", 0)
+	west := warehouses.add("WEST", "US", 1)
+	mug := &entities.Product{ID: uuid.New(), Name: "Mug", Price: 8, IsActive: true}
+	bowl := &entities.Product{ID: uuid.New(), Name: "Bowl", Price: 12, IsActive: true}
+	warehouses.stock(east, mug, 5)
+	warehouses.stock(west, mug, 5)
+	warehouses.stock(west, bowl, 5)
+
+	policy, err := usecases.NewAllocationPolicy(usecases.AllocationPriority, true)
+	require.NoError(t, err)
+	taken, err := placeOrder(t, policy, warehouses, "US", map[*entities.Product]int{mug: 2, bowl: 1})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[uuid.UUID]int{west.ID: 2}, taken[mug.ID])
+	assert.Equal(t, map[uuid.UUID]int{west.ID: 1}, taken[bowl.ID])
+}
+
+func TestCreateOrder_NearestStrategy(t *testing.T) {
+	warehouses := &memoryWarehouseRepo{}
+	us := warehouses.add("US", "US", 0)
+	de := warehouses.add("DE", "DE", 5)
+	mug := &entities.Product{ID: uuid.New(), Name: "Mug", Price: 8, IsActive: true}
+	warehouses.stock(us, mug, 5)
+	warehouses.stock(de, mug, 5)
+
+	nearest, err := usecases.NewAllocationPolicy(usecases.AllocationNearest, true)
+	require.NoError(t, err)
+	taken, err := placeOrder(t, nearest, warehouses, "DE", map[*entities.Product]int{mug: 1})
+	require.NoError(t, err)
+	assert.Equal(t, map[uuid.UUID]int{de.ID: 1}, taken[mug.ID])
+
+	taken, err = placeOrder(t, nearest, warehouses, "FR", map[*entities.Product]int{mug: 1})
+	require.NoError(t, err)
+	assert.Equal(t, map[uuid.UUID]int{us.ID: 1}, taken[mug.ID])
+}
+
+func TestCreateOrder_SplitsOnlyWhenAllowed(t *testing.T) {
+	warehouses := &memoryWarehouseRepo{}
+	east := warehouses.add("EAST", "US", 0)
+	west := warehouses.add("WEST", "US", 1)
+	mug := &entities.Product{ID: uuid.New(), Name: "Mug", Price: 8, IsActive: true}
+	warehouses.stock(east, mug, 3)
+	warehouses.stock(west, mug, 4)
+
+	split, err := usecases.NewAllocationPolicy(usecases.AllocationPriority, true)
+	require.NoError(t, err)
+	taken, err := placeOrder(t, split, warehouses, "US", map[*entities.Product]int{mug: 6})
+	require.NoError(t, err)
+	assert.Equal(t, map[uuid.UUID]int{east.ID: 3, west.ID: 3}, taken[mug.ID])
+
+	whole, err := usecases.NewAllocationPolicy(usecases.AllocationPriority, false)
+	require.NoError(t, err)
+	_, err = placeOrder(t, whole, warehouses, "US", map[*entities.Product]int{mug: 6})
+
+	var appErr *apperrors.Error
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperrors.CodeAllocationFailed, appErr.Code)
+}
+
+func TestNewAllocationPolicy_RejectsUnknownStrategy(t *testing.T) {
+	_, err := usecases.NewAllocationPolicy("cheapest", true)
+	assert.Error(t, err)
+}
+
+func TestDeleteWarehouse(t *testing.T) {
+	warehouses := &memoryWarehouseRepo{}
+	main := warehouses.add("MAIN", "", 0)
+	uc := usecases.NewWarehouseUseCase(warehouses, nil)
+
+	var appErr *apperrors.Error
+	require.True(t, errors.As(uc.DeleteWarehouse(main.ID), &appErr))
+	assert.Equal(t, apperrors.CodeLastWarehouse, appErr.Code)
+
+	spare := warehouses.add("SPARE", "", 1)
+	warehouses.stock(spare, &entities.Product{ID: uuid.New()}, 2)
+	require.True(t, errors.As(uc.DeleteWarehouse(spare.ID), &appErr))
+	assert.Equal(t, apperrors.CodeWarehouseNotEmpty, appErr.Code)
+
+	assert.NoError(t, uc.DeleteWarehouse(main.ID))
+}
+
+func TestGetAvailability_PickupLocationsOnly(t *testing.T) {
+	warehouses := &memoryWarehouseRepo{}
+	depot := warehouses.add("DEPOT", "US", 0)
+	store := warehouses.add("STORE", "US", 1)
+	store.Pickup = true
+	mug := &entities.Product{ID: uuid.New(), Name: "Mug", IsActive: true}
+	warehouses.stock(depot, mug, 10)
+	warehouses.stock(store, mug, 2)
+	uc := usecases.NewWarehouseUseCase(warehouses, &variantProductRepo{product: mug})
+
+	availability, err := uc.GetAvailability(mug.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, 12, availability.Stock)
+	require.Len(t, availability.Locations, 1)
+	assert.Equal(t, "STORE", availability.Locations[0].Warehouse.Code)
+	assert.Equal(t, 2, availability.Locations[0].Quantity)
+
+	availability, err = uc.GetAvailability(mug.ID, false)
+	require.NoError(t, err)
+	assert.Len(t, availability.Locations, 2)
+}
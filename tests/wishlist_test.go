@@ -0,0 +1,213 @@
+package tests
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"prototype-fiber/internal/domain/apperrors"
+	"prototype-fiber/internal/domain/entities"
+	"prototype-fiber/internal/interfaces/http/handlers"
+	"prototype-fiber/internal/interfaces/http/problem"
+	"prototype-fiber/internal/usecases"
+	"prototype-fiber/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryWishlistRepo keeps wishlists in memory, resolving items against a
+// single product.
+type memoryWishlistRepo struct {
+	entities.WishlistRepository
+	product   *entities.Product
+	wishlists map[uuid.UUID]*entities.Wishlist
+}
+
+func (r *memoryWishlistRepo) Create(wishlist *entities.Wishlist) error {
+	wishlist.ID = uuid.New()
+	copied := *wishlist
+	r.wishlists[wishlist.ID] = &copied
+	return nil
+}
+
+func (r *memoryWishlistRepo) GetByID(id uuid.UUID) (*entities.Wishlist, error) {
+	wishlist, ok := r.wishlists[id]
+	if !ok {
+		return nil, apperrors.ErrNotFound
+	}
+	copied := *wishlist
+	copied.Items = append([]entities.WishlistItem(nil), wishlist.Items...)
+	return &copied, nil
+}
+
+func (r *memoryWishlistRepo) GetByShareToken(token string) (*entities.Wishlist, error) {
+	for _, wishlist := range r.wishlists {
+		if wishlist.ShareToken != nil && *wishlist.ShareToken == token {
+			return r.GetByID(wishlist.ID)
+		}
+	}
+	return nil, apperrors.ErrNotFound
+}
+
+func (r *memoryWishlistRepo) Update(wishlist *entities.Wishlist) error {
+	r.wishlists[wishlist.ID].Name = wishlist.Name
+	r.wishlists[wishlist.ID].ShareToken = wishlist.ShareToken
+	return nil
+}
+
+func (r *memoryWishlistRepo) AddItem(wishlistID uuid.UUID, item *entities.WishlistItem) error {
+	wishlist := r.wishlists[wishlistID]
+	if wishlist.Contains(item.ProductID, item.VariantID) {
+		return nil
+	}
+	item.Product = *r.product
+	if item.VariantID != nil {
+		item.Variant = r.product.Variant(*item.VariantID)
+	}
+	wishlist.Items = append(wishlist.Items, *item)
+	return nil
+}
+
+func (r *memoryWishlistRepo) RemoveItem(wishlistID, productID uuid.UUID, variantID *uuid.UUID) error {
+	wishlist := r.wishlists[wishlistID]
+	for i, item := range wishlist.Items {
+		if (&entities.Wishlist{Items: []entities.WishlistItem{item}}).Contains(productID, variantID) {
+			wishlist.Items = append(wishlist.Items[:i], wishlist.Items[i+1:]...)
+			return nil
+		}
+	}
+	return apperrors.ErrNotFound
+}
+
+func newWishlistUseCase(product *entities.Product) (*usecases.WishlistUseCase, *memoryWishlistRepo, *memoryCartRepo) {
+	products := &variantProductRepo{product: product}
+	wishlists := &memoryWishlistRepo{product: product, wishlists: map[uuid.UUID]*entities.Wishlist{}}
+	carts := &memoryCartRepo{cart: &entities.Cart{ID: uuid.New()}}
+	return usecases.NewWishlistUseCase(wishlists, products, usecases.NewCartUseCase(carts, products, nil, 0)), wishlists, carts
+}
+
+func TestWishlist_ItemsShowCurrentPriceAndStock(t *testing.T) {
+	product := tshirt()
+	variant := product.Variants[0]
+	uc, _, _ := newWishlistUseCase(product)
+	userID := uuid.New()
+
+	wishlist, err := uc.CreateWishlist(userID, &usecases.WishlistRequest{Name: " Birthday "})
+	require.NoError(t, err)
+	assert.Equal(t, "Birthday", wishlist.Name)
+
+	wishlist, err = uc.AddItem(wishlist.ID, userID, &usecases.AddToWishlistRequest{ProductID: product.ID, VariantID: &variant.ID})
+	require.NoError(t, err)
+	require.Len(t, wishlist.Items, 1)
+	assert.Equal(t, 25.0, wishlist.Items[0].Price)
+	assert.True(t, wishlist.Items[0].InStock)
+
+	var appErr *apperrors.Error
+	_, err = uc.AddItem(wishlist.ID, userID, &usecases.AddToWishlistRequest{ProductID: product.ID})
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperrors.CodeVariantRequired, appErr.Code)
+
+	product.Variants[0].Stock = 0
+	wishlist, err = uc.GetWishlist(wishlist.ID, userID)
+	require.NoError(t, err)
+	assert.False(t, wishlist.Items[0].InStock)
+}
+
+func TestWishlist_BelongsToItsOwner(t *testing.T) {
+	product := tshirt()
+	uc, _, _ := newWishlistUseCase(product)
+	wishlist, err := uc.CreateWishlist(uuid.New(), &usecases.WishlistRequest{Name: "Mine"})
+	require.NoError(t, err)
+
+	var appErr *apperrors.Error
+	_, err = uc.GetWishlist(wishlist.ID, uuid.New())
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperrors.CodeWishlistNotFound, appErr.Code)
+}
+
+func TestWishlist_Sharing(t *testing.T) {
+	product := tshirt()
+	uc, _, _ := newWishlistUseCase(product)
+	userID := uuid.New()
+	wishlist, err := uc.CreateWishlist(userID, &usecases.WishlistRequest{Name: "Gifts"})
+	require.NoError(t, err)
+
+	shared, err := uc.Share(wishlist.ID, userID)
+	require.NoError(t, err)
+	require.NotNil(t, shared.ShareToken)
+	first := *shared.ShareToken
+	assert.GreaterOrEqual(t, len(first), 43)
+
+	public, err := uc.GetSharedWishlist(first)
+	require.NoError(t, err)
+	assert.Equal(t, "Gifts", public.Name)
+	assert.Nil(t, public.ShareToken)
+
+	reshared, err := uc.Share(wishlist.ID, userID)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, *reshared.ShareToken)
+	_, err = uc.GetSharedWishlist(first)
+	assert.ErrorIs(t, err, apperrors.ErrNotFound, "rotating the token retires the old link")
+
+	_, err = uc.Unshare(wishlist.ID, userID)
+	require.NoError(t, err)
+	_, err = uc.GetSharedWishlist(*reshared.ShareToken)
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+}
+
+func TestWishlist_MoveToCart(t *testing.T) {
+	product := tshirt()
+	variant := product.Variants[0]
+	uc, wishlists, carts := newWishlistUseCase(product)
+	userID := uuid.New()
+	wishlist, err := uc.CreateWishlist(userID, &usecases.WishlistRequest{Name: "Later"})
+	require.NoError(t, err)
+	_, err = uc.AddItem(wishlist.ID, userID, &usecases.AddToWishlistRequest{ProductID: product.ID, VariantID: &variant.ID})
+	require.NoError(t, err)
+
+	_, err = uc.MoveToCart(wishlist.ID, userID, &usecases.MoveToCartRequest{ProductID: product.ID, VariantID: &variant.ID, Quantity: 4})
+	var appErr *apperrors.Error
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperrors.CodeInsufficientStock, appErr.Code)
+	assert.Len(t, wishlists.wishlists[wishlist.ID].Items, 1, "a failed move keeps the item")
+
+	cart, err := uc.MoveToCart(wishlist.ID, userID, &usecases.MoveToCartRequest{ProductID: product.ID, VariantID: &variant.ID})
+	require.NoError(t, err)
+	require.Len(t, cart.Items, 1)
+	assert.Equal(t, 1, carts.cart.Items[0].Quantity)
+	assert.Empty(t, wishlists.wishlists[wishlist.ID].Items)
+
+	_, err = uc.MoveToCart(wishlist.ID, userID, &usecases.MoveToCartRequest{ProductID: product.ID, VariantID: &variant.ID})
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperrors.CodeWishlistItemNotFound, appErr.Code)
+}
+
+func TestWishlistHandler_RenameTakesMergePatch(t *testing.T) {
+	uc, wishlists, _ := newWishlistUseCase(tshirt())
+	userID := uuid.New()
+	wishlist, err := uc.CreateWishlist(userID, &usecases.WishlistRequest{Name: "Mine"})
+	require.NoError(t, err)
+
+	app := fiber.New(fiber.Config{ErrorHandler: problem.ErrorHandler(logger.New())})
+	app.Patch("/wishlists/:id", func(c *fiber.Ctx) error {
+		c.Locals("user_id", userID.String())
+		return c.Next()
+	}, handlers.NewWishlistHandler(uc).RenameWishlist)
+
+	rename := func(contentType, body string) int {
+		req := httptest.NewRequest("PATCH", "/wishlists/"+wishlist.ID.String(), strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, 415, rename("application/x-www-form-urlencoded", "name=Birthday"))
+	assert.Equal(t, "Mine", wishlists.wishlists[wishlist.ID].Name)
+	assert.Equal(t, 200, rename("application/merge-patch+json", `{"name":"Birthday"}`))
+	assert.Equal(t, "Birthday", wishlists.wishlists[wishlist.ID].Name)
+}